@@ -0,0 +1,90 @@
+//go:build !noactions
+// +build !noactions
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+func init() {
+	actionHandlers["/actions/intrusion-reset"] = intrusionResetHandler
+}
+
+// sdrSensorNumberRegex matches an `ipmitool sdr type` line of the chassis
+// intrusion sensor and captures its sensor number, e.g. "0Bh".
+var sdrSensorNumberRegex = regexp.MustCompile(`^\s*Chassis\s*Intru[^|]*\|\s*([0-9A-Fa-f]{2})h\s*\|`)
+
+// intrusionResetCommands returns the ipmitool commands that clear the latched
+// chassis intrusion state of target: the command configured in the module,
+// or the standard "Re-arm Sensor Events" command for the chassis intrusion
+// sensor.
+func intrusionResetCommands(target ipmiTarget) ([][]string, error) {
+	if len(target.config.IntrusionResetCommand) > 0 {
+		return [][]string{target.config.IntrusionResetCommand}, nil
+	}
+	output, err := ipmitoolOutput(target, []string{"sdr", "type", "Physical Security"})
+	if err != nil {
+		return nil, fmt.Errorf("listing physical security sensors failed: %s", err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if match := sdrSensorNumberRegex.FindStringSubmatch(line); match != nil {
+			return [][]string{{"raw", "0x04", "0x2a", "0x" + strings.ToLower(match[1]), "0x00"}}, nil
+		}
+	}
+	return nil, fmt.Errorf("no chassis intrusion sensor found")
+}
+
+// intrusionResetHandler clears the latched chassis intrusion state of a
+// target after maintenance. It needs POST requests from an authenticated
+// user, as it changes the state of the BMC.
+func intrusionResetHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Only POST requests allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	user := requestUser(r)
+	if user == "" {
+		http.Error(w, "Actions need a listener with authentication, see web.config.file", http.StatusForbidden)
+		return
+	}
+	target := r.URL.Query().Get("target")
+	module := safeConf.ModuleForTarget(target, r.URL.Query().Get("module"))
+	if module != "default" && !safeConf.HasModule(module) {
+		http.Error(w, fmt.Sprintf("Unknown module %q", module), http.StatusBadRequest)
+		return
+	}
+
+	conf := safeConf.Config()
+	if targetIsLocal(target) && conf.DisableLocal {
+		http.Error(w, "Local collection is disabled, 'target' parameter must be specified", http.StatusBadRequest)
+		return
+	}
+	ipmiTarget := ipmiTarget{
+		host:    target,
+		address: targetResolver.resolve(conf, target, time.Time{}),
+		config:  conf.ConfigForTarget(target, module),
+	}
+	commands, err := intrusionResetCommands(ipmiTarget)
+	if err == nil {
+		for _, command := range commands {
+			if _, err = ipmitoolOutput(ipmiTarget, command); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Errorf("Chassis intrusion reset of %s by %s failed: %s", targetName(target), user, err)
+		http.Error(w, fmt.Sprintf("Chassis intrusion reset failed: %s", err), http.StatusInternalServerError)
+		return
+	}
+	log.Infof("Chassis intrusion of %s reset by %s", targetName(target), user)
+	fmt.Fprintf(w, "Chassis intrusion of %s reset\n", targetName(target))
+}
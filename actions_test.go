@@ -0,0 +1,69 @@
+//go:build !noactions
+// +build !noactions
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIntrusionResetHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipmitool_exporter")
+	if err != nil {
+		t.Fatalf("Creating mock directory failed. Reason: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "10.0.0.1"), 0755); err != nil {
+		t.Fatalf("Creating mock directory failed. Reason: %s", err)
+	}
+	for file, output := range map[string]string{
+		"sdr_type_Physical Security.txt": "Chassis Intru     | 0Bh | ok  | 23.1 | General Chassis intrusion\n",
+		"raw_0x04_0x2a_0x0b_0x00.txt":    "",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "10.0.0.1", file), []byte(output), 0644); err != nil {
+			t.Fatalf("Writing mock output failed. Reason: %s", err)
+		}
+	}
+
+	*mockDir = dir
+	savedConf := safeConf
+	safeConf = NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	defer func() {
+		*mockDir = ""
+		safeConf = savedConf
+	}()
+
+	for _, req := range []struct {
+		method, target, user string
+		expect               int
+	}{
+		{"POST", "10.0.0.1", "tech", http.StatusOK},
+		{"POST", "10.0.0.1", "", http.StatusForbidden},
+		{"GET", "10.0.0.1", "tech", http.StatusMethodNotAllowed},
+		{"POST", "10.0.0.2", "tech", http.StatusInternalServerError},
+	} {
+		r := httptest.NewRequest(req.method, "/actions/intrusion-reset?target="+req.target, nil)
+		if req.user != "" {
+			r = r.WithContext(context.WithValue(r.Context(), userContextKey, req.user))
+		}
+		w := httptest.NewRecorder()
+		intrusionResetHandler(w, r)
+		if w.Code != req.expect {
+			t.Errorf("Intrusion reset status check failed for %s %s as %q.\n Expect: %d\n Got: %d", req.method, req.target, req.user, req.expect, w.Code)
+		}
+	}
+}
+
+func TestIntrusionResetCommands(t *testing.T) {
+	target := ipmiTarget{config: IPMIConfig{IntrusionResetCommand: []string{"raw", "0x30", "0x03"}}}
+	commands, err := intrusionResetCommands(target)
+	if err != nil || len(commands) != 1 || len(commands[0]) != 3 || commands[0][1] != "0x30" {
+		t.Errorf("Configured intrusion reset command check failed.\n Expect: [[raw 0x30 0x03]]\n Got: %v (%v)", commands, err)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+// identifyingInfoRegex matches the names of fru, lan and bmc info values that
+// identify a machine, such as serial numbers, asset tags and addresses.
+var identifyingInfoRegex = regexp.MustCompile(`(?i)(serial|assettag|macaddress|ipaddress|gateway|guid)`)
+
+// anonymizeInfo applies the anonymization policy of config to the value of an
+// info metric. It returns false if the metric must be dropped.
+func anonymizeInfo(config IPMIConfig, name, value string) (string, bool) {
+	if !identifyingInfoRegex.MatchString(name) {
+		return value, true
+	}
+	switch config.Anonymize {
+	case "drop":
+		return "", false
+	case "hash":
+		// Equal values hash equally, so that joins on the label still work.
+		hash := sha256.Sum256([]byte(config.AnonymizeSalt + value))
+		return hex.EncodeToString(hash[:8]), true
+	}
+	return value, true
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAnonymizeInfo(t *testing.T) {
+	hash := IPMIConfig{Anonymize: "hash", AnonymizeSalt: "salt"}
+	first, ok := anonymizeInfo(hash, "ProductSerial", "ABC123")
+	if !ok || first == "ABC123" || len(first) != 16 {
+		t.Errorf("Hashed serial check failed.\n Expect: 16 hex digits\n Got: %q (kept: %v)", first, ok)
+	}
+	if second, _ := anonymizeInfo(hash, "ChassisSerial", "ABC123"); second != first {
+		t.Errorf("Stable hash check failed.\n Expect: %s\n Got: %s", first, second)
+	}
+	if other, _ := anonymizeInfo(IPMIConfig{Anonymize: "hash"}, "ProductSerial", "ABC123"); other == first {
+		t.Errorf("Salt was not applied to the hash")
+	}
+	if value, _ := anonymizeInfo(hash, "ProductName", "R640"); value != "R640" {
+		t.Errorf("Non-identifying value check failed.\n Expect: R640\n Got: %s", value)
+	}
+
+	drop := IPMIConfig{Anonymize: "drop"}
+	if _, ok := anonymizeInfo(drop, "MACAddress", "aa:bb:cc:dd:ee:ff"); ok {
+		t.Errorf("MAC address was not dropped")
+	}
+	if value, ok := anonymizeInfo(emptyConfig, "MACAddress", "aa:bb:cc:dd:ee:ff"); !ok || value != "aa:bb:cc:dd:ee:ff" {
+		t.Errorf("Disabled anonymization check failed.\n Expect: aa:bb:cc:dd:ee:ff\n Got: %s", value)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// benchConfig configures a load test of the exporter against a simulated
+// fleet of BMCs served by the mock backend.
+type benchConfig struct {
+	// mockDir holds the recorded outputs of one or more fake BMCs, which the
+	// simulated targets are assigned to in turn.
+	mockDir string
+	module  string
+	targets int
+	// concurrency is the number of scrapes running at the same time.
+	concurrency int
+	duration    time.Duration
+	// latency delays every simulated ipmitool call, like a real BMC.
+	latency time.Duration
+}
+
+// benchResult summarizes a load test.
+type benchResult struct {
+	duration time.Duration
+	// latencies of all scrapes, sorted.
+	latencies []time.Duration
+	// Scrapes with some or all collectors failing.
+	degraded, failed int
+	maxHeap, maxSys  uint64
+}
+
+// runBench scrapes the simulated fleet of c until its duration elapsed.
+func runBench(c benchConfig, conf *SafeConfig) (*benchResult, error) {
+	fakeBMCs, err := benchFakeBMCs(c.mockDir)
+	if err != nil {
+		return nil, err
+	}
+	fleetDir, err := ioutil.TempDir("", "ipmitool_exporter_bench")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(fleetDir)
+	targets := make([]string, c.targets)
+	for i := range targets {
+		targets[i] = fmt.Sprintf("bench-%d", i)
+		if err := os.Symlink(fakeBMCs[i%len(fakeBMCs)], mockTargetDir(fleetDir, targets[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	savedMockDir, savedLatency := *mockDir, mockLatency
+	*mockDir, mockLatency = fleetDir, c.latency
+	defer func() {
+		*mockDir, mockLatency = savedMockDir, savedLatency
+	}()
+
+	var (
+		result = &benchResult{}
+		mtx    sync.Mutex
+		next   uint64
+		wg     sync.WaitGroup
+	)
+	stop, sampled := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(sampled)
+		var stats runtime.MemStats
+		for {
+			runtime.ReadMemStats(&stats)
+			mtx.Lock()
+			if stats.HeapAlloc > result.maxHeap {
+				result.maxHeap = stats.HeapAlloc
+			}
+			if stats.Sys > result.maxSys {
+				result.maxSys = stats.Sys
+			}
+			mtx.Unlock()
+			select {
+			case <-stop:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}()
+
+	start := time.Now()
+	end := start.Add(c.duration)
+	for w := 0; w < c.concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(end) {
+				target := targets[atomic.AddUint64(&next, 1)%uint64(len(targets))]
+				summary := &scrapeSummary{}
+				registry := prometheus.NewRegistry()
+				registry.MustRegister(collector{target: target, module: c.module, config: conf, summary: summary})
+				scrapeStart := time.Now()
+				_, err := registry.Gather()
+				latency := time.Since(scrapeStart)
+
+				mtx.Lock()
+				result.latencies = append(result.latencies, latency)
+				switch {
+				case err != nil || summary.allCollectorsFailed():
+					result.failed++
+				case !summary.up:
+					result.degraded++
+				}
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	close(stop)
+	<-sampled
+	result.duration = time.Since(start)
+	sort.Slice(result.latencies, func(i, j int) bool { return result.latencies[i] < result.latencies[j] })
+	return result, nil
+}
+
+// benchFakeBMCs returns the directories of the fake BMCs in dir.
+func benchFakeBMCs(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var fakeBMCs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			path, err := filepath.Abs(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			fakeBMCs = append(fakeBMCs, path)
+		}
+	}
+	if len(fakeBMCs) == 0 {
+		return nil, fmt.Errorf("no fake BMCs in %s", dir)
+	}
+	return fakeBMCs, nil
+}
+
+// percentile returns the p-th percentile (0 < p <= 1) of the sorted
+// latencies.
+func (r *benchResult) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.latencies))*p+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	return r.latencies[i]
+}
+
+func (r *benchResult) report(w io.Writer) {
+	scrapes := len(r.latencies)
+	fmt.Fprintf(w, "Scrapes:          %d in %s (%.1f/s)\n", scrapes, r.duration.Round(time.Millisecond), float64(scrapes)/r.duration.Seconds())
+	fmt.Fprintf(w, "Failed scrapes:   %d (all collectors failed), %d degraded (some collectors failed)\n", r.failed, r.degraded)
+	fmt.Fprintf(w, "Scrape latency:   p50 %s, p90 %s, p99 %s, max %s\n", r.percentile(0.5).Round(time.Microsecond), r.percentile(0.9).Round(time.Microsecond), r.percentile(0.99).Round(time.Microsecond), r.percentile(1).Round(time.Microsecond))
+	fmt.Fprintf(w, "Memory:           max heap %.1f MiB, max from OS %.1f MiB\n", float64(r.maxHeap)/(1<<20), float64(r.maxSys)/(1<<20))
+}
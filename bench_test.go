@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunBench(t *testing.T) {
+	conf := NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	res, err := runBench(benchConfig{
+		mockDir:     e2eDir,
+		module:      "default",
+		targets:     6,
+		concurrency: 2,
+		duration:    100 * time.Millisecond,
+	}, conf)
+	if err != nil {
+		t.Fatalf("runBench() call failed. Reason: %s", err)
+	}
+	if len(res.latencies) == 0 || res.failed != 0 {
+		t.Errorf("Load test check failed.\n Expect: some scrapes, none failed\n Got: %d scrapes, %d failed", len(res.latencies), res.failed)
+	}
+	if *mockDir != "" {
+		t.Errorf("Mock directory was not restored.\n Expect: \"\"\n Got: %q", *mockDir)
+	}
+	var report bytes.Buffer
+	res.report(&report)
+	if !strings.Contains(report.String(), "p99") {
+		t.Errorf("Load test report check failed.\n Expect: latency percentiles\n Got:\n%s", report.String())
+	}
+
+	empty, err := ioutil.TempDir("", "ipmitool_exporter")
+	if err != nil {
+		t.Fatalf("Creating mock directory failed. Reason: %s", err)
+	}
+	defer os.RemoveAll(empty)
+	if _, err := runBench(benchConfig{mockDir: empty, targets: 1, concurrency: 1}, conf); err == nil {
+		t.Errorf("Load test without fake BMCs was started")
+	}
+}
+
+func TestBenchPercentile(t *testing.T) {
+	res := &benchResult{}
+	for i := 1; i <= 100; i++ {
+		res.latencies = append(res.latencies, time.Duration(i)*time.Millisecond)
+	}
+	for p, expect := range map[float64]time.Duration{0.5: 50 * time.Millisecond, 0.99: 99 * time.Millisecond, 1: 100 * time.Millisecond} {
+		if got := res.percentile(p); got != expect {
+			t.Errorf("Percentile check failed for %v.\n Expect: %s\n Got: %s", p, expect, got)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"math"
+	"context"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -20,236 +22,234 @@ const (
 	targetLocal = ""
 )
 
-var (
-	fruBoardDateRegex     = regexp.MustCompile(`\sBoard\sMfg\sDate\s*:\s*(?P<value>.*)`)
-	ipmiCurrentPowerRegex = regexp.MustCompile(`^Chassis\s*Power\s*is\s*(?P<value>on|off*)`)
-	ipSourceRegex         = regexp.MustCompile(`^IP\sAddress\sSource\s*:\s*(?P<value>.*)`)
-	macAddressRegex       = regexp.MustCompile(`^MAC\sAddress\s*:\s*(?P<value>.*)`)
-	defaultGatewayRegex   = regexp.MustCompile(`^Default\sGateway\sIP\s*:\s*(?P<value>.*)`)
-	vlanIDRegex           = regexp.MustCompile(`^802.1q\sVLAN\sID\s*:\s*(?P<value>.*)`)
-	vlanPriorityRegex     = regexp.MustCompile(`^802.1q\sVLAN\sPriority\s*:\s*(?P<value>.*)`)
-	subnetMaskRegex       = regexp.MustCompile(`^Subnet\sMask\s*:\s*(?P<value>.*)`)
-	firmwareRevRegex      = regexp.MustCompile(`^Firmware\sRevision\s*:\s*(?P<value>.*)`)
-	ipmiVersionRegex      = regexp.MustCompile(`^IPMI\sVersion\s*:\s*(?P<value>.*)`)
-	manufacturerRegex     = regexp.MustCompile(`^Manufacturer\sName\s*:\s*(?P<value>.*)`)
-	dcmiAvgPowerRegex     = regexp.MustCompile(`^\s*Average\spower\sreading\sover\ssample\speriod:\s*(?P<value>.*) Watts`)
-	dcmiInstaPowerRegex   = regexp.MustCompile(`^\s*Instantaneous\spower\sreading:\s*(?P<value>.*) Watts`)
-	dcmiMinPowerRegex     = regexp.MustCompile(`^\s*Minimum\sduring\ssampling\speriod:\s*(?P<value>.*) Watts`)
-	dcmiMaxPowerRegex     = regexp.MustCompile(`^\s*Maximum\sduring\ssampling\speriod:\s*(?P<value>.*) Watts`)
-)
+// ipmiCollector is implemented by every collector that can be enabled in a
+// module. Collectors register themselves with registerCollector from an init
+// function in their own file, so adding a collector requires no changes to
+// the core.
+type ipmiCollector interface {
+	// Name is used in the module configuration and as the collector label
+	// of ipmi_up.
+	Name() string
+	// Commands returns the ipmitool commands to run, without the connection
+	// options.
+	Commands(config IPMIConfig) [][]string
+	// Parse turns the outputs of the commands, in the order returned by
+	// Commands, into collector-specific data.
+	Parse(outputs []string) (interface{}, error)
+	// Emit sends the metrics for the data returned by Parse.
+	Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{})
+}
 
-type fruData struct {
-	Name  string
-	Value string
+// exitStatusIgnorer may be implemented by collectors whose ipmitool commands
+// exit with a non-zero status even if they succeeded.
+type exitStatusIgnorer interface {
+	IgnoreExitStatus() bool
 }
 
-type lanData struct {
-	Name  string
-	Value string
+// scrapeIntervalHinter may be implemented by collectors whose data changes
+// slowly enough that they don't need to run on every scrape, such as inventory
+// and configuration data, which rarely changes and is hinted an hour.
+// Collectors that don't implement it are hinted defaultScrapeInterval.
+type scrapeIntervalHinter interface {
+	ScrapeInterval() time.Duration
 }
 
-type sensorData struct {
-	Name  string
-	Value float64
-	Type  string
-	State string
+// defaultScrapeInterval is the recommended scrape interval of collectors
+// returning frequently changing data, such as sensor readings.
+const defaultScrapeInterval = 30 * time.Second
+
+// ipmi20Requirer may be implemented by collectors that need IPMI 2.0
+// features. They are skipped in modules for legacy IPMI 1.5 devices.
+type ipmi20Requirer interface {
+	RequiresIPMI20() bool
 }
 
-type dcmiPowerData struct {
-	Name  string
-	Value float64
+// recordCounter may be implemented by collectors whose parsed data holds a
+// varying number of records, e.g. one per sensor, to report them in
+// ipmi_collector_records. Collectors that don't implement it parse a single
+// record.
+type recordCounter interface {
+	Records(data interface{}) int
 }
 
-type fwumData struct {
-	Name  string
-	Value float64
+// configValidator may be implemented by collectors with module settings of
+// their own, to validate them when the config is loaded if the collector is
+// enabled.
+type configValidator interface {
+	ValidateConfig(config IPMIConfig) error
 }
 
-type bmcData struct {
-	Name  string
-	Value string
+// registeredCollectors holds all collectors available to modules by name.
+var registeredCollectors = make(map[string]ipmiCollector)
+
+func registerCollector(c ipmiCollector) {
+	if _, ok := registeredCollectors[c.Name()]; ok {
+		panic(fmt.Sprintf("collector %s registered twice", c.Name()))
+	}
+	registeredCollectors[c.Name()] = c
+	// Start the parse failures of every collector at 0, so that their
+	// increase can be alerted on.
+	parseFailures.WithLabelValues(c.Name())
+}
+
+// collectorNames returns the sorted names of all registered collectors.
+func collectorNames() []string {
+	var names []string
+	for name := range registeredCollectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 type collector struct {
 	target string
 	module string
 	config *SafeConfig
+	// collectors replaces the collectors of the module if not nil.
+	collectors []string
+	// deadline is when ipmitool calls of the scrape are killed, if set.
+	deadline time.Time
+	// summary receives the results of the scrape if not nil.
+	summary *scrapeSummary
 }
 
 type ipmiTarget struct {
-	host   string
-	config IPMIConfig
+	host string
+	// module is the name of the module the target is scraped with.
+	module string
+	// address is passed to ipmitool instead of host if set.
+	address string
+	config  IPMIConfig
+	// summary collects the fleet-wide relevant results of the scrape.
+	summary *scrapeSummary
+	// deadline is when ipmitool calls are killed, if set.
+	deadline time.Time
+}
+
+// collectorGroups are names for sets of collectors that can be scraped
+// together at /metrics/<group>.
+var collectorGroups = map[string][]string{
+	"inventory": {"fru", "bmc", "bmc-guid", "lan", "fwum", "dcmi-asset"},
+}
+
+// collectorsByName returns the registered collectors of the group or the
+// collector with the given name.
+func collectorsByName(name string) ([]string, bool) {
+	if group, ok := collectorGroups[name]; ok {
+		var names []string
+		for _, n := range group {
+			if _, ok := registeredCollectors[n]; ok {
+				names = append(names, n)
+			}
+		}
+		return names, len(names) > 0
+	}
+	if _, ok := registeredCollectors[name]; ok {
+		return []string{name}, true
+	}
+	return nil, false
 }
 
 var (
-	sensorStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "sensor", "state"),
-		"Indicates the severity of the state reported by an IPMI sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
-		[]string{"name", "type"},
-		nil,
-	)
-
-	sensorValueDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "sensor", "value"),
-		"Generic data read from an IPMI sensor of unknown type, relying on labels for context.",
-		[]string{"name", "type"},
-		nil,
-	)
-
-	chassisIntrusionDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "chassis_int", "value"),
-		"State of Chassis Intrusion.",
-		[]string{"name"},
-		nil,
-	)
-
-	chassisIntrusionStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "chassis_int", "state"),
-		"Reported state of a Chassis Intrusion (0=ok, 1=intrusion).",
-		[]string{"name"},
-		nil,
-	)
-
-	chassisPowerDeviceDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "chassis_power_dev", "value"),
-		"Chassis Power Supply device status (0=missing, 1=present).",
-		[]string{"name"},
-		nil,
-	)
-
-	chassisPowerDeviceStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "chassis_power_dev", "state"),
-		"Reported state of a Power Supply (0=missing, 1=present).",
-		[]string{"name"},
-		nil,
-	)
-
-	chassisPowerStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "power", "state"),
-		"Reported Chassis Power State (0=off, 1=on).",
-		[]string{"name"},
-		nil,
-	)
-
-	fanSpeedDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "fan_speed", "rpm"),
-		"Fan speed in rotations per minute.",
-		[]string{"name"},
-		nil,
-	)
-
-	fanSpeedStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "fan_speed", "state"),
-		"Reported state of a fan speed sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
-		[]string{"name"},
+	upDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "up"),
+		"'1' if a scrape of the IPMI device was successful, '0' otherwise.",
+		[]string{"collector"},
 		nil,
 	)
 
-	temperatureDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "temperature", "celsius"),
-		"Temperature reading in degree Celsius.",
-		[]string{"name"},
+	sessionSetupDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "session_setup_duration", "seconds"),
+		"Time it took to establish an IPMI session with the target and run a \"mc info\" probe in seconds.",
 		nil,
-	)
-
-	temperatureStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "temperature", "state"),
-		"Reported state of a temperature sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
-		[]string{"name"},
 		nil,
 	)
 
-	voltageDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "voltage", "volts"),
-		"Voltage reading in Volts.",
-		[]string{"name"},
+	collectorErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collector", "error"),
+		"'1' if the collector failed at the stage (command or parse), '0' otherwise.",
+		[]string{"collector", "stage"},
 		nil,
 	)
 
-	voltageStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "voltage", "state"),
-		"Reported state of a voltage sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
-		[]string{"name"},
+	collectorRecordsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collector", "records"),
+		"Number of records, e.g. sensors, the collector parsed from the ipmitool output.",
+		[]string{"collector"},
 		nil,
 	)
 
-	currentDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "current", "amperes"),
-		"Current reading in Amperes.",
-		[]string{"name"},
+	collectorDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collector", "duration_seconds"),
+		"How long running the collector took in seconds.",
+		[]string{"collector"},
 		nil,
 	)
 
-	currentStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "current", "state"),
-		"Reported state of a current sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
-		[]string{"name"},
+	collectorsFailedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collectors", "failed"),
+		"Number of collectors that failed in the scrape, i.e. with ipmi_up 0.",
 		nil,
-	)
-
-	powerDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "power", "watts"),
-		"Power reading in Watts.",
-		[]string{"name"},
 		nil,
 	)
 
-	powerStateDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "sensor_power", "state"),
-		"Reported state of a power sensor (1=ok, 0=critical).",
-		[]string{"name"},
+	durationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape_duration", "seconds"),
+		"Returns how long the scrape took to complete in seconds.",
 		nil,
-	)
-
-	powerConsumptionDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "dcmi", "power_consumption_watts"),
-		"Current power consumption in Watts.",
-		[]string{"name"},
 		nil,
 	)
 
-	fwumInfo = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "fwum", "info"),
-		"Constant metric with value '1' providing details about the BMC.",
-		[]string{"firmware_revision", "manufacturer_id"},
+	scrapePhaseDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "scrape_phase_duration", "seconds"),
+		"How long the scrape spent in each phase in seconds, summed over all collectors.",
+		[]string{"phase"},
 		nil,
 	)
 
-	bmcInfo = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "bmc", "info"),
-		"Constant metric with value '1' providing details about the BMC.",
-		[]string{"name", "value"},
-		nil,
-	)
+	parseFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "parse_failures_total",
+		Help:      "Number of lines of ipmitool output a collector couldn't parse, e.g. sensor lines with too few columns, and of outputs it failed to parse as a whole.",
+	}, []string{"collector"})
+)
 
-	fruInfo = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "fru", "info"),
-		"Constant metric with value '1' providing details from FRU.",
-		[]string{"name", "value"},
-		nil,
-	)
+// scrapePhases are the phases a scrape spends its time in, in the order they
+// are reported. "other" is the remainder of the scrape duration, e.g. waiting
+// for the remote config or skipped collectors.
+var scrapePhases = []string{"session_setup", "vendor_detection", "command", "parse", "emit", "other"}
 
-	lanInfo = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "lan", "info"),
-		"Constant metric with value '1' providing details from LAN.",
-		[]string{"name", "value"},
-		nil,
-	)
+// scrapeBudget accounts the time of a scrape to its phases.
+type scrapeBudget map[string]time.Duration
 
-	upDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "", "up"),
-		"'1' if a scrape of the IPMI device was successful, '0' otherwise.",
-		[]string{"collector"},
-		nil,
-	)
+// add accounts the time of a collector run to the command, parse and emit
+// phases.
+func (b scrapeBudget) add(result collectorResult) {
+	b["command"] += result.CommandDuration
+	b["parse"] += result.ParseDuration
+	b["emit"] += result.EmitDuration
+}
 
-	durationDesc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "scrape_duration", "seconds"),
-		"Returns how long the scrape took to complete in seconds.",
-		nil,
-		nil,
-	)
-)
+// collect emits the time spent per phase, given the total scrape duration.
+func (b scrapeBudget) collect(ch chan<- prometheus.Metric, total time.Duration) {
+	other := total
+	for _, phase := range scrapePhases {
+		other -= b[phase]
+	}
+	if other < 0 {
+		other = 0
+	}
+	b["other"] = other
+	for _, phase := range scrapePhases {
+		ch <- prometheus.MustNewConstMetric(
+			scrapePhaseDurationDesc,
+			prometheus.GaugeValue,
+			b[phase].Seconds(),
+			phase,
+		)
+	}
+}
 
 func ipmitoolConfig(config IPMIConfig) []string {
 	var args []string
@@ -271,695 +271,365 @@ func ipmitoolConfig(config IPMIConfig) []string {
 	return args
 }
 
-func ipmitoolOutput(target ipmiTarget, command string) (string, error) {
-	var cmdCommand []string
+// ipmitoolArgs returns the ipmitool argument vector used to run command
+// against target.
+func ipmitoolArgs(target ipmiTarget, command []string) []string {
 	cmdConfig := ipmitoolConfig(target.config)
-	switch command {
-	case "sensor":
-		cmdCommand = append(cmdCommand, "sensor", "list")
-	case "fru":
-		cmdCommand = append(cmdCommand, "fru", "list")
-	case "power":
-		cmdCommand = append(cmdCommand, "power", "status")
-	case "fwum":
-		cmdCommand = append(cmdCommand, "fwum", "info")
-	case "bmc":
-		cmdCommand = append(cmdCommand, "bmc", "info")
-	case "lan":
-		cmdCommand = append(cmdCommand, "lan", "print")
-	case "dcmi-power":
-		cmdCommand = append(cmdCommand, "dcmi", "power", "reading", "1_min")
-	default:
-		log.Errorf("Unknown ipmitool command: '%s'\n", command)
-		cmdCommand = append(cmdCommand, "")
-	}
-
-	if target.host != "" {
-		cmdConfig = append(cmdConfig, "-H", target.host)
-	}
-	cmdConfig = append(cmdConfig, cmdCommand...)
-
-	cmd := exec.Command("ipmitool", cmdConfig...)
-	var outBuf bytes.Buffer
-	cmd.Stdout = &outBuf
-	cmd.Stderr = &outBuf
-	err := cmd.Run()
-	if err != nil {
-		if command == "fwum" {
-			// Because fwum return exit code 1 even if everything is OK.
-			// Be carefull with it and properly check command output later
-			// Yes, i know that next piece of code sucks, but can`t do anything
-			// with it right now
-			if exiterr, ok := err.(*exec.ExitError); ok {
-				if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-					log.Debugf("Exit status of FWUM %d, but it was suppressed", status.ExitStatus())
-				}
-			}
-		} else {
-			log.Errorf("Error while calling %s for %s: %s", command, targetName(target.host), cmd)
-			//log.Fatal(err)
+	if target.config.Legacy && !targetIsLocal(target.host) {
+		// IPMI 1.5 BMCs only support the lan interface and MD5
+		// authentication.
+		if target.config.Interface == "" {
+			cmdConfig = append(cmdConfig, "-I", "lan")
 		}
+		cmdConfig = append(cmdConfig, "-A", "MD5")
 	}
-	return outBuf.String(), err
-}
-
-func splitSensorOutput(impitoolOutput string) ([]sensorData, error) {
-	var result []sensorData
-
-	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
-
-	var err error
-
-	for scanner.Scan() {
-		var data sensorData
-		line := scanner.Text()
-		if len(line) > 0 {
-			trimmedL := strings.ReplaceAll(line, " ", "")
-			splittedL := strings.Split(trimmedL, "|")
-			data.Name = splittedL[0]
-			valueS := splittedL[1]
-			convValueS, convErr := strconv.ParseUint(valueS, 0, 64)
-			if valueS != "na" && convErr != nil {
-				data.Value, err = strconv.ParseFloat(valueS, 64)
-				if err != nil {
-					continue
-				}
-			} else if valueS != "na" && convErr == nil {
-				data.Value = float64(convValueS)
-			} else {
-				data.Value = math.NaN()
-			}
-			data.Type = splittedL[2]
-			data.State = splittedL[3]
-			result = append(result, data)
-		}
+	if target.address != "" {
+		cmdConfig = append(cmdConfig, "-H", target.address)
+	} else if target.host != "" {
+		cmdConfig = append(cmdConfig, "-H", target.host)
 	}
-	return result, err
+	return append(cmdConfig, command...)
 }
 
-func splitDcmiPowerOutput(impitoolOutput string) ([]dcmiPowerData, error) {
-	var result []dcmiPowerData
-
-	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
-
-	var err error
-
-	for scanner.Scan() {
-		var data dcmiPowerData
-		line := scanner.Text()
-		if len(line) > 0 {
-			dcmiAvgPower := dcmiAvgPowerRegex.FindStringSubmatch(line)
-			if dcmiAvgPower != nil {
-				for i, name := range dcmiAvgPowerRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "Avg power consumption"
-					data.Value, err = strconv.ParseFloat(dcmiAvgPower[i], 64)
-					if err != nil {
-						continue
-					}
-					result = append(result, data)
-				}
-			}
-			dcmiMinPower := dcmiMinPowerRegex.FindStringSubmatch(line)
-			if dcmiMinPower != nil {
-				for i, name := range dcmiMinPowerRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "Min power consumption"
-					data.Value, err = strconv.ParseFloat(dcmiMinPower[i], 64)
-					if err != nil {
-						continue
-					}
-					result = append(result, data)
-				}
-			}
-			dcmiMaxPower := dcmiMaxPowerRegex.FindStringSubmatch(line)
-			if dcmiMaxPower != nil {
-				for i, name := range dcmiMaxPowerRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "Max power consumption"
-					data.Value, err = strconv.ParseFloat(dcmiMaxPower[i], 64)
-					if err != nil {
-						continue
-					}
-					result = append(result, data)
-				}
-			}
-			dcmiInstaPower := dcmiInstaPowerRegex.FindStringSubmatch(line)
-			if dcmiInstaPower != nil {
-				for i, name := range dcmiInstaPowerRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "Instantaneous power consumption"
-					data.Value, err = strconv.ParseFloat(dcmiInstaPower[i], 64)
-					if err != nil {
-						continue
-					}
-					result = append(result, data)
-				}
-			}
+// maskedArgs returns a copy of args with the password replaced, suitable for
+// logging and display.
+func maskedArgs(args []string) []string {
+	masked := make([]string, len(args))
+	copy(masked, args)
+	for i := 0; i < len(masked)-1; i++ {
+		if masked[i] == "-P" {
+			masked[i+1] = "******"
 		}
 	}
-	return result, err
+	return masked
 }
 
-func splitFwumOutput(impitoolOutput string) ([]fwumData, error) {
-	var result []fwumData
-
-	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
-
-	var err error
-
-	for scanner.Scan() {
-		var data fwumData
-		line := scanner.Text()
-		trimmedL := strings.ReplaceAll(line, " ", "")
-		re := regexp.MustCompile(`:`)
-		sanitizedL := re.FindStringSubmatch(trimmedL)
-		if sanitizedL != nil {
-			splittedL := strings.Split(trimmedL, ":")
-			data.Name = splittedL[0]
-			data.Value, err = strconv.ParseFloat(splittedL[1], 64)
-			if err != nil {
-				return result, err
-			}
-		}
-		result = append(result, data)
+// ipmitoolCommand returns the executable and arguments that run ipmitool with
+// args, honouring the configured executables path and execution prefix. The
+// prefix allows running ipmitool as a less privileged user owning access to
+// the local IPMI device instead of the exporter itself.
+func ipmitoolCommand(path, prefix string, args []string) (string, []string) {
+	ipmitool := "ipmitool"
+	if path != "" {
+		ipmitool = filepath.Join(path, ipmitool)
 	}
-	return result, err
+	argv := append(strings.Fields(prefix), ipmitool)
+	argv = append(argv, args...)
+	return argv[0], argv[1:]
 }
 
-func splitBmcOutput(impitoolOutput string) ([]bmcData, error) {
-	var result []bmcData
-
-	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
-
-	var err error
+// ipmitoolEnviron returns the environment ipmitool runs with: the PATH of
+// the exporter, for the execution prefix, and the C locale, as the collectors
+// parse untranslated output, followed by the configured variables, which
+// take precedence.
+func ipmitoolEnviron(extra []string) []string {
+	env := []string{"PATH=" + os.Getenv("PATH"), "LC_ALL=C", "LANG=C"}
+	return append(env, extra...)
+}
 
-	for scanner.Scan() {
-		var data bmcData
-		line := scanner.Text()
-		if len(line) > 0 {
-			firmwareRev := firmwareRevRegex.FindStringSubmatch(line)
-			if firmwareRev != nil {
-				for i, name := range firmwareRevRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "FirmwareRevision"
-					data.Value = firmwareRev[i]
-					result = append(result, data)
-					break
-				}
-				continue
-			}
-			ipmiVersion := ipmiVersionRegex.FindStringSubmatch(line)
-			if ipmiVersion != nil {
-				for i, name := range ipmiVersionRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "IPMIVersion"
-					data.Value = ipmiVersion[i]
-					result = append(result, data)
-					break
-				}
-				continue
-			}
-			manufacturer := manufacturerRegex.FindStringSubmatch(line)
-			if manufacturer != nil {
-				for i, name := range manufacturerRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "Manufacturer"
-					data.Value = manufacturer[i]
-					result = append(result, data)
-					break
-				}
-				break
-			}
-		}
+func ipmitoolOutput(target ipmiTarget, command []string) (string, error) {
+	if *mockDir != "" {
+		return mockOutput(*mockDir, target.host, command)
+	}
+	ctx := context.Background()
+	if !target.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, target.deadline)
+		defer cancel()
 	}
-	return result, err
+	name, args := ipmitoolCommand(*executablesPath, *execPrefix, ipmitoolArgs(target, command))
+	cmd := exec.Command(name, args...)
+	cmd.Env = ipmitoolEnviron(*ipmitoolEnv)
+	output, err := childProcesses.run(ctx, cmd, target.deadline, *ipmitoolMaxRuntime)
+	return string(output), err
 }
 
-func splitFruOutput(impitoolOutput string) ([]fruData, error) {
-	var result []fruData
-
-	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+// collectorResult describes the outcome of running a collector against a
+// target.
+type collectorResult struct {
+	// Records is the number of records parsed from the output, e.g. sensors.
+	Records int
+	// CommandErr is set if an ipmitool command failed.
+	CommandErr error
+	// ParseErr is set if the output couldn't be parsed.
+	ParseErr error
+	// Duration is how long running the commands, parsing and emitting took.
+	Duration time.Duration
+	// CommandDuration, ParseDuration and EmitDuration split Duration into
+	// running the commands, parsing their output and emitting the metrics.
+	CommandDuration, ParseDuration, EmitDuration time.Duration
+}
 
-	var err error
-	for scanner.Scan() {
-		var data fruData
-		line := scanner.Text()
-		if len(line) > 0 {
-			boardDate := fruBoardDateRegex.FindStringSubmatch(line)
-			if boardDate != nil {
-				for i, name := range fruBoardDateRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "BoardMfgDate"
-					data.Value = boardDate[i]
-					result = append(result, data)
-					break
-				}
-				continue
-			}
-			trimmedL := strings.ReplaceAll(line, " ", "")
-			splittedL := strings.Split(trimmedL, ":")
-			data.Name = splittedL[0]
-			data.Value = splittedL[1]
-			result = append(result, data)
-		}
+// up returns 1 if the collector succeeded and 0 otherwise.
+func (r collectorResult) up() int {
+	if r.CommandErr != nil || r.ParseErr != nil {
+		return 0
 	}
-	return result, err
+	return 1
 }
 
-func splitLANOutput(impitoolOutput string) ([]lanData, error) {
-	var result []lanData
-
-	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+// runCollector runs the commands of c against target and emits the metrics
+// parsed from their output.
+func runCollector(ch chan<- prometheus.Metric, c ipmiCollector, target ipmiTarget) (result collectorResult) {
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
 
-	var err error
-	for scanner.Scan() {
-		var data lanData
-		line := scanner.Text()
-		if len(line) > 0 {
-			ipSource := ipSourceRegex.FindStringSubmatch(line)
-			if ipSource != nil {
-				for i, name := range ipSourceRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "IPSource"
-					data.Value = strings.ReplaceAll(ipSource[i], " ", "")
-					result = append(result, data)
-					break
-				}
-				continue
-			}
-			subnetMask := subnetMaskRegex.FindStringSubmatch(line)
-			if subnetMask != nil {
-				for i, name := range subnetMaskRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "SubnetMask"
-					data.Value = subnetMask[i]
-					result = append(result, data)
-					break
-				}
-				continue
-			}
-			macMatch := macAddressRegex.FindStringSubmatch(line)
-			if macMatch != nil {
-				for i, name := range macAddressRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "MACAddress"
-					data.Value = macMatch[i]
-					result = append(result, data)
-					break
-				}
-				continue
-			}
-			defGateway := defaultGatewayRegex.FindStringSubmatch(line)
-			if defGateway != nil {
-				for i, name := range defaultGatewayRegex.SubexpNames() {
-					if name != "value" {
-						continue
+	var outputs []string
+	for _, command := range c.Commands(target.config) {
+		output, err := ipmitoolOutput(target, command)
+		if err != nil {
+			if ignorer, ok := c.(exitStatusIgnorer); ok && ignorer.IgnoreExitStatus() {
+				if exiterr, ok := err.(*exec.ExitError); ok {
+					if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+						log.Debugf("Exit status of %s %d, but it was suppressed", c.Name(), status.ExitStatus())
 					}
-					data.Name = "DefaultGateway"
-					data.Value = defGateway[i]
-					result = append(result, data)
-					break
 				}
-				continue
-			}
-			vlanID := vlanIDRegex.FindStringSubmatch(line)
-			if vlanID != nil {
-				for i, name := range vlanIDRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "VLANID"
-					data.Value = vlanID[i]
-					result = append(result, data)
-					break
-				}
-				continue
-			}
-			vlanPriority := vlanPriorityRegex.FindStringSubmatch(line)
-			if vlanPriority != nil {
-				for i, name := range vlanPriorityRegex.SubexpNames() {
-					if name != "value" {
-						continue
-					}
-					data.Name = "VLANPriority"
-					data.Value = vlanPriority[i]
-					result = append(result, data)
-					break
+			} else {
+				if usesLocalInterface(target) {
+					target.summary.setLocalInterfaceProblem(localInterfaceProblem(output))
 				}
-				break
+				log.Debugf("Output of ipmitool %s for %s: %s", c.Name(), targetName(target.host), output)
+				result.CommandErr = fmt.Errorf("ipmitool %s: %s", strings.Join(maskedArgs(ipmitoolArgs(target, command)), " "), err)
+				result.CommandDuration = time.Since(start)
+				return result
 			}
 		}
+		outputs = append(outputs, output)
 	}
-	return result, err
-}
+	result.CommandDuration = time.Since(start)
 
-func getChassisPowerState(ipmitoolOutput string) (int, error) {
-	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
-
-	var err error
+	parseStart := time.Now()
+	data, err := c.Parse(outputs)
+	result.ParseDuration = time.Since(parseStart)
+	if err != nil {
+		parseFailures.WithLabelValues(c.Name()).Inc()
+		result.ParseErr = err
+		return result
+	}
+	result.Records = parsedRecords(c, data)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if len(line) > 0 {
-			value := ipmiCurrentPowerRegex.FindStringSubmatch(line)[1]
-			if value == "on" {
-				return 1, err
-			}
-		}
+	emitStart := time.Now()
+	if *metricTimestamps {
+		emitCollectedAt(ch, c, target, data, start)
+	} else {
+		c.Emit(ch, target, data)
 	}
-	return 0, err
+	result.EmitDuration = time.Since(emitStart)
+	return result
 }
 
-// Describe implements Prometheus.Collector.
-func (c collector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- sensorStateDesc
-	ch <- sensorValueDesc
-	ch <- fanSpeedDesc
-	ch <- temperatureDesc
-	ch <- powerConsumptionDesc
-	ch <- upDesc
-	ch <- durationDesc
-	ch <- chassisPowerDeviceDesc
-	ch <- chassisIntrusionDesc
+// parsedRecords returns the number of records in data parsed by c.
+func parsedRecords(c ipmiCollector, data interface{}) int {
+	if counter, ok := c.(recordCounter); ok {
+		return counter.Records(data)
+	}
+	if data == nil {
+		return 0
+	}
+	return 1
 }
 
-func collectTypedSensor(ch chan<- prometheus.Metric, desc, stateDesc *prometheus.Desc, state float64, data sensorData) {
+// collectResult logs the outcome of running the collector name and emits the
+// metrics describing it.
+func collectResult(ch chan<- prometheus.Metric, name string, target ipmiTarget, result collectorResult) {
+	switch {
+	case result.CommandErr != nil:
+		log.Errorf("Error while calling %s for %s: %s", name, targetName(target.host), result.CommandErr)
+	case result.ParseErr != nil:
+		log.Errorf("Failed to parse ipmitool %s data from %s: %s", name, targetName(target.host), result.ParseErr)
+	}
+	markCollectorUp(ch, name, result.up())
 	ch <- prometheus.MustNewConstMetric(
-		desc,
+		collectorErrorDesc,
 		prometheus.GaugeValue,
-		data.Value,
-		data.Name,
+		boolToFloat(result.CommandErr != nil),
+		name, "command",
 	)
 	ch <- prometheus.MustNewConstMetric(
-		stateDesc,
+		collectorErrorDesc,
 		prometheus.GaugeValue,
-		state,
-		data.Name,
+		boolToFloat(result.ParseErr != nil),
+		name, "parse",
 	)
-}
-
-func collectGenericSensor(ch chan<- prometheus.Metric, state float64, data sensorData) {
 	ch <- prometheus.MustNewConstMetric(
-		sensorValueDesc,
+		collectorRecordsDesc,
 		prometheus.GaugeValue,
-		data.Value,
-		data.Name,
-		data.Type,
+		float64(result.Records),
+		name,
 	)
 	ch <- prometheus.MustNewConstMetric(
-		sensorStateDesc,
+		collectorDurationDesc,
 		prometheus.GaugeValue,
-		state,
-		data.Name,
-		data.Type,
+		result.Duration.Seconds(),
+		name,
 	)
 }
 
-func collectSensorMonitoring(ch chan<- prometheus.Metric, target ipmiTarget) (int, error) {
-	output, err := ipmitoolOutput(target, "sensor")
-	if err != nil {
-		log.Errorf("Failed to collect ipmitool sensor data from %s: %s", targetName(target.host), err)
-		return 0, err
-	}
-	results, err := splitSensorOutput(output)
-	if err != nil {
-		log.Errorf("Failed to parse ipmitool sensor data from %s: %s", targetName(target.host), err)
-		return 0, err
-	}
-	for _, data := range results {
-		var state float64
-
-		switch data.State {
-		case "ok":
-			state = 0
-		case "cr":
-			state = 1
-		case "nr":
-			state = 2
-		case "nc":
-			state = 3
-		case "ns":
-			state = 4
-		case "0x0000":
-			state = 0
-		case "0x0100":
-			state = 1
-		case "na":
-			state = math.NaN()
-		default:
-			log.Errorf("Unknown sensor state: '%s'\n", data.State)
-			state = math.NaN()
-		}
-
-		switch data.Type {
-		case "RPM":
-			collectTypedSensor(ch, fanSpeedDesc, fanSpeedStateDesc, state, data)
-		case "degrees C":
-			collectTypedSensor(ch, temperatureDesc, temperatureStateDesc, state, data)
-		case "Ampers":
-			collectTypedSensor(ch, currentDesc, currentStateDesc, state, data)
-		case "Volts":
-			collectTypedSensor(ch, voltageDesc, voltageStateDesc, state, data)
-		case "Watts":
-			collectTypedSensor(ch, powerDesc, powerStateDesc, state, data)
-		case "discrete":
-			if res, err := regexp.MatchString("ChassisIntru", data.Name); res {
-				if err != nil {
-					// TODO log error
-					collectTypedSensor(ch, chassisIntrusionDesc, chassisIntrusionStateDesc, state, data)
-				} else {
-					collectTypedSensor(ch, chassisIntrusionDesc, chassisIntrusionStateDesc, state, data)
-				}
-			} else if res, err := regexp.MatchString(`PS\dStatus*`, data.Name); res {
-				if err != nil {
-					// TODO log error
-					collectTypedSensor(ch, chassisPowerDeviceDesc, chassisPowerDeviceStateDesc, state, data)
-				} else {
-					collectTypedSensor(ch, chassisPowerDeviceDesc, chassisPowerDeviceStateDesc, state, data)
-				}
-			}
-		default:
-			collectGenericSensor(ch, state, data)
-		}
+// emitCollectedAt emits the metrics of c with the time the data was collected
+// attached as timestamp.
+func emitCollectedAt(ch chan<- prometheus.Metric, c ipmiCollector, target ipmiTarget, data interface{}, collected time.Time) {
+	emitCh := make(chan prometheus.Metric)
+	go func() {
+		c.Emit(emitCh, target, data)
+		close(emitCh)
+	}()
+	for m := range emitCh {
+		ch <- prometheus.NewMetricWithTimestamp(collected, m)
 	}
-	return 1, nil
 }
 
-func collectFRUInfo(ch chan<- prometheus.Metric, target ipmiTarget) (int, error) {
-	output, err := ipmitoolOutput(target, "fru")
-	if err != nil {
-		log.Debugf("Failed to collect ipmitool fru data from %s: %s", targetName(target.host), err)
-		return 0, err
-	}
-	results, err := splitFruOutput(output)
-	if err != nil {
-		log.Errorf("Failed to parse ipmitool fru data from %s: %s", targetName(target.host), err)
-		return 0, err
-	}
+// Describe implements Prometheus.Collector.
+func (c collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- collectorErrorDesc
+	ch <- collectorRecordsDesc
+	ch <- collectorDurationDesc
+	ch <- collectorSkippedDesc
+	ch <- collectorsFailedDesc
+	ch <- durationDesc
+	ch <- scrapePhaseDurationDesc
+	ch <- sessionSetupDurationDesc
+	ch <- localInterfaceHealthyDesc
+}
 
-	for _, data := range results {
-		ch <- prometheus.MustNewConstMetric(
-			fruInfo,
-			prometheus.GaugeValue,
-			1,
-			data.Name, data.Value,
-		)
-	}
-	return 1, nil
+func markCollectorUp(ch chan<- prometheus.Metric, name string, up int) {
+	ch <- prometheus.MustNewConstMetric(
+		upDesc,
+		prometheus.GaugeValue,
+		float64(up),
+		name,
+	)
 }
 
-func collectLANInfo(ch chan<- prometheus.Metric, target ipmiTarget) (int, error) {
-	output, err := ipmitoolOutput(target, "lan")
-	if err != nil {
-		log.Debugf("Failed to collect ipmitool lan data from %s: %s", targetName(target.host), err)
-		return 0, err
-	}
-	results, err := splitLANOutput(output)
-	if err != nil {
-		log.Errorf("Failed to parse ipmitool lan data from %s: %s", targetName(target.host), err)
-		return 0, err
+// Collect implements Prometheus.Collector.
+func (c collector) Collect(ch chan<- prometheus.Metric) {
+	conf := c.config.Config()
+	if targetIsLocal(c.target) && conf.DisableLocal {
+		log.Debugf("Not collecting local metrics, local collection is disabled")
+		return
 	}
 
-	for _, data := range results {
+	start := time.Now()
+	budget := make(scrapeBudget)
+	defer func() {
+		duration := time.Since(start)
+		log.Debugf("Scrape of target %s took %f seconds.", targetName(c.target), duration.Seconds())
 		ch <- prometheus.MustNewConstMetric(
-			lanInfo,
+			durationDesc,
 			prometheus.GaugeValue,
-			1,
-			data.Name, data.Value,
+			duration.Seconds(),
 		)
-	}
-	return 1, nil
-}
+		budget.collect(ch, duration)
+	}()
 
-func collectBmcInfo(ch chan<- prometheus.Metric, target ipmiTarget) (int, error) {
-	output, err := ipmitoolOutput(target, "bmc")
-	if err != nil {
-		log.Debugf("Failed to collect ipmtool bmc data from %s: %s", targetName(target.host), err)
-		return 0, err
-	}
-	results, err := splitBmcOutput(output)
-	if err != nil {
-		log.Errorf("Failed to collect ipmtool bmc data from %s: %s", targetName(target.host), err)
-		return 0, err
-	}
+	targetState.touch(c.target, start)
 
-	for _, data := range results {
-		ch <- prometheus.MustNewConstMetric(
-			bmcInfo,
-			prometheus.GaugeValue,
-			1,
-			data.Name, data.Value,
-		)
+	config := conf.ConfigForTarget(c.target, c.module)
+	if c.collectors != nil {
+		config.Collectors, config.collectorsListed = c.collectors, true
 	}
-	return 1, nil
-}
-
-func collectDcmiPowerInfo(ch chan<- prometheus.Metric, target ipmiTarget) (int, error) {
-	output, err := ipmitoolOutput(target, "dcmi-power")
-	if err != nil {
-		log.Debugf("Failed to collect ipmtool dcmi power data from %s: %s", targetName(target.host), err)
-		return 0, err
+	summary := c.summary
+	if summary == nil {
+		summary = &scrapeSummary{}
 	}
-	results, err := splitDcmiPowerOutput(output)
-	if err != nil {
-		log.Errorf("Failed to collect ipmtool dcmi power data from %s: %s", targetName(target.host), err)
-		return 0, err
+	summary.up = true
+	target := ipmiTarget{
+		host:     c.target,
+		module:   c.module,
+		address:  targetResolver.resolve(conf, c.target, c.deadline),
+		config:   config,
+		summary:  summary,
+		deadline: c.deadline,
 	}
-
-	for _, data := range results {
-		ch <- prometheus.MustNewConstMetric(
-			powerConsumptionDesc,
-			prometheus.GaugeValue,
-			data.Value,
-			data.Name,
-		)
+	if usesLocalInterface(target) && *mockDir == "" {
+		target.summary.setLocalInterfaceProblem(localDeviceProblem())
 	}
-	return 1, nil
-}
+	detectStart := time.Now()
+	target.config = applyVendorProfile(ch, target)
+	budget["vendor_detection"] = time.Since(detectStart)
 
-func collectFwumInfo(ch chan<- prometheus.Metric, target ipmiTarget) (int, error) {
-	output, _ := ipmitoolOutput(target, "fwum")
-	// Then fwum collector will work without exit code 1 -- uncomment this error check:
-	// if err != nil {
-	// 	log.Debugf("Failed to collect ipmtool fwum data from %s: %s", targetName(target.host), err)
-	// 	return 0, err
-	// }
-	results, err := splitFwumOutput(output)
-	if err != nil {
-		log.Errorf("Failed to collect ipmtool fwum data from %s: %s", targetName(target.host), err)
-		return 0, err
+	if target.config.SessionProbe && !targetIsLocal(target.host) {
+		budget["session_setup"] = probeSession(ch, target)
 	}
 
-	var firmwareRevision, manufacturerID string
-
-	for _, data := range results {
-		switch data.Name {
-		case "FirmwareRevision":
-			firmwareRevision = strconv.FormatFloat(data.Value, 'f', 6, 64)
-		case "ManufacturerId":
-			manufacturerID = strconv.FormatFloat(data.Value, 'f', 6, 64)
+	var failed int
+	for _, name := range collectorOrder(target.config) {
+		if requirer, ok := registeredCollectors[name].(ipmi20Requirer); ok && requirer.RequiresIPMI20() && target.config.Legacy {
+			log.Debugf("Skipping collector %s for legacy target %s", name, targetName(target.host))
+			continue
+		}
+		if skipBestEffort(target, name, time.Now()) {
+			log.Infof("Skipping best-effort collector %s for %s, the scrape timeout is near", name, targetName(target.host))
+			ch <- prometheus.MustNewConstMetric(collectorSkippedDesc, prometheus.GaugeValue, 1, name)
+			continue
+		}
+		log.Debugf("Running collector: %s", name)
+		ipmiCollector, ok := registeredCollectors[name]
+		if !ok {
+			markCollectorUp(ch, name, 0)
+			target.summary.recordCollector(false)
+			failed++
+			continue
+		}
+		result := runCollector(ch, ipmiCollector, target)
+		collectorDurations.record(target.host, name, result.Duration)
+		budget.add(result)
+		collectResult(ch, name, target, result)
+		target.summary.recordCollector(result.up() == 1)
+		if result.up() == 0 {
+			failed++
 		}
 	}
-	ch <- prometheus.MustNewConstMetric(
-		fwumInfo,
-		prometheus.GaugeValue,
-		1,
-		firmwareRevision, manufacturerID,
-	)
-	return 1, nil
-}
-
-func collectPowerState(ch chan<- prometheus.Metric, target ipmiTarget) (int, error) {
-	output, err := ipmitoolOutput(target, "power")
-	if err != nil {
-		log.Debugf("Failed to collect ipmtool power data from %s: %s", targetName(target.host), err)
-		return 0, err
+	ch <- prometheus.MustNewConstMetric(collectorsFailedDesc, prometheus.GaugeValue, float64(failed))
+	// The sensor and chassis collectors both report faults.
+	if target.summary.faults != nil {
+		target.summary.faults.collect(ch)
 	}
-	result, err := getChassisPowerState(output)
-	if err != nil {
-		log.Errorf("Failed to collect ipmtool power data from %s: %s", targetName(target.host), err)
-		return 0, err
+	if usesLocalInterface(target) {
+		collectLocalInterfaceHealth(ch, target.summary.localInterfaceProblem)
+	}
+	// Scrapes of single collectors would replace the summary of the full
+	// scrape with that of their collectors alone.
+	if c.collectors == nil {
+		fleet.record(c.target, target.summary, start)
 	}
-	ch <- prometheus.MustNewConstMetric(
-		chassisPowerStateDesc,
-		prometheus.GaugeValue,
-		float64(result),
-		"PowerState",
-	)
-	return 1, nil
 }
 
-func markCollectorUp(ch chan<- prometheus.Metric, name string, up int) {
+// probeSession times a cheap command against target, which is dominated by
+// the session setup. Compared to ipmi_scrape_duration_seconds this tells a
+// slow management network apart from a BMC that is slow to answer commands.
+// It returns the time spent, even if the probe failed.
+func probeSession(ch chan<- prometheus.Metric, target ipmiTarget) time.Duration {
+	start := time.Now()
+	if _, err := ipmitoolOutput(target, []string{"mc", "info"}); err != nil {
+		log.Errorf("Session probe of %s failed: %s", targetName(target.host), err)
+		return time.Since(start)
+	}
+	duration := time.Since(start)
 	ch <- prometheus.MustNewConstMetric(
-		upDesc,
+		sessionSetupDurationDesc,
 		prometheus.GaugeValue,
-		float64(up),
-		name,
+		duration.Seconds(),
 	)
+	return duration
 }
 
-// Collect implements Prometheus.Collector.
-func (c collector) Collect(ch chan<- prometheus.Metric) {
-	start := time.Now()
-	defer func() {
-		duration := time.Since(start).Seconds()
-		log.Debugf("Scrape of target %s took %f seconds.", targetName(c.target), duration)
-		ch <- prometheus.MustNewConstMetric(
-			durationDesc,
-			prometheus.GaugeValue,
-			duration,
-		)
-	}()
-
-	config := c.config.ConfigForTarget(c.target, c.module)
-	target := ipmiTarget{
-		host:   c.target,
-		config: config,
-	}
-
-	for _, collector := range config.Collectors {
-		var up int
-		log.Debugf("Running collector: %s", collector)
-		switch collector {
-		case "sensor":
-			up, _ = collectSensorMonitoring(ch, target)
-		case "fru":
-			up, _ = collectFRUInfo(ch, target)
-		case "lan":
-			up, _ = collectLANInfo(ch, target)
-		case "bmc":
-			up, _ = collectBmcInfo(ch, target)
-		case "fwum":
-			up, _ = collectFwumInfo(ch, target)
-		case "dcmi-power":
-			up, _ = collectDcmiPowerInfo(ch, target)
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
 		}
-		markCollectorUp(ch, collector, up)
 	}
-	collectPowerState(ch, target)
+	return false
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
 }
 
 func contains(s []int64, elm int64) bool {
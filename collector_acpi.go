@@ -0,0 +1,124 @@
+//go:build !nopower
+// +build !nopower
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(acpiPowerCollector{})
+}
+
+// acpiPowerCollector collects the ACPI system and device power states the
+// BMC was told by the system software, which tell sleeping (S3) servers
+// apart from ones that are on (S0) or soft-off (S5).
+type acpiPowerCollector struct{}
+
+func (acpiPowerCollector) Name() string {
+	return "acpi-power"
+}
+
+func (acpiPowerCollector) Commands(config IPMIConfig) [][]string {
+	// Get ACPI Power State (NetFn App, command 07h).
+	return [][]string{{"raw", "0x06", "0x07"}}
+}
+
+func (acpiPowerCollector) Parse(outputs []string) (interface{}, error) {
+	response, err := parseRawBytes(outputs[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(response) < 2 {
+		return nil, fmt.Errorf("short ACPI power state response: %q", outputs[0])
+	}
+	return acpiPowerState{
+		System: acpiStateName(acpiSystemStates, response[0]&0x7f),
+		Device: acpiStateName(acpiDeviceStates, response[1]&0x7f),
+	}, nil
+}
+
+func (acpiPowerCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	state := data.(acpiPowerState)
+	for _, s := range acpiStateNames(acpiSystemStates) {
+		ch <- prometheus.MustNewConstMetric(acpiSystemPowerStateDesc, prometheus.GaugeValue, boolToFloat(s == state.System), s)
+	}
+	for _, s := range acpiStateNames(acpiDeviceStates) {
+		ch <- prometheus.MustNewConstMetric(acpiDevicePowerStateDesc, prometheus.GaugeValue, boolToFloat(s == state.Device), s)
+	}
+}
+
+type acpiPowerState struct {
+	// System and Device are the names of the states, e.g. "S0" and "D0".
+	System, Device string
+}
+
+type acpiState struct {
+	code byte
+	name string
+}
+
+// acpiSystemStates and acpiDeviceStates are the ACPI power states of the Get
+// ACPI Power State response, in the order they are exposed. Codes not listed
+// are reported as unknown.
+var (
+	acpiSystemStates = []acpiState{
+		{0x00, "S0"},
+		{0x01, "S1"},
+		{0x02, "S2"},
+		{0x03, "S3"},
+		{0x04, "S4"},
+		{0x05, "S5"},
+		{0x06, "S4/S5"},
+		{0x07, "G3"},
+		{0x08, "sleeping"},
+		{0x09, "G1"},
+		{0x0a, "S5_override"},
+		{0x20, "legacy_on"},
+		{0x21, "legacy_off"},
+		{0x2a, "unknown"},
+	}
+	acpiDeviceStates = []acpiState{
+		{0x00, "D0"},
+		{0x01, "D1"},
+		{0x02, "D2"},
+		{0x03, "D3"},
+		{0x2a, "unknown"},
+	}
+)
+
+var (
+	acpiSystemPowerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "acpi", "system_power_state"),
+		"'1' for the current ACPI system power state, e.g. S0 (working), S3 (suspend to RAM) or S5 (soft-off), '0' for all others.",
+		[]string{"state"},
+		nil,
+	)
+
+	acpiDevicePowerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "acpi", "device_power_state"),
+		"'1' for the current ACPI device power state (D0 to D3), '0' for all others.",
+		[]string{"state"},
+		nil,
+	)
+)
+
+func acpiStateName(states []acpiState, code byte) string {
+	for _, s := range states {
+		if s.code == code {
+			return s.name
+		}
+	}
+	return "unknown"
+}
+
+func acpiStateNames(states []acpiState) []string {
+	names := make([]string, len(states))
+	for i, s := range states {
+		names[i] = s.name
+	}
+	return names
+}
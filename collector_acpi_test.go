@@ -0,0 +1,28 @@
+//go:build !nopower
+// +build !nopower
+
+package main
+
+import (
+	"testing"
+)
+
+func TestACPIPowerState(t *testing.T) {
+	for output, expect := range map[string]acpiPowerState{
+		" 00 00\n": {System: "S0", Device: "D0"},
+		" 83 03\n": {System: "S3", Device: "D3"},
+		" 05 2a\n": {System: "S5", Device: "unknown"},
+		" 1f 00\n": {System: "unknown", Device: "D0"},
+	} {
+		data, err := (acpiPowerCollector{}).Parse([]string{output})
+		if err != nil {
+			t.Fatalf("Parse() call failed. Reason: %s", err)
+		}
+		if data.(acpiPowerState) != expect {
+			t.Errorf("ACPI power state check failed for %q.\n Expect: %+v\n Got: %+v", output, expect, data)
+		}
+	}
+	if _, err := (acpiPowerCollector{}).Parse([]string{" 00\n"}); err == nil {
+		t.Errorf("Short ACPI power state response was accepted")
+	}
+}
@@ -0,0 +1,195 @@
+//go:build !nobmc
+// +build !nobmc
+
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(bmcCollector{})
+}
+
+// bmcCollector collects details about the BMC.
+type bmcCollector struct{}
+
+func (bmcCollector) Name() string {
+	return "bmc"
+}
+
+func (bmcCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"bmc", "info"}}
+}
+
+func (bmcCollector) Parse(outputs []string) (interface{}, error) {
+	return splitBmcOutput(outputs[0])
+}
+
+func (bmcCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	var aux string
+	for _, data := range data.([]bmcData) {
+		if data.Name == "AuxFirmwareRevision" {
+			aux = data.Value
+		}
+	}
+	for _, data := range data.([]bmcData) {
+		value, ok := anonymizeInfo(target.config, data.Name, data.Value)
+		if !ok {
+			continue
+		}
+		// The parts of the firmware revision are only set on its own
+		// series.
+		var major, minor, revAux string
+		if data.Name == "FirmwareRevision" {
+			if parts := firmwareVersionRegex.FindStringSubmatch(data.Value); parts != nil {
+				major, minor, revAux = parts[1], parts[2], aux
+				ch <- prometheus.MustNewConstMetric(
+					bmcFirmwareRevisionDesc,
+					prometheus.GaugeValue,
+					firmwareRevision(major, minor),
+				)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(
+			bmcInfo,
+			prometheus.GaugeValue,
+			1,
+			sanitizeName(target.config.NameSanitization, data.RawName), value, major, minor, revAux,
+		)
+	}
+}
+
+// Records implements recordCounter.
+func (bmcCollector) Records(data interface{}) int {
+	return len(data.([]bmcData))
+}
+
+// ScrapeInterval implements scrapeIntervalHinter.
+func (bmcCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+var (
+	firmwareRevRegex  = regexp.MustCompile(`^Firmware\sRevision\s*:\s*(?P<value>.*)`)
+	ipmiVersionRegex  = regexp.MustCompile(`^IPMI\sVersion\s*:\s*(?P<value>.*)`)
+	manufacturerRegex = regexp.MustCompile(`^Manufacturer\sName\s*:\s*(?P<value>.*)`)
+	auxFirmwareRegex  = regexp.MustCompile(`^Aux\sFirmware\sRev\sInfo\s*:`)
+	auxByteRegex      = regexp.MustCompile(`^\s+0x([0-9a-fA-F]{2})\s*$`)
+
+	// firmwareVersionRegex splits the firmware revision printed by
+	// ipmitool into the major and the minor revision, e.g. "4.40".
+	firmwareVersionRegex = regexp.MustCompile(`^(\d+)\.(\d+)$`)
+)
+
+type bmcData struct {
+	// Name identifies the field, e.g. "FirmwareRevision", and RawName is
+	// the name exposed according to name_sanitization, e.g. "Firmware
+	// Revision".
+	Name    string
+	RawName string
+	Value   string
+}
+
+var (
+	bmcInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "info"),
+		"Constant metric with value '1' providing details about the BMC. The firmware revision is split into its major, minor and auxiliary revision.",
+		[]string{"name", "value", "major", "minor", "aux"},
+		nil,
+	)
+
+	bmcFirmwareRevisionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "firmware_revision"),
+		"Firmware revision of the BMC as a comparable number, the major revision plus the minor revision in hundredths, e.g. 4.4 for 4.40.",
+		nil,
+		nil,
+	)
+)
+
+// firmwareRevision returns the firmware revision as a number. ipmitool
+// prints the minor revision as two BCD digits.
+func firmwareRevision(major, minor string) float64 {
+	ma, _ := strconv.Atoi(major)
+	mi, _ := strconv.Atoi(minor)
+	return float64(ma) + float64(mi)/100
+}
+
+func splitBmcOutput(impitoolOutput string) ([]bmcData, error) {
+	var result []bmcData
+
+	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+
+	var err error
+	// The auxiliary firmware revision is printed as one byte per line
+	// following its name, and joined into a hex string.
+	var inAux bool
+	var aux string
+
+	for scanner.Scan() {
+		var data bmcData
+		line := scanner.Text()
+		if inAux {
+			if b := auxByteRegex.FindStringSubmatch(line); b != nil {
+				aux += strings.ToLower(b[1])
+				continue
+			}
+			inAux = false
+		}
+		if len(line) > 0 {
+			if auxFirmwareRegex.MatchString(line) {
+				inAux = true
+				continue
+			}
+			firmwareRev := firmwareRevRegex.FindStringSubmatch(line)
+			if firmwareRev != nil {
+				for i, name := range firmwareRevRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name, data.RawName = "FirmwareRevision", "Firmware Revision"
+					data.Value = firmwareRev[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			ipmiVersion := ipmiVersionRegex.FindStringSubmatch(line)
+			if ipmiVersion != nil {
+				for i, name := range ipmiVersionRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name, data.RawName = "IPMIVersion", "IPMI Version"
+					data.Value = ipmiVersion[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			manufacturer := manufacturerRegex.FindStringSubmatch(line)
+			if manufacturer != nil {
+				for i, name := range manufacturerRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name, data.RawName = "Manufacturer", "Manufacturer"
+					data.Value = manufacturer[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+		}
+	}
+	if aux != "" {
+		result = append(result, bmcData{Name: "AuxFirmwareRevision", RawName: "Aux Firmware Revision", Value: aux})
+	}
+	return result, err
+}
@@ -0,0 +1,70 @@
+//go:build !nobmc
+// +build !nobmc
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(bmcGUIDCollector{})
+}
+
+// bmcGUIDCollector collects the GUID of the BMC, which identifies it across
+// changes of its address.
+type bmcGUIDCollector struct{}
+
+func (bmcGUIDCollector) Name() string {
+	return "bmc-guid"
+}
+
+func (bmcGUIDCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"mc", "guid"}}
+}
+
+func (bmcGUIDCollector) Parse(outputs []string) (interface{}, error) {
+	return getBMCGUID(outputs[0])
+}
+
+func (bmcGUIDCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	guid, ok := anonymizeInfo(target.config, "GUID", data.(string))
+	if !ok {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		bmcGUIDInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		guid,
+	)
+}
+
+// ScrapeInterval implements scrapeIntervalHinter, as the GUID never changes.
+func (bmcGUIDCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+var (
+	bmcGUIDRegex = regexp.MustCompile(`(?m)^System\s+GUID\s*:\s*(\S+)`)
+
+	bmcGUIDInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "guid_info"),
+		"Constant metric with value '1' providing the GUID of the BMC.",
+		[]string{"guid"},
+		nil,
+	)
+)
+
+func getBMCGUID(ipmitoolOutput string) (string, error) {
+	match := bmcGUIDRegex.FindStringSubmatch(ipmitoolOutput)
+	if match == nil {
+		return "", fmt.Errorf("no GUID in output: %q", ipmitoolOutput)
+	}
+	return strings.ToLower(match[1]), nil
+}
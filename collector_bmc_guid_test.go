@@ -0,0 +1,25 @@
+//go:build !nobmc
+// +build !nobmc
+
+package main
+
+import (
+	"testing"
+)
+
+func TestGetBMCGUID(t *testing.T) {
+	collGUIDOutput := `System GUID  : 44454C4C-5000-1048-8030-B4C04F564D32
+Timestamp    : 01/05/1970 08:21:55`
+	res, err := getBMCGUID(collGUIDOutput)
+	if err != nil {
+		t.Errorf("getBMCGUID() call failed. Reason: %s", err)
+	}
+	expect := "44454c4c-5000-1048-8030-b4c04f564d32"
+	if res != expect {
+		t.Errorf("BMC GUID check failed.\n Expect: %s\n Got: %s", expect, res)
+	}
+
+	if _, err := getBMCGUID("Get Device GUID command failed"); err == nil {
+		t.Errorf("Output without GUID was accepted")
+	}
+}
@@ -0,0 +1,124 @@
+//go:build !nobmc
+// +build !nobmc
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(bmcSelftestCollector{})
+}
+
+// bmcSelftestCollector collects the result of the self-test the BMC ran when
+// it started, which reveals e.g. corrupted SDR repositories or firmware.
+type bmcSelftestCollector struct{}
+
+func (bmcSelftestCollector) Name() string {
+	return "bmc-selftest"
+}
+
+func (bmcSelftestCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"mc", "selftest"}}
+}
+
+func (bmcSelftestCollector) Parse(outputs []string) (interface{}, error) {
+	return splitSelftestOutput(outputs[0])
+}
+
+func (bmcSelftestCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	result := data.(selftestData)
+	ch <- prometheus.MustNewConstMetric(
+		bmcSelftestPassedDesc,
+		prometheus.GaugeValue,
+		boolToFloat(result.Passed()),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		bmcSelftestResultDesc,
+		prometheus.GaugeValue,
+		1,
+		result.Result,
+	)
+	for _, failure := range result.Failures {
+		ch <- prometheus.MustNewConstMetric(
+			bmcSelftestFailureDesc,
+			prometheus.GaugeValue,
+			1,
+			failure,
+		)
+	}
+}
+
+// ScrapeInterval implements scrapeIntervalHinter, as the BMC only runs the
+// self-test when it starts.
+func (bmcSelftestCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+type selftestData struct {
+	// Result is the result printed by ipmitool, e.g. "passed" or "device
+	// error".
+	Result string
+	// Failures are the reasons of a device error, e.g. "SDR Repository
+	// empty".
+	Failures []string
+}
+
+// Passed returns true if the self-test passed. BMCs that don't implement the
+// self-test are considered to pass it.
+func (d selftestData) Passed() bool {
+	return d.Result == "passed" || d.Result == "not implemented"
+}
+
+var (
+	selftestResultRegex  = regexp.MustCompile(`^Selftest\s*:\s*(.+?)\s*$`)
+	selftestFailureRegex = regexp.MustCompile(`^Failure\s*:\s*(.+?)\s*$`)
+
+	bmcSelftestPassedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "selftest_passed"),
+		"'1' if the BMC passed its self-test, '0' otherwise.",
+		nil,
+		nil,
+	)
+
+	bmcSelftestResultDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "selftest_result_info"),
+		"Constant metric with value '1' providing the result of the self-test of the BMC.",
+		[]string{"result"},
+		nil,
+	)
+
+	bmcSelftestFailureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "selftest_failure_info"),
+		"Constant metric with value '1' providing a reason of a failed self-test of the BMC.",
+		[]string{"reason"},
+		nil,
+	)
+)
+
+func splitSelftestOutput(ipmitoolOutput string) (selftestData, error) {
+	var result selftestData
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := selftestResultRegex.FindStringSubmatch(line); match != nil {
+			result.Result = strings.ToLower(match[1])
+			continue
+		}
+		if match := selftestFailureRegex.FindStringSubmatch(line); match != nil {
+			result.Failures = append(result.Failures, match[1])
+		}
+	}
+	if result.Result == "" {
+		return result, fmt.Errorf("no self-test result in output: %q", ipmitoolOutput)
+	}
+	return result, nil
+}
@@ -0,0 +1,35 @@
+//go:build !nobmc
+// +build !nobmc
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSelftestOutput(t *testing.T) {
+	for output, expect := range map[string]selftestData{
+		"Selftest: passed\n": {Result: "passed"},
+		"Selftest: device error\nFailure: SDR Repository empty\nFailure: Internal Use Area corrupted\n": {
+			Result:   "device error",
+			Failures: []string{"SDR Repository empty", "Internal Use Area corrupted"},
+		},
+		"Selftest: fatal hardware error\n": {Result: "fatal hardware error"},
+	} {
+		res, err := splitSelftestOutput(output)
+		if err != nil {
+			t.Errorf("splitSelftestOutput() call failed. Reason: %s", err)
+		}
+		if !reflect.DeepEqual(res, expect) {
+			t.Errorf("Self-test check failed.\n Expect: %+v\n Got: %+v", expect, res)
+		}
+		if res.Passed() != (expect.Result == "passed") {
+			t.Errorf("Self-test passed check failed for %q.\n Expect: %v\n Got: %v", expect.Result, expect.Result == "passed", res.Passed())
+		}
+	}
+
+	if _, err := splitSelftestOutput("Get Self Test command failed"); err == nil {
+		t.Errorf("Output without self-test result was accepted")
+	}
+}
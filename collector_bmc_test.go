@@ -0,0 +1,76 @@
+//go:build !nobmc
+// +build !nobmc
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSplitBmcOutput(t *testing.T) {
+	collBmcOutput := `Device ID                 : 32
+Device Revision           : 1
+Firmware Revision         : 1.30
+IPMI Version              : 2.0
+Manufacturer ID           : 19046
+Manufacturer Name         : Lenovo
+Product ID                : 1287 (0x0507)
+Device Available          : yes
+Provides Device SDRs      : no
+Additional Device Support :
+    Sensor Device
+    SEL Device
+Aux Firmware Rev Info     :
+    0x1e
+    0x00
+    0x00
+    0x00
+`
+	res, err := splitBmcOutput(collBmcOutput)
+	if err != nil {
+		t.Fatalf("splitBmcOutput() call failed. Reason: %s", err)
+	}
+	expect := []bmcData{
+		{Name: "FirmwareRevision", RawName: "Firmware Revision", Value: "1.30"},
+		{Name: "IPMIVersion", RawName: "IPMI Version", Value: "2.0"},
+		{Name: "Manufacturer", RawName: "Manufacturer", Value: "Lenovo"},
+		{Name: "AuxFirmwareRevision", RawName: "Aux Firmware Revision", Value: "1e000000"},
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("BMC info check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	ch := make(chan prometheus.Metric, 10)
+	(bmcCollector{}).Emit(ch, ipmiTarget{config: IPMIConfig{Anonymize: "none"}}, res)
+	close(ch)
+	var revision float64
+	var labels map[string]string
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("Writing metric failed: %s", err)
+		}
+		if m.Desc() == bmcFirmwareRevisionDesc {
+			revision = metric.GetGauge().GetValue()
+			continue
+		}
+		l := make(map[string]string)
+		for _, pair := range metric.Label {
+			l[pair.GetName()] = pair.GetValue()
+		}
+		if l["name"] == "FirmwareRevision" {
+			labels = l
+		}
+	}
+	if revision != 1.3 {
+		t.Errorf("Firmware revision check failed.\n Expect: 1.3\n Got: %v", revision)
+	}
+	expectLabels := map[string]string{"name": "FirmwareRevision", "value": "1.30", "major": "1", "minor": "30", "aux": "1e000000"}
+	if !reflect.DeepEqual(labels, expectLabels) {
+		t.Errorf("Firmware revision labels check failed.\n Expect: %v\n Got: %v", expectLabels, labels)
+	}
+}
@@ -0,0 +1,208 @@
+//go:build !nochannel
+// +build !nochannel
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(channelCollector{})
+}
+
+// channelCollector collects the medium, protocol and access mode of the BMC
+// channels, e.g. to audit which channels are enabled.
+type channelCollector struct{}
+
+func (channelCollector) Name() string {
+	return "channel"
+}
+
+func (channelCollector) Commands(config IPMIConfig) [][]string {
+	var commands [][]string
+	for _, channel := range config.Channels {
+		commands = append(commands, []string{"channel", "info", strconv.Itoa(channel)})
+	}
+	return commands
+}
+
+func (channelCollector) Parse(outputs []string) (interface{}, error) {
+	var result []channelData
+	for _, output := range outputs {
+		if channel, ok := splitChannelInfoOutput(output); ok {
+			result = append(result, channel)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no channel info in output: %q", strings.Join(outputs, "\n"))
+	}
+	return result, nil
+}
+
+func (channelCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	for _, c := range data.([]channelData) {
+		ch <- prometheus.MustNewConstMetric(
+			channelInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			c.Channel, c.Medium, c.Protocol, c.SessionSupport,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			channelActiveSessionsDesc,
+			prometheus.GaugeValue,
+			float64(c.ActiveSessions),
+			c.Channel,
+		)
+		if c.AccessMode != "" {
+			ch <- prometheus.MustNewConstMetric(
+				channelEnabledDesc,
+				prometheus.GaugeValue,
+				boolToFloat(c.AccessMode != "disabled"),
+				c.Channel,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				channelAccessModeDesc,
+				prometheus.GaugeValue,
+				1,
+				c.Channel, c.AccessMode,
+			)
+		}
+	}
+}
+
+// Records implements recordCounter.
+func (channelCollector) Records(data interface{}) int {
+	return len(data.([]channelData))
+}
+
+// IgnoreExitStatus implements exitStatusIgnorer, as ipmitool fails for
+// channels the BMC doesn't implement. Parse skips their output, so that
+// modules can list all channels to audit.
+func (channelCollector) IgnoreExitStatus() bool {
+	return true
+}
+
+// ValidateConfig implements configValidator.
+func (channelCollector) ValidateConfig(config IPMIConfig) error {
+	if len(config.Channels) == 0 {
+		return fmt.Errorf("channel collector needs channels")
+	}
+	seen := make(map[int]bool)
+	for _, channel := range config.Channels {
+		if channel < 0 || channel > 15 {
+			return fmt.Errorf("invalid channels channel: %d (must be 0-15)", channel)
+		}
+		if seen[channel] {
+			return fmt.Errorf("duplicate channels channel: %d", channel)
+		}
+		seen[channel] = true
+	}
+	return nil
+}
+
+type channelData struct {
+	// Channel is the channel number in decimal.
+	Channel        string
+	Medium         string
+	Protocol       string
+	SessionSupport string
+	ActiveSessions int
+	// AccessMode is the volatile (active) access mode, e.g. "always
+	// available" or "disabled". It is empty for session-less channels like
+	// the system interface, which don't report one.
+	AccessMode string
+}
+
+var (
+	channelHeaderRegex = regexp.MustCompile(`(?m)^Channel\s+0x([0-9a-fA-F]+)\s+info:`)
+
+	channelInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "channel", "info"),
+		"Constant metric with value '1' providing the medium, protocol and session support of the BMC channel.",
+		[]string{"channel", "medium", "protocol", "session_support"},
+		nil,
+	)
+
+	channelActiveSessionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "channel", "active_sessions"),
+		"Number of active sessions on the BMC channel.",
+		[]string{"channel"},
+		nil,
+	)
+
+	channelEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "channel", "enabled"),
+		"'1' if the active access mode of the BMC channel isn't disabled, '0' otherwise.",
+		[]string{"channel"},
+		nil,
+	)
+
+	channelAccessModeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "channel", "access_mode_info"),
+		"Constant metric with value '1' providing the active access mode of the BMC channel.",
+		[]string{"channel", "mode"},
+		nil,
+	)
+)
+
+// splitChannelInfoOutput parses the output of `ipmitool channel info`. It
+// returns false if the output doesn't describe a channel, e.g. because the
+// BMC doesn't implement it.
+func splitChannelInfoOutput(ipmitoolOutput string) (channelData, bool) {
+	var result channelData
+	header := channelHeaderRegex.FindStringSubmatch(ipmitoolOutput)
+	if header == nil {
+		return result, false
+	}
+	number, err := strconv.ParseUint(header[1], 16, 8)
+	if err != nil {
+		return result, false
+	}
+	result.Channel = strconv.FormatUint(number, 10)
+
+	// The access settings are listed twice, first the volatile (active)
+	// and then the non-volatile ones.
+	volatile := false
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Volatile") {
+			volatile = true
+			continue
+		}
+		if strings.HasPrefix(line, "Non-Volatile") {
+			volatile = false
+			continue
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		switch name {
+		case "Channel Medium Type":
+			result.Medium = value
+		case "Channel Protocol Type":
+			result.Protocol = value
+		case "Session Support":
+			result.SessionSupport = value
+		case "Active Session Count":
+			result.ActiveSessions, _ = strconv.Atoi(value)
+		case "Access Mode":
+			if volatile {
+				result.AccessMode = value
+			}
+		}
+	}
+	if result.Medium == "" {
+		return result, false
+	}
+	return result, true
+}
@@ -0,0 +1,77 @@
+//go:build !nochannel
+// +build !nochannel
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestSplitChannelInfoOutput(t *testing.T) {
+	lanOutput := `Channel 0x8 info:
+  Channel Medium Type   : 802.3 LAN
+  Channel Protocol Type : IPMB-1.0
+  Session Support       : multi-session
+  Active Session Count  : 2
+  Protocol Vendor ID    : 7154
+  Volatile(active) Settings
+    Alerting            : enabled
+    Per-message Auth    : enabled
+    User Level Auth     : enabled
+    Access Mode         : disabled
+  Non-Volatile Settings
+    Alerting            : enabled
+    Per-message Auth    : enabled
+    User Level Auth     : enabled
+    Access Mode         : always available
+`
+	systemOutput := `Channel 0xf info:
+  Channel Medium Type   : System Interface
+  Channel Protocol Type : KCS
+  Session Support       : session-less
+  Active Session Count  : 0
+  Protocol Vendor ID    : 7154
+`
+	absentOutput := `Get Channel Info command failed: Invalid data field in request
+Invalid channel 5
+`
+	data, err := (channelCollector{}).Parse([]string{lanOutput, absentOutput, systemOutput})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	expect := []channelData{
+		{Channel: "8", Medium: "802.3 LAN", Protocol: "IPMB-1.0", SessionSupport: "multi-session", ActiveSessions: 2, AccessMode: "disabled"},
+		{Channel: "15", Medium: "System Interface", Protocol: "KCS", SessionSupport: "session-less"},
+	}
+	if !reflect.DeepEqual(data, expect) {
+		t.Errorf("Channel info check failed.\n Expect: %+v\n Got: %+v", expect, data)
+	}
+
+	if _, err := (channelCollector{}).Parse([]string{absentOutput}); err == nil {
+		t.Errorf("Output without channel info was parsed")
+	}
+}
+
+func TestChannelConfig(t *testing.T) {
+	var config IPMIConfig
+	if err := yaml.Unmarshal([]byte("collectors: [channel]\nchannels: [1, 8]\n"), &config); err != nil {
+		t.Fatalf("Config with channels not loaded.\n Error is: %s", err)
+	}
+	expect := [][]string{{"channel", "info", "1"}, {"channel", "info", "8"}}
+	if res := (channelCollector{}).Commands(config); !reflect.DeepEqual(res, expect) {
+		t.Errorf("Channel commands check failed.\n Expect: %v\n Got: %v", expect, res)
+	}
+
+	for _, bad := range []string{
+		"collectors: [channel]\nchannels: []\n",
+		"collectors: [channel]\nchannels: [16]\n",
+		"collectors: [channel]\nchannels: [1, 1]\n",
+	} {
+		if err := yaml.Unmarshal([]byte(bad), &IPMIConfig{}); err == nil {
+			t.Errorf("Invalid channels were accepted: %q", bad)
+		}
+	}
+}
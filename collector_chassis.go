@@ -0,0 +1,180 @@
+//go:build !nochassis
+// +build !nochassis
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(chassisCollector{})
+}
+
+// chassisCollector collects the chassis power faults, the power restore
+// policy and the cause of the last power event.
+type chassisCollector struct{}
+
+func (chassisCollector) Name() string {
+	return "chassis"
+}
+
+func (chassisCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"chassis", "status"}}
+}
+
+func (chassisCollector) Parse(outputs []string) (interface{}, error) {
+	return splitChassisStatusOutput(outputs[0])
+}
+
+func (chassisCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	status := data.(chassisStatus)
+	for _, flag := range chassisStatusFlags {
+		ch <- prometheus.MustNewConstMetric(
+			flag.desc,
+			prometheus.GaugeValue,
+			boolToFloat(status.Flags[flag.field]),
+		)
+	}
+	target.summary.addFaults(status.faults())
+	policy := status.PowerRestorePolicy
+	if policy == "" {
+		policy = "unknown"
+	}
+	ch <- prometheus.MustNewConstMetric(
+		chassisPowerRestorePolicyDesc,
+		prometheus.GaugeValue,
+		1,
+		policy,
+	)
+	events := status.LastPowerEvents
+	if len(events) == 0 {
+		events = []string{"none"}
+	}
+	for _, event := range events {
+		ch <- prometheus.MustNewConstMetric(
+			chassisLastPowerEventDesc,
+			prometheus.GaugeValue,
+			1,
+			event,
+		)
+	}
+}
+
+type chassisStatus struct {
+	// Flags maps the boolean fields of `ipmitool chassis status` to whether
+	// they are set.
+	Flags map[string]bool
+	// PowerRestorePolicy is what the system does when AC power returns:
+	// "always-on", "previous", "always-off" or "unknown".
+	PowerRestorePolicy string
+	// LastPowerEvents lists the causes of the last power event, e.g.
+	// "ac-failed" or "command".
+	LastPowerEvents []string
+}
+
+// chassisStatusFlags are the boolean fields of `ipmitool chassis status`
+// exposed as gauges.
+var chassisStatusFlags = []struct {
+	field string
+	desc  *prometheus.Desc
+}{
+	{"Power Overload", prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "power_overload"),
+		"'1' if the system was shut down because of a power overload, '0' otherwise.",
+		nil,
+		nil,
+	)},
+	{"Power Interlock", prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "power_interlock"),
+		"'1' if the chassis power interlock is active, '0' otherwise.",
+		nil,
+		nil,
+	)},
+	{"Main Power Fault", prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "main_power_fault"),
+		"'1' if a fault was detected in the main power subsystem, '0' otherwise.",
+		nil,
+		nil,
+	)},
+	{"Power Control Fault", prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "power_control_fault"),
+		"'1' if the controller attempted to turn system power on or off but the system did not enter the desired state, '0' otherwise.",
+		nil,
+		nil,
+	)},
+}
+
+var (
+	chassisLastPowerEventDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "last_power_event"),
+		"Constant metric with value '1' providing the cause of the last power event (none, ac-failed, overload, interlock, fault or command).",
+		[]string{"event"},
+		nil,
+	)
+
+	chassisPowerRestorePolicyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "power_restore_policy_info"),
+		"Constant metric with value '1' providing the power restore policy applied when AC power returns (always-on, previous, always-off or unknown).",
+		[]string{"policy"},
+		nil,
+	)
+)
+
+// chassisStatusFaults maps the fault flags of `ipmitool chassis status` to
+// the categories of ipmi_chassis_fault.
+var chassisStatusFaults = map[string]string{
+	"Main Power Fault":    "power",
+	"Power Overload":      "power",
+	"Power Control Fault": "power",
+	"Cooling/Fan Fault":   "cooling",
+	"Drive Fault":         "drive",
+	"Chassis Intrusion":   "intrusion",
+}
+
+// faults returns the chassis faults flagged in the status.
+func (s chassisStatus) faults() chassisFaults {
+	faults := make(chassisFaults)
+	for field, faultType := range chassisStatusFaults {
+		if s.Flags[field] {
+			faults[faultType] = true
+		}
+	}
+	return faults
+}
+
+func splitChassisStatusOutput(ipmitoolOutput string) (chassisStatus, error) {
+	status := chassisStatus{Flags: make(map[string]bool)}
+	var found bool
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		switch name {
+		case "Power Restore Policy":
+			status.PowerRestorePolicy = value
+		case "Last Power Event":
+			status.LastPowerEvents = strings.Fields(value)
+		case "Power Overload", "Main Power Fault", "Power Control Fault":
+			status.Flags[name] = value == "true"
+			found = true
+		case "Cooling/Fan Fault", "Drive Fault":
+			status.Flags[name] = value == "true"
+		case "Power Interlock", "Chassis Intrusion":
+			status.Flags[name] = value == "active"
+			found = true
+		}
+	}
+	if !found {
+		return status, fmt.Errorf("no chassis status in output: %q", ipmitoolOutput)
+	}
+	return status, nil
+}
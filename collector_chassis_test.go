@@ -0,0 +1,52 @@
+//go:build !nochassis
+// +build !nochassis
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitChassisStatusOutput(t *testing.T) {
+	collChassisOutput := `System Power         : off
+Power Overload       : false
+Power Interlock      : inactive
+Main Power Fault     : true
+Power Control Fault  : false
+Power Restore Policy : always-off
+Last Power Event     : ac-failed fault
+Chassis Intrusion    : active
+Front-Panel Lockout  : inactive
+Drive Fault          : false
+Cooling/Fan Fault    : false`
+	res, err := splitChassisStatusOutput(collChassisOutput)
+	if err != nil {
+		t.Errorf("splitChassisStatusOutput() call failed. Reason: %s", err)
+	}
+	expect := chassisStatus{
+		Flags: map[string]bool{
+			"Power Overload":      false,
+			"Power Interlock":     false,
+			"Main Power Fault":    true,
+			"Power Control Fault": false,
+			"Chassis Intrusion":   true,
+			"Drive Fault":         false,
+			"Cooling/Fan Fault":   false,
+		},
+		PowerRestorePolicy: "always-off",
+		LastPowerEvents:    []string{"ac-failed", "fault"},
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("Chassis status check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+	expectFaults := chassisFaults{"power": true, "intrusion": true}
+	if faults := res.faults(); !reflect.DeepEqual(faults, expectFaults) {
+		t.Errorf("Chassis faults check failed.\n Expect: %v\n Got: %v", expectFaults, faults)
+	}
+
+	res, err = splitChassisStatusOutput("System Power         : on\nLast Power Event     : ")
+	if err == nil {
+		t.Errorf("Chassis status without power fields was accepted: %+v", res)
+	}
+}
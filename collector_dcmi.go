@@ -0,0 +1,173 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(dcmiPowerCollector{})
+}
+
+// dcmiPowerCollector collects DCMI power consumption readings.
+type dcmiPowerCollector struct{}
+
+func (dcmiPowerCollector) Name() string {
+	return "dcmi-power"
+}
+
+func (dcmiPowerCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{dcmiPowerReadingCommand(config)}
+}
+
+func (dcmiPowerCollector) Parse(outputs []string) (interface{}, error) {
+	return splitDcmiPowerOutput(outputs[0])
+}
+
+func (dcmiPowerCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	for _, data := range data.([]dcmiPowerData) {
+		if data.Name == "Instantaneous power consumption" {
+			target.summary.setPower(data.Value)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			powerConsumptionDesc,
+			prometheus.GaugeValue,
+			data.Value,
+			data.Name,
+		)
+	}
+}
+
+// Records implements recordCounter.
+func (dcmiPowerCollector) Records(data interface{}) int {
+	return len(data.([]dcmiPowerData))
+}
+
+// RequiresIPMI20 implements ipmi20Requirer, as DCMI is based on IPMI 2.0.
+func (dcmiPowerCollector) RequiresIPMI20() bool {
+	return true
+}
+
+// ValidateConfig implements configValidator.
+func (dcmiPowerCollector) ValidateConfig(config IPMIConfig) error {
+	if config.DCMISamplePeriod != "now" && !containsString(dcmiSamplePeriods, config.DCMISamplePeriod) {
+		return fmt.Errorf("unknown dcmi_sample_period: %s (must be now or one of %s)", config.DCMISamplePeriod, strings.Join(dcmiSamplePeriods, ", "))
+	}
+	return nil
+}
+
+// dcmiSamplePeriods are the sampling periods ipmitool accepts for DCMI power
+// readings.
+var dcmiSamplePeriods = []string{
+	"5_sec", "15_sec", "30_sec", "1_min", "3_min", "7_min", "15_min", "30_min",
+	"1_hour", "2_hour", "6_hour", "12_hour", "24_hour", "2_day", "3_day", "7_day",
+}
+
+// dcmiPowerReadingCommand returns the ipmitool command reading the power
+// consumption over the dcmi_sample_period of config. Without a period, BMCs
+// report statistics over their default period.
+func dcmiPowerReadingCommand(config IPMIConfig) []string {
+	if config.DCMISamplePeriod == "now" {
+		return []string{"dcmi", "power", "reading"}
+	}
+	return []string{"dcmi", "power", "reading", config.DCMISamplePeriod}
+}
+
+var (
+	dcmiAvgPowerRegex   = regexp.MustCompile(`^\s*Average\spower\sreading\sover\ssample\speriod:\s*(?P<value>.*) Watts`)
+	dcmiInstaPowerRegex = regexp.MustCompile(`^\s*Instantaneous\spower\sreading:\s*(?P<value>.*) Watts`)
+	dcmiMinPowerRegex   = regexp.MustCompile(`^\s*Minimum\sduring\ssampling\speriod:\s*(?P<value>.*) Watts`)
+	dcmiMaxPowerRegex   = regexp.MustCompile(`^\s*Maximum\sduring\ssampling\speriod:\s*(?P<value>.*) Watts`)
+)
+
+type dcmiPowerData struct {
+	Name  string
+	Value float64
+}
+
+var powerConsumptionDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "dcmi", "power_consumption_watts"),
+	"Current power consumption in Watts.",
+	[]string{"name"},
+	nil,
+)
+
+func splitDcmiPowerOutput(impitoolOutput string) ([]dcmiPowerData, error) {
+	var result []dcmiPowerData
+
+	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+
+	var err error
+
+	for scanner.Scan() {
+		var data dcmiPowerData
+		line := scanner.Text()
+		if len(line) > 0 {
+			dcmiAvgPower := dcmiAvgPowerRegex.FindStringSubmatch(line)
+			if dcmiAvgPower != nil {
+				for i, name := range dcmiAvgPowerRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "Avg power consumption"
+					data.Value, err = strconv.ParseFloat(dcmiAvgPower[i], 64)
+					if err != nil {
+						continue
+					}
+					result = append(result, data)
+				}
+			}
+			dcmiMinPower := dcmiMinPowerRegex.FindStringSubmatch(line)
+			if dcmiMinPower != nil {
+				for i, name := range dcmiMinPowerRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "Min power consumption"
+					data.Value, err = strconv.ParseFloat(dcmiMinPower[i], 64)
+					if err != nil {
+						continue
+					}
+					result = append(result, data)
+				}
+			}
+			dcmiMaxPower := dcmiMaxPowerRegex.FindStringSubmatch(line)
+			if dcmiMaxPower != nil {
+				for i, name := range dcmiMaxPowerRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "Max power consumption"
+					data.Value, err = strconv.ParseFloat(dcmiMaxPower[i], 64)
+					if err != nil {
+						continue
+					}
+					result = append(result, data)
+				}
+			}
+			dcmiInstaPower := dcmiInstaPowerRegex.FindStringSubmatch(line)
+			if dcmiInstaPower != nil {
+				for i, name := range dcmiInstaPowerRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "Instantaneous power consumption"
+					data.Value, err = strconv.ParseFloat(dcmiInstaPower[i], 64)
+					if err != nil {
+						continue
+					}
+					result = append(result, data)
+				}
+			}
+		}
+	}
+	return result, err
+}
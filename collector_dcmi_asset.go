@@ -0,0 +1,100 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(dcmiAssetCollector{})
+}
+
+// dcmiAssetCollector collects the asset tag and the management controller
+// identifier string configured in the BMC through DCMI.
+type dcmiAssetCollector struct{}
+
+func (dcmiAssetCollector) Name() string {
+	return "dcmi-asset"
+}
+
+func (dcmiAssetCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"dcmi", "asset_tag"}, {"dcmi", "get_mc_id_string"}}
+}
+
+func (dcmiAssetCollector) Parse(outputs []string) (interface{}, error) {
+	return getDCMIAsset(outputs[0], outputs[1])
+}
+
+func (dcmiAssetCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	asset := data.(dcmiAsset)
+	if tag, ok := anonymizeInfo(target.config, "AssetTag", asset.AssetTag); ok {
+		ch <- prometheus.MustNewConstMetric(
+			dcmiAssetTagInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			tag,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		dcmiMCIDInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		asset.MCID,
+	)
+}
+
+// RequiresIPMI20 implements ipmi20Requirer, as DCMI is based on IPMI 2.0.
+func (dcmiAssetCollector) RequiresIPMI20() bool {
+	return true
+}
+
+// ScrapeInterval implements scrapeIntervalHinter.
+func (dcmiAssetCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+type dcmiAsset struct {
+	AssetTag string
+	MCID     string
+}
+
+var (
+	dcmiAssetTagRegex = regexp.MustCompile(`(?m)^\s*Asset\s+tag\s*:[ \t]*(.*)$`)
+	dcmiMCIDRegex     = regexp.MustCompile(`(?m)^\s*Get\s+Management\s+Controller\s+Identifier\s+String\s*:[ \t]*(.*)$`)
+
+	dcmiAssetTagInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "asset_tag_info"),
+		"Constant metric with value '1' providing the DCMI asset tag of the system.",
+		[]string{"asset_tag"},
+		nil,
+	)
+
+	dcmiMCIDInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "mc_id_info"),
+		"Constant metric with value '1' providing the DCMI management controller identifier string.",
+		[]string{"mc_id"},
+		nil,
+	)
+)
+
+func getDCMIAsset(assetTagOutput, mcIDOutput string) (dcmiAsset, error) {
+	var result dcmiAsset
+	match := dcmiAssetTagRegex.FindStringSubmatch(assetTagOutput)
+	if match == nil {
+		return result, fmt.Errorf("no asset tag in output: %q", assetTagOutput)
+	}
+	result.AssetTag = strings.TrimSpace(match[1])
+	match = dcmiMCIDRegex.FindStringSubmatch(mcIDOutput)
+	if match == nil {
+		return result, fmt.Errorf("no management controller identifier in output: %q", mcIDOutput)
+	}
+	result.MCID = strings.TrimSpace(match[1])
+	return result, nil
+}
@@ -0,0 +1,34 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"testing"
+)
+
+func TestGetDCMIAsset(t *testing.T) {
+	collAssetTagOutput := `
+ Asset tag                    : SRV-0042
+`
+	collMCIDOutput := `
+ Get Management Controller Identifier String: idrac-7XJ2Q12
+`
+	res, err := getDCMIAsset(collAssetTagOutput, collMCIDOutput)
+	if err != nil {
+		t.Errorf("getDCMIAsset() call failed. Reason: %s", err)
+	}
+	expect := dcmiAsset{AssetTag: "SRV-0042", MCID: "idrac-7XJ2Q12"}
+	if res != expect {
+		t.Errorf("DCMI asset check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	res, err = getDCMIAsset(" Asset tag                    :\n", collMCIDOutput)
+	if err != nil || res.AssetTag != "" {
+		t.Errorf("Empty asset tag check failed.\n Expect: \"\", <nil>\n Got: %q, %v", res.AssetTag, err)
+	}
+
+	if _, err := getDCMIAsset("DCMI request failed\n", collMCIDOutput); err == nil {
+		t.Errorf("Output without asset tag was accepted")
+	}
+}
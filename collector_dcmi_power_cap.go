@@ -0,0 +1,124 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(dcmiPowerCapCollector{})
+}
+
+// dcmiPowerCapCollector collects the DCMI power limit configured in the BMC
+// and whether it is being enforced.
+type dcmiPowerCapCollector struct{}
+
+func (dcmiPowerCapCollector) Name() string {
+	return "dcmi-power-cap"
+}
+
+func (dcmiPowerCapCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"dcmi", "power", "get_limit"}}
+}
+
+func (dcmiPowerCapCollector) Parse(outputs []string) (interface{}, error) {
+	return splitDcmiPowerCapOutput(outputs[0])
+}
+
+func (dcmiPowerCapCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	limit := data.(dcmiPowerCap)
+	ch <- prometheus.MustNewConstMetric(dcmiPowerCapDesc, prometheus.GaugeValue, limit.Limit)
+	ch <- prometheus.MustNewConstMetric(dcmiPowerCapActiveDesc, prometheus.GaugeValue, boolToFloat(limit.Active))
+	ch <- prometheus.MustNewConstMetric(dcmiPowerCapCorrectionTimeDesc, prometheus.GaugeValue, limit.CorrectionTime)
+	ch <- prometheus.MustNewConstMetric(dcmiPowerCapActionDesc, prometheus.GaugeValue, boolToFloat(limit.PowerOff), "power_off")
+	ch <- prometheus.MustNewConstMetric(dcmiPowerCapActionDesc, prometheus.GaugeValue, boolToFloat(limit.LogEvent), "log")
+}
+
+// RequiresIPMI20 implements ipmi20Requirer, as DCMI is based on IPMI 2.0.
+func (dcmiPowerCapCollector) RequiresIPMI20() bool {
+	return true
+}
+
+var (
+	dcmiPowerCapStateRegex          = regexp.MustCompile(`^\s*Current\sLimit\sState:\s*(?P<value>.*)$`)
+	dcmiPowerCapActionRegex         = regexp.MustCompile(`^\s*Exception\sactions:\s*(?P<value>.*)$`)
+	dcmiPowerCapLimitRegex          = regexp.MustCompile(`^\s*Power\sLimit:\s*(?P<value>\d+)\sWatts`)
+	dcmiPowerCapCorrectionTimeRegex = regexp.MustCompile(`^\s*Correction\stime:\s*(?P<value>\d+)\smilliseconds`)
+)
+
+type dcmiPowerCap struct {
+	Limit          float64
+	Active         bool
+	CorrectionTime float64
+	PowerOff       bool
+	LogEvent       bool
+}
+
+var (
+	dcmiPowerCapDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "power_cap_watts"),
+		"Power limit configured through DCMI in Watts.",
+		nil,
+		nil,
+	)
+
+	dcmiPowerCapActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "power_cap_active"),
+		"Whether the DCMI power limit is activated (1) or not (0).",
+		nil,
+		nil,
+	)
+
+	dcmiPowerCapCorrectionTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "power_cap_correction_time_seconds"),
+		"Time the power consumption may exceed the DCMI power limit before the exception actions are taken.",
+		nil,
+		nil,
+	)
+
+	dcmiPowerCapActionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "power_cap_action"),
+		"Whether an exception action of the DCMI power limit is configured (1) or not (0).",
+		[]string{"action"},
+		nil,
+	)
+)
+
+// splitDcmiPowerCapOutput parses the output of `ipmitool dcmi power
+// get_limit`. The exception action is one of "No Action", "Hard Power Off &
+// Log Event to SEL" and "Log Event to SEL".
+func splitDcmiPowerCapOutput(ipmitoolOutput string) (dcmiPowerCap, error) {
+	var limit dcmiPowerCap
+	var found bool
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := dcmiPowerCapLimitRegex.FindStringSubmatch(line); m != nil {
+			limit.Limit, _ = strconv.ParseFloat(m[1], 64)
+			found = true
+		} else if m := dcmiPowerCapStateRegex.FindStringSubmatch(line); m != nil {
+			state := strings.ToLower(m[1])
+			limit.Active = strings.Contains(state, "active") && !strings.HasPrefix(state, "no ")
+		} else if m := dcmiPowerCapActionRegex.FindStringSubmatch(line); m != nil {
+			action := strings.ToLower(m[1])
+			limit.PowerOff = strings.Contains(action, "power off")
+			limit.LogEvent = strings.Contains(action, "log event")
+		} else if m := dcmiPowerCapCorrectionTimeRegex.FindStringSubmatch(line); m != nil {
+			milliseconds, _ := strconv.ParseFloat(m[1], 64)
+			limit.CorrectionTime = milliseconds / 1000
+		}
+	}
+	if !found {
+		return limit, fmt.Errorf("no power limit in output: %q", ipmitoolOutput)
+	}
+	return limit, nil
+}
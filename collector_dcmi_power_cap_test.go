@@ -0,0 +1,46 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitDcmiPowerCapOutput(t *testing.T) {
+	collPowerCapOutput := `
+    Current Limit State: Power Limit Active
+    Exception actions:   Hard Power Off & Log Event to SEL
+    Power Limit:         450 Watts
+    Correction time:     6000 milliseconds
+    Sampling period:     1 seconds
+`
+	res, err := splitDcmiPowerCapOutput(collPowerCapOutput)
+	if err != nil {
+		t.Errorf("splitDcmiPowerCapOutput() call failed. Reason: %s", err)
+	}
+	expect := dcmiPowerCap{Limit: 450, Active: true, CorrectionTime: 6, PowerOff: true, LogEvent: true}
+	if res != expect {
+		t.Errorf("DCMI power cap check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	collPowerCapOutput = `
+    Current Limit State: No Active Power Limit
+    Exception actions:   Log Event to SEL
+    Power Limit:         0 Watts
+    Correction time:     0 milliseconds
+    Sampling period:     0 seconds
+`
+	res, err = splitDcmiPowerCapOutput(collPowerCapOutput)
+	if err != nil {
+		t.Errorf("splitDcmiPowerCapOutput() call failed. Reason: %s", err)
+	}
+	expect = dcmiPowerCap{LogEvent: true}
+	if res != expect {
+		t.Errorf("Inactive DCMI power cap check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	if _, err := splitDcmiPowerCapOutput("DCMI request failed because: Invalid command (c1)"); err == nil {
+		t.Errorf("Output without power limit was accepted")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestDCMISamplePeriod(t *testing.T) {
+	for yml, expect := range map[string]string{
+		"collectors: [dcmi-power]":                               "dcmi power reading 1_min",
+		"{collectors: [dcmi-power], dcmi_sample_period: now}":    "dcmi power reading",
+		"{collectors: [dcmi-power], dcmi_sample_period: 1_hour}": "dcmi power reading 1_hour",
+	} {
+		var config IPMIConfig
+		if err := yaml.Unmarshal([]byte(yml), &config); err != nil {
+			t.Errorf("Module %s not loaded.\n Error is: %s", yml, err)
+			continue
+		}
+		if got := strings.Join(dcmiPowerReadingCommand(config), " "); got != expect {
+			t.Errorf("DCMI power reading command check failed for %s.\n Expect: %s\n Got: %s", yml, expect, got)
+		}
+	}
+	var config IPMIConfig
+	if err := yaml.Unmarshal([]byte("{collectors: [dcmi-power], dcmi_sample_period: 2_min}"), &config); err == nil {
+		t.Errorf("Module with unknown DCMI sample period was loaded.\n")
+	}
+}
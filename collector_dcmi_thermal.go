@@ -0,0 +1,185 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(dcmiThermalPolicyCollector{})
+}
+
+// dcmiThermalPolicyCollector collects the DCMI thermal limit policies of the
+// entities configured in dcmi_thermal_entities.
+type dcmiThermalPolicyCollector struct{}
+
+func (dcmiThermalPolicyCollector) Name() string {
+	return "dcmi-thermal"
+}
+
+func (dcmiThermalPolicyCollector) Commands(config IPMIConfig) [][]string {
+	var commands [][]string
+	for _, entity := range config.DCMIThermalEntities {
+		commands = append(commands, []string{"dcmi", "thermalpolicy", "get", dcmiThermalEntities[entity], "1"})
+	}
+	return commands
+}
+
+func (dcmiThermalPolicyCollector) Parse(outputs []string) (interface{}, error) {
+	var policies []dcmiThermalPolicy
+	for _, output := range outputs {
+		policy, err := splitDcmiThermalPolicyOutput(output)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func (dcmiThermalPolicyCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	for i, policy := range data.([]dcmiThermalPolicy) {
+		entity := target.config.DCMIThermalEntities[i]
+		ch <- prometheus.MustNewConstMetric(
+			dcmiThermalLimitDesc,
+			prometheus.GaugeValue,
+			policy.TemperatureLimit,
+			entity,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			dcmiThermalExceptionTimeDesc,
+			prometheus.GaugeValue,
+			policy.ExceptionTime,
+			entity,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			dcmiThermalActionDesc,
+			prometheus.GaugeValue,
+			boolToFloat(policy.PowerOff),
+			entity, "power_off",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			dcmiThermalActionDesc,
+			prometheus.GaugeValue,
+			boolToFloat(policy.LogEvent),
+			entity, "log",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			dcmiThermalEnabledDesc,
+			prometheus.GaugeValue,
+			boolToFloat(policy.PowerOff || policy.LogEvent),
+			entity,
+		)
+	}
+}
+
+// Records implements recordCounter.
+func (dcmiThermalPolicyCollector) Records(data interface{}) int {
+	return len(data.([]dcmiThermalPolicy))
+}
+
+// ValidateConfig implements configValidator.
+func (dcmiThermalPolicyCollector) ValidateConfig(config IPMIConfig) error {
+	for _, entity := range config.DCMIThermalEntities {
+		if _, ok := dcmiThermalEntities[entity]; !ok {
+			return fmt.Errorf("unknown dcmi_thermal_entities entity: %s (must be inlet, cpu or baseboard)", entity)
+		}
+	}
+	return nil
+}
+
+// RequiresIPMI20 implements ipmi20Requirer, as DCMI is based on IPMI 2.0.
+func (dcmiThermalPolicyCollector) RequiresIPMI20() bool {
+	return true
+}
+
+// ScrapeInterval implements scrapeIntervalHinter, as thermal policies are
+// configuration and rarely change.
+func (dcmiThermalPolicyCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+// dcmiThermalEntities maps the entity names accepted in dcmi_thermal_entities
+// to DCMI entity IDs.
+var dcmiThermalEntities = map[string]string{
+	"inlet":     "0x40",
+	"cpu":       "0x41",
+	"baseboard": "0x42",
+}
+
+var (
+	dcmiThermalLimitRegex         = regexp.MustCompile(`^\s*Temperature\sLimit\s+(?P<value>\d+)\sdegrees`)
+	dcmiThermalExceptionTimeRegex = regexp.MustCompile(`^\s*Exception\sTime\s+(?P<value>\d+)\sseconds`)
+	dcmiThermalPowerOffRegex      = regexp.MustCompile(`^\s*Hard\sPower\sOff\ssystem\sand\slog\sevent:\s*(?P<value>\w+)`)
+	dcmiThermalLogEventRegex      = regexp.MustCompile(`^\s*Log\sevent\sto\sSEL\sonly:\s*(?P<value>\w+)`)
+)
+
+type dcmiThermalPolicy struct {
+	TemperatureLimit float64
+	ExceptionTime    float64
+	PowerOff         bool
+	LogEvent         bool
+}
+
+var (
+	dcmiThermalLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "thermal_policy_temperature_limit_celsius"),
+		"Temperature limit of the DCMI thermal policy of an entity.",
+		[]string{"entity"},
+		nil,
+	)
+
+	dcmiThermalExceptionTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "thermal_policy_exception_time_seconds"),
+		"Time the temperature limit of the DCMI thermal policy of an entity may be exceeded before the exception actions are taken.",
+		[]string{"entity"},
+		nil,
+	)
+
+	dcmiThermalActionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "thermal_policy_action"),
+		"Whether an exception action of the DCMI thermal policy of an entity is active (1) or not (0).",
+		[]string{"entity", "action"},
+		nil,
+	)
+
+	dcmiThermalEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dcmi", "thermal_policy_enabled"),
+		"Whether the DCMI thermal policy of an entity takes any exception action (1) or not (0).",
+		[]string{"entity"},
+		nil,
+	)
+)
+
+func splitDcmiThermalPolicyOutput(ipmitoolOutput string) (dcmiThermalPolicy, error) {
+	var policy dcmiThermalPolicy
+	var found bool
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := dcmiThermalLimitRegex.FindStringSubmatch(line); m != nil {
+			policy.TemperatureLimit, _ = strconv.ParseFloat(m[1], 64)
+			found = true
+		} else if m := dcmiThermalExceptionTimeRegex.FindStringSubmatch(line); m != nil {
+			policy.ExceptionTime, _ = strconv.ParseFloat(m[1], 64)
+		} else if m := dcmiThermalPowerOffRegex.FindStringSubmatch(line); m != nil {
+			policy.PowerOff = m[1] == "active"
+		} else if m := dcmiThermalLogEventRegex.FindStringSubmatch(line); m != nil {
+			policy.LogEvent = m[1] == "active"
+		}
+	}
+	if !found {
+		return policy, fmt.Errorf("no temperature limit in output: %q", ipmitoolOutput)
+	}
+	return policy, nil
+}
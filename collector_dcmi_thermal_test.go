@@ -0,0 +1,31 @@
+//go:build !nodcmi
+// +build !nodcmi
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitDcmiThermalPolicyOutput(t *testing.T) {
+	collThermalOutput := `
+    Persistence flag is:                      set
+    Exception Actions, taken if the Temperature Limit exceeded:
+        Hard Power Off system and log event:  inactive
+        Log event to SEL only:                active
+    Temperature Limit                         35 degrees
+    Exception Time                            60 seconds
+    Statistics Time                           20 seconds`
+	res, err := splitDcmiThermalPolicyOutput(collThermalOutput)
+	if err != nil {
+		t.Errorf("splitDcmiThermalPolicyOutput() call failed. Reason: %s", err)
+	}
+	expect := dcmiThermalPolicy{TemperatureLimit: 35, ExceptionTime: 60, LogEvent: true}
+	if res != expect {
+		t.Errorf("Thermal policy check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	if _, err := splitDcmiThermalPolicyOutput("Invalid command"); err == nil {
+		t.Errorf("Output without thermal policy was accepted")
+	}
+}
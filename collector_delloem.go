@@ -0,0 +1,172 @@
+//go:build !nodelloem
+// +build !nodelloem
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(dellOEMCollector{})
+}
+
+// dellOEMCollector collects the power tracking statistics of Dell iDRACs,
+// which are only available through the Dell OEM extension of ipmitool. It is
+// skipped for other vendors by their profiles.
+type dellOEMCollector struct{}
+
+func (dellOEMCollector) Name() string {
+	return "delloem"
+}
+
+func (dellOEMCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"delloem", "powermonitor"}}
+}
+
+func (dellOEMCollector) Parse(outputs []string) (interface{}, error) {
+	return splitPowerMonitorOutput(outputs[0])
+}
+
+func (dellOEMCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	pm := data.(powerMonitor)
+	ch <- prometheus.MustNewConstMetric(dellEnergyDesc, prometheus.CounterValue, pm.EnergyJoules)
+	ch <- prometheus.MustNewConstMetric(dellEnergyStartDesc, prometheus.GaugeValue, pm.EnergyStart)
+	ch <- prometheus.MustNewConstMetric(dellPeakPowerDesc, prometheus.GaugeValue, pm.PeakPower)
+	ch <- prometheus.MustNewConstMetric(dellPeakPowerTimeDesc, prometheus.GaugeValue, pm.PeakPowerTime)
+	ch <- prometheus.MustNewConstMetric(dellPeakCurrentDesc, prometheus.GaugeValue, pm.PeakCurrent)
+	ch <- prometheus.MustNewConstMetric(dellPeakCurrentTimeDesc, prometheus.GaugeValue, pm.PeakCurrentTime)
+}
+
+// powerMonitorTimeLayout is the layout of the times printed by `ipmitool
+// delloem powermonitor`, e.g. "Mon Jun 19 13:38:53 2017".
+const powerMonitorTimeLayout = time.ANSIC
+
+// powerMonitor holds the power tracking statistics of an iDRAC. Timestamps are
+// in seconds since the Unix epoch, and NaN if not reported.
+type powerMonitor struct {
+	EnergyJoules    float64
+	EnergyStart     float64
+	PeakPower       float64
+	PeakPowerTime   float64
+	PeakCurrent     float64
+	PeakCurrentTime float64
+}
+
+var (
+	dellEnergyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dell", "energy_joules_total"),
+		"Cumulative energy consumption tracked by the iDRAC since the start time, in Joules.",
+		nil,
+		nil,
+	)
+
+	dellEnergyStartDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dell", "energy_start_timestamp_seconds"),
+		"Time the iDRAC started tracking the cumulative energy consumption, as seconds since the Unix epoch.",
+		nil,
+		nil,
+	)
+
+	dellPeakPowerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dell", "peak_power_watts"),
+		"Peak system power tracked by the iDRAC in Watts.",
+		nil,
+		nil,
+	)
+
+	dellPeakPowerTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dell", "peak_power_timestamp_seconds"),
+		"Time of the peak system power, as seconds since the Unix epoch.",
+		nil,
+		nil,
+	)
+
+	dellPeakCurrentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dell", "peak_current_amperes"),
+		"Peak system amperage tracked by the iDRAC in Amperes.",
+		nil,
+		nil,
+	)
+
+	dellPeakCurrentTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dell", "peak_current_timestamp_seconds"),
+		"Time of the peak system amperage, as seconds since the Unix epoch.",
+		nil,
+		nil,
+	)
+)
+
+// splitPowerMonitorOutput parses the output of `ipmitool delloem
+// powermonitor`, which prints one block of "name : value" lines per
+// statistic, introduced by its name.
+func splitPowerMonitorOutput(ipmitoolOutput string) (powerMonitor, error) {
+	result := powerMonitor{
+		EnergyJoules:    math.NaN(),
+		EnergyStart:     math.NaN(),
+		PeakPower:       math.NaN(),
+		PeakPowerTime:   math.NaN(),
+		PeakCurrent:     math.NaN(),
+		PeakCurrentTime: math.NaN(),
+	}
+	var statistic string
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(fields[0])
+		value := strings.TrimSpace(fields[1])
+		switch {
+		case name == "Statistic":
+			statistic = value
+		case statistic == "Cumulative Energy Consumption" && name == "Start Time":
+			result.EnergyStart = parsePowerMonitorTime(value)
+		case statistic == "Cumulative Energy Consumption" && name == "Reading":
+			// Reported in kWh.
+			result.EnergyJoules = parsePowerMonitorReading(value) * 3.6e6
+		case statistic == "System Peak Power" && name == "Peak Time":
+			result.PeakPowerTime = parsePowerMonitorTime(value)
+		case statistic == "System Peak Power" && name == "Peak Reading":
+			result.PeakPower = parsePowerMonitorReading(value)
+		case statistic == "System Peak Amperage" && name == "Peak Time":
+			result.PeakCurrentTime = parsePowerMonitorTime(value)
+		case statistic == "System Peak Amperage" && name == "Peak Reading":
+			result.PeakCurrent = parsePowerMonitorReading(value)
+		}
+	}
+	if math.IsNaN(result.EnergyJoules) && math.IsNaN(result.PeakPower) {
+		return result, fmt.Errorf("no power tracking statistics in output: %q", ipmitoolOutput)
+	}
+	return result, nil
+}
+
+// parsePowerMonitorReading parses readings like "2584.2 kWh" or "455 W".
+func parsePowerMonitorReading(value string) float64 {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return math.NaN()
+	}
+	reading, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return reading
+}
+
+func parsePowerMonitorTime(value string) float64 {
+	t, err := time.Parse(powerMonitorTimeLayout, value)
+	if err != nil {
+		return math.NaN()
+	}
+	return float64(t.Unix())
+}
@@ -0,0 +1,46 @@
+//go:build !nodelloem
+// +build !nodelloem
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitPowerMonitorOutput(t *testing.T) {
+	collPowerMonitorOutput := `Power Tracking Statistics
+Statistic      : Cumulative Energy Consumption
+Start Time     : Mon Jun 19 13:38:53 2017
+Finish Time    : Mon Oct  1 12:00:05 2018
+Reading        : 2584.2 kWh
+
+Statistic      : System Peak Power
+Start Time     : Mon Jun 19 13:38:53 2017
+Peak Time      : Wed Sep 26 10:25:49 2018
+Peak Reading   : 455 W
+
+Statistic      : System Peak Amperage
+Start Time     : Mon Jun 19 13:38:53 2017
+Peak Time      : Wed Sep 26 10:25:50 2018
+Peak Reading   : 2.6 A
+`
+	res, err := splitPowerMonitorOutput(collPowerMonitorOutput)
+	if err != nil {
+		t.Errorf("splitPowerMonitorOutput() call failed. Reason: %s", err)
+	}
+	expect := powerMonitor{
+		EnergyJoules:    2584.2 * 3.6e6,
+		EnergyStart:     1497879533,
+		PeakPower:       455,
+		PeakPowerTime:   1537957549,
+		PeakCurrent:     2.6,
+		PeakCurrentTime: 1537957550,
+	}
+	if res != expect {
+		t.Errorf("Power monitor check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	if _, err := splitPowerMonitorOutput("Invalid OEM command\n"); err == nil {
+		t.Errorf("Output without power tracking statistics was accepted")
+	}
+}
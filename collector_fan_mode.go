@@ -0,0 +1,67 @@
+//go:build !nofanmode
+// +build !nofanmode
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(fanModeCollector{})
+}
+
+// fanModeCollector collects the fan mode of Supermicro boards through their
+// OEM raw command. It is skipped for other vendors by their profiles.
+type fanModeCollector struct{}
+
+func (fanModeCollector) Name() string {
+	return "fan-mode"
+}
+
+func (fanModeCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"raw", "0x30", "0x45", "0x00"}}
+}
+
+func (fanModeCollector) Parse(outputs []string) (interface{}, error) {
+	return getFanMode(outputs[0])
+}
+
+func (fanModeCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	mode := data.(string)
+	for _, m := range fanModes {
+		ch <- prometheus.MustNewConstMetric(
+			fanModeDesc,
+			prometheus.GaugeValue,
+			boolToFloat(m == mode),
+			m,
+		)
+	}
+}
+
+// fanModes are the Supermicro fan modes by their raw value.
+var fanModes = []string{"standard", "full", "optimal", "pue", "heavy_io"}
+
+var fanModeDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "fan", "mode"),
+	"Whether the Supermicro fan mode is set to the mode (1) or not (0).",
+	[]string{"mode"},
+	nil,
+)
+
+// getFanMode decodes the response of the Supermicro "get fan mode" command,
+// a single byte in hex, e.g. " 02".
+func getFanMode(ipmitoolOutput string) (string, error) {
+	value, err := strconv.ParseUint(strings.TrimSpace(ipmitoolOutput), 16, 8)
+	if err != nil {
+		return "", fmt.Errorf("no fan mode in output: %q", ipmitoolOutput)
+	}
+	if int(value) >= len(fanModes) {
+		return "", fmt.Errorf("unknown fan mode: %d", value)
+	}
+	return fanModes[value], nil
+}
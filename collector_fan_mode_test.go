@@ -0,0 +1,30 @@
+//go:build !nofanmode
+// +build !nofanmode
+
+package main
+
+import (
+	"testing"
+)
+
+func TestGetFanMode(t *testing.T) {
+	for output, expect := range map[string]string{
+		" 00\n": "standard",
+		" 01\n": "full",
+		" 02\n": "optimal",
+		" 04\n": "heavy_io",
+	} {
+		res, err := getFanMode(output)
+		if err != nil {
+			t.Errorf("getFanMode() call failed. Reason: %s", err)
+		}
+		if res != expect {
+			t.Errorf("Fan mode check failed for %q.\n Expect: %s\n Got: %s", output, expect, res)
+		}
+	}
+	for _, output := range []string{" 09\n", "Unable to send RAW command\n"} {
+		if _, err := getFanMode(output); err == nil {
+			t.Errorf("Invalid fan mode output was accepted: %q", output)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+//go:build !nofru
+// +build !nofru
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+func init() {
+	registerCollector(fruCollector{})
+}
+
+// fruCollector collects the FRU inventory.
+type fruCollector struct{}
+
+func (fruCollector) Name() string {
+	return "fru"
+}
+
+func (fruCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"fru", "list"}}
+}
+
+func (fruCollector) Parse(outputs []string) (interface{}, error) {
+	return splitFruOutput(outputs[0])
+}
+
+func (fruCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	var boardDateSeen bool
+	for _, data := range data.([]fruData) {
+		if value, ok := anonymizeInfo(target.config, data.Name, data.Value); ok {
+			ch <- prometheus.MustNewConstMetric(
+				fruInfo,
+				prometheus.GaugeValue,
+				1,
+				sanitizeName(target.config.NameSanitization, data.RawName), value, data.DeviceID, data.Device,
+			)
+		}
+		if data.Name == "BoardMfgDate" && !boardDateSeen {
+			boardDateSeen = true
+			date, err := parseFRUDate(data.Value)
+			if err != nil {
+				log.Debugf("Failed to parse board manufacturing date from %s: %s", targetName(target.host), err)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				fruBoardMfgTimestampDesc,
+				prometheus.GaugeValue,
+				float64(date.Unix()),
+			)
+		}
+	}
+}
+
+// Records implements recordCounter.
+func (fruCollector) Records(data interface{}) int {
+	return len(data.([]fruData))
+}
+
+// ScrapeInterval implements scrapeIntervalHinter.
+func (fruCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+var (
+	// fruFieldRegex splits "Name : Value" lines at the first colon preceded
+	// by whitespace, so that colons in values, e.g. in MAC addresses, are
+	// kept. The value may be empty.
+	fruFieldRegex = regexp.MustCompile(`^\s*(\S.*?)\s+:(?:\s+(.*?))?\s*$`)
+	// fruDeviceRegex matches the first line of every FRU device, e.g.
+	// "FRU Device Description : Builtin FRU Device (ID 0)".
+	fruDeviceRegex = regexp.MustCompile(`^FRU Device Description\s*:\s*(.*?)\s*(?:\(ID\s*(\d+)\))?\s*$`)
+)
+
+type fruData struct {
+	// Name is the field name with whitespace stripped, e.g. "BoardSerial",
+	// and RawName the name as printed by ipmitool.
+	Name    string
+	RawName string
+	Value   string
+	// Device and DeviceID identify the FRU device the field belongs to, e.g.
+	// "Builtin FRU Device" and "0". Multi-node and blade systems report
+	// several devices.
+	Device   string
+	DeviceID string
+}
+
+var (
+	fruInfo = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fru", "info"),
+		"Constant metric with value '1' providing details from FRU.",
+		[]string{"name", "value", "fru_id", "fru_device"},
+		nil,
+	)
+
+	fruBoardMfgTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fru", "board_mfg_timestamp_seconds"),
+		"Board manufacturing date from FRU as Unix timestamp.",
+		nil,
+		nil,
+	)
+)
+
+// splitFruOutput parses the fields of `ipmitool fru list`. Field names are
+// returned with whitespace stripped, e.g. "BoardSerial", while values keep
+// their inner spacing.
+func splitFruOutput(impitoolOutput string) ([]fruData, error) {
+	var result []fruData
+
+	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+
+	var device, deviceID string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := fruDeviceRegex.FindStringSubmatch(line); m != nil {
+			device, deviceID = m[1], m[2]
+		}
+		// Skip blank lines and messages like "Device not present" of FRU
+		// devices without data, and count any other line without field.
+		field := fruFieldRegex.FindStringSubmatch(line)
+		if field == nil {
+			if strings.TrimSpace(line) != "" && !strings.Contains(line, "not present") {
+				log.Debugf("Skipping malformed FRU line: %s", line)
+				parseFailures.WithLabelValues("fru").Inc()
+			}
+			continue
+		}
+		result = append(result, fruData{
+			Name:     strings.Join(strings.Fields(field[1]), ""),
+			RawName:  field[1],
+			Value:    field[2],
+			Device:   device,
+			DeviceID: deviceID,
+		})
+	}
+	return result, scanner.Err()
+}
+
+// fruDateLayouts are the date formats printed for FRU manufacturing dates by
+// the various ipmitool versions.
+var fruDateLayouts = []string{
+	time.ANSIC,                        // Mon Jan  2 15:04:05 2006
+	"Mon 02 Jan 2006 03:04:05 PM MST", // ipmitool >= 1.8.19
+	"Mon Jan _2 15:04:05 MST 2006",
+	"01/02/2006 15:04:05",
+	"01/02/06 15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// parseFRUDate parses a FRU manufacturing date. Dates without a time zone
+// are assumed to be in UTC.
+func parseFRUDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range fruDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unknown date format: %q", value)
+}
@@ -0,0 +1,125 @@
+//go:build !nofru
+// +build !nofru
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFruOutput(t *testing.T) {
+	collFruOutput := `FRU Device Description : Builtin FRU Device (ID 0)
+Chassis Type          : Other
+Chassis Part Number   : CSE-747BTS-R2K04BP
+Chassis Serial        : C7470KH08MS0040
+Board Mfg Date        : Mon Jan  1 03:00:00 1996
+Board Mfg             : Supermicro
+Board Serial          : VM187S012298
+Board Part Number     : X10DRG-Q
+Product Manufacturer  : Supermicro
+Product Part Number   : SYS-7048GR-TR
+Product Serial        : E16953528901097`
+	res, err := splitFruOutput(collFruOutput)
+	expectProductPN := "SYS-7048GR-TR"
+	expectProductMfg := "Supermicro"
+	if err != nil {
+		t.Errorf("splitFruOutput() call failed. Reason: %s", err)
+	}
+	if res[9].Name != "ProductPartNumber" && res[9].Value != expectProductPN {
+		t.Errorf("Product Part Number check failed.\n Expect:\n value: %s\n Got:\n value: %s", expectProductPN, res[9].Value)
+	}
+	if res[5].Name != "FirmwareRevision" && res[5].Value != expectProductMfg {
+		t.Errorf("Board Mfg check failed.\n Expect:\n value: %s\n Got:\n value: %s", expectProductMfg, res[5].Value)
+	}
+}
+
+func TestSplitFruOutputDevices(t *testing.T) {
+	collFruOutput := `FRU Device Description : Builtin FRU Device (ID 0)
+ Board Mfg             : Supermicro
+ Board Serial          : VM187S012298
+
+FRU Device Description : Node 2 (ID 2)
+ Board Mfg             : Supermicro
+ Board Serial          : VM187S012299
+
+FRU Device Description : DIMM0 (ID 3)
+ Device not present (Requested sensor, data, or record not found)
+
+FRU Device Description : PSU1 (ID 4)
+ Board Mfg Garbled
+`
+	before := parseFailureCount(t, "fru")
+	res, err := splitFruOutput(collFruOutput)
+	if err != nil {
+		t.Errorf("splitFruOutput() call failed. Reason: %s", err)
+	}
+	expect := []fruData{
+		{Name: "FRUDeviceDescription", RawName: "FRU Device Description", Value: "Builtin FRU Device (ID 0)", Device: "Builtin FRU Device", DeviceID: "0"},
+		{Name: "BoardMfg", RawName: "Board Mfg", Value: "Supermicro", Device: "Builtin FRU Device", DeviceID: "0"},
+		{Name: "BoardSerial", RawName: "Board Serial", Value: "VM187S012298", Device: "Builtin FRU Device", DeviceID: "0"},
+		{Name: "FRUDeviceDescription", RawName: "FRU Device Description", Value: "Node 2 (ID 2)", Device: "Node 2", DeviceID: "2"},
+		{Name: "BoardMfg", RawName: "Board Mfg", Value: "Supermicro", Device: "Node 2", DeviceID: "2"},
+		{Name: "BoardSerial", RawName: "Board Serial", Value: "VM187S012299", Device: "Node 2", DeviceID: "2"},
+		{Name: "FRUDeviceDescription", RawName: "FRU Device Description", Value: "DIMM0 (ID 3)", Device: "DIMM0", DeviceID: "3"},
+		{Name: "FRUDeviceDescription", RawName: "FRU Device Description", Value: "PSU1 (ID 4)", Device: "PSU1", DeviceID: "4"},
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("FRU devices check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+	// Only the garbled line counts, not the blank lines or the device
+	// without data.
+	if got := parseFailureCount(t, "fru") - before; got != 1 {
+		t.Errorf("Parse failures check failed.\n Expect: 1\n Got: %v", got)
+	}
+}
+
+func TestFruFixtureParseFailures(t *testing.T) {
+	checkFixtureParseFailures(t, fruCollector{})
+}
+
+func TestSplitFruOutputSeparators(t *testing.T) {
+	collFruOutput := `FRU Device Description : Builtin FRU Device (ID 0)
+ Board Mfg Date        : Mon Jan  1 03:00:00 1996
+ Board Product         : PowerEdge R640
+ Board Extra           : MAC 00:11:22:33:44:55
+ Product Asset Tag     :
+ Multi Record Area
+  Power Supply Record: 500 W
+`
+	res, err := splitFruOutput(collFruOutput)
+	if err != nil {
+		t.Errorf("splitFruOutput() call failed. Reason: %s", err)
+	}
+	expect := []fruData{
+		{Name: "FRUDeviceDescription", RawName: "FRU Device Description", Value: "Builtin FRU Device (ID 0)", Device: "Builtin FRU Device", DeviceID: "0"},
+		{Name: "BoardMfgDate", RawName: "Board Mfg Date", Value: "Mon Jan  1 03:00:00 1996", Device: "Builtin FRU Device", DeviceID: "0"},
+		{Name: "BoardProduct", RawName: "Board Product", Value: "PowerEdge R640", Device: "Builtin FRU Device", DeviceID: "0"},
+		{Name: "BoardExtra", RawName: "Board Extra", Value: "MAC 00:11:22:33:44:55", Device: "Builtin FRU Device", DeviceID: "0"},
+		{Name: "ProductAssetTag", RawName: "Product Asset Tag", Value: "", Device: "Builtin FRU Device", DeviceID: "0"},
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("FRU separators check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+}
+
+func TestParseFRUDate(t *testing.T) {
+	expect := int64(820465200)
+	for _, value := range []string{
+		"Mon Jan  1 03:00:00 1996",
+		"Mon 01 Jan 1996 03:00:00 AM UTC",
+		"01/01/1996 03:00:00",
+		"1996-01-01 03:00:00",
+	} {
+		res, err := parseFRUDate(value)
+		if err != nil {
+			t.Errorf("parseFRUDate() call failed. Reason: %s", err)
+		}
+		if res.Unix() != expect {
+			t.Errorf("FRU date check failed for %q.\n Expect: %v\n Got: %v", value, expect, res.Unix())
+		}
+	}
+	if _, err := parseFRUDate("Unspecified"); err == nil {
+		t.Errorf("Unspecified FRU date was parsed.\n")
+	}
+}
@@ -0,0 +1,107 @@
+//go:build !nofwum
+// +build !nofwum
+
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(fwumCollector{})
+}
+
+// fwumCollector collects firmware details via the FWUM extension.
+type fwumCollector struct{}
+
+func (fwumCollector) Name() string {
+	return "fwum"
+}
+
+func (fwumCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"fwum", "info"}}
+}
+
+func (fwumCollector) Parse(outputs []string) (interface{}, error) {
+	return splitFwumOutput(outputs[0])
+}
+
+func (fwumCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	var firmwareRevision, manufacturerID string
+
+	for _, data := range data.([]fwumData) {
+		switch data.Name {
+		case "FirmwareRevision":
+			firmwareRevision = strconv.FormatFloat(data.Value, 'f', 6, 64)
+		case "ManufacturerId":
+			manufacturerID = strconv.FormatFloat(data.Value, 'f', 6, 64)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(
+		fwumInfo,
+		prometheus.GaugeValue,
+		1,
+		firmwareRevision, manufacturerID,
+	)
+}
+
+// Records implements recordCounter.
+func (fwumCollector) Records(data interface{}) int {
+	return len(data.([]fwumData))
+}
+
+// IgnoreExitStatus implements exitStatusIgnorer, because fwum returns exit
+// code 1 even if everything is OK. Be careful with it and properly check the
+// command output in Parse.
+func (fwumCollector) IgnoreExitStatus() bool {
+	return true
+}
+
+// ScrapeInterval implements scrapeIntervalHinter.
+func (fwumCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+type fwumData struct {
+	Name  string
+	Value float64
+}
+
+var fwumInfo = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "fwum", "info"),
+	"Constant metric with value '1' providing details about the BMC.",
+	[]string{"firmware_revision", "manufacturer_id"},
+	nil,
+)
+
+func splitFwumOutput(impitoolOutput string) ([]fwumData, error) {
+	var result []fwumData
+
+	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+
+	var err error
+
+	for scanner.Scan() {
+		var data fwumData
+		line := scanner.Text()
+		trimmedL := strings.ReplaceAll(line, " ", "")
+		re := regexp.MustCompile(`:`)
+		sanitizedL := re.FindStringSubmatch(trimmedL)
+		if sanitizedL != nil {
+			splittedL := strings.Split(trimmedL, ":")
+			data.Name = splittedL[0]
+			data.Value, err = strconv.ParseFloat(splittedL[1], 64)
+			if err != nil {
+				return result, err
+			}
+		}
+		result = append(result, data)
+	}
+	return result, err
+}
@@ -0,0 +1,30 @@
+//go:build !nofwum
+// +build !nofwum
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitFwumOutput(t *testing.T) {
+	collFwumOutput := `FWUM extension Version 1.3
+
+IPMC Info
+=========
+Manufacturer Id           : 10876
+Board Id                  : 2130
+Firmware Revision         : 3.76`
+	res, err := splitFwumOutput(collFwumOutput)
+	expectManID := float64(10876)
+	expectFWVer := 3.76
+	if err != nil {
+		t.Errorf("splitFwumOutput() call failed. Reason: %s", err)
+	}
+	if res[4].Name != "ManufacturerId" && res[4].Value != expectManID {
+		t.Errorf("Manufacturer Id check failed.\n Expect:\n value: %f\n Got:\n value: %f", expectManID, res[4].Value)
+	}
+	if res[6].Name != "FirmwareRevision" && res[6].Value != expectFWVer {
+		t.Errorf("Firmware Revision check failed.\n Expect:\n value: %f\n Got:\n value: %f", expectFWVer, res[6].Value)
+	}
+}
@@ -0,0 +1,226 @@
+//go:build !nolan
+// +build !nolan
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(lanCollector{})
+}
+
+// lanCollector collects the LAN configuration of the BMC.
+type lanCollector struct{}
+
+func (lanCollector) Name() string {
+	return "lan"
+}
+
+// Commands prints the configuration of every channel in lan_channels, or of
+// the LAN channel ipmitool picks if none are configured.
+func (lanCollector) Commands(config IPMIConfig) [][]string {
+	if len(config.LANChannels) == 0 {
+		return [][]string{{"lan", "print"}}
+	}
+	var commands [][]string
+	for _, channel := range config.LANChannels {
+		commands = append(commands, []string{"lan", "print", strconv.Itoa(channel)})
+	}
+	return commands
+}
+
+func (lanCollector) Parse(outputs []string) (interface{}, error) {
+	var result [][]lanData
+	for _, output := range outputs {
+		data, err := splitLANOutput(output)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+func (lanCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	for i, channelData := range data.([][]lanData) {
+		// The channel is left empty if ipmitool picked it.
+		channel := ""
+		if i < len(target.config.LANChannels) {
+			channel = strconv.Itoa(target.config.LANChannels[i])
+		}
+		for _, data := range channelData {
+			value, ok := anonymizeInfo(target.config, data.Name, data.Value)
+			if !ok {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				lanInfo,
+				prometheus.GaugeValue,
+				1,
+				data.Name, value, channel,
+			)
+		}
+	}
+}
+
+// Records implements recordCounter, counting the LAN channels.
+func (lanCollector) Records(data interface{}) int {
+	return len(data.([][]lanData))
+}
+
+// ValidateConfig implements configValidator.
+func (lanCollector) ValidateConfig(config IPMIConfig) error {
+	seen := make(map[int]bool)
+	for _, channel := range config.LANChannels {
+		if channel < 0 || channel > 15 {
+			return fmt.Errorf("invalid lan_channels channel: %d (must be 0-15)", channel)
+		}
+		if seen[channel] {
+			return fmt.Errorf("duplicate lan_channels channel: %d", channel)
+		}
+		seen[channel] = true
+	}
+	return nil
+}
+
+// ScrapeInterval implements scrapeIntervalHinter.
+func (lanCollector) ScrapeInterval() time.Duration {
+	return time.Hour
+}
+
+var (
+	ipSourceRegex       = regexp.MustCompile(`^IP\sAddress\sSource\s*:\s*(?P<value>.*)`)
+	ipAddressRegex      = regexp.MustCompile(`^IP\sAddress\s*:\s*(?P<value>.*)`)
+	macAddressRegex     = regexp.MustCompile(`^MAC\sAddress\s*:\s*(?P<value>.*)`)
+	defaultGatewayRegex = regexp.MustCompile(`^Default\sGateway\sIP\s*:\s*(?P<value>.*)`)
+	vlanIDRegex         = regexp.MustCompile(`^802.1q\sVLAN\sID\s*:\s*(?P<value>.*)`)
+	vlanPriorityRegex   = regexp.MustCompile(`^802.1q\sVLAN\sPriority\s*:\s*(?P<value>.*)`)
+	subnetMaskRegex     = regexp.MustCompile(`^Subnet\sMask\s*:\s*(?P<value>.*)`)
+)
+
+type lanData struct {
+	Name  string
+	Value string
+}
+
+var lanInfo = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "lan", "info"),
+	"Constant metric with value '1' providing details from LAN.",
+	[]string{"name", "value", "channel"},
+	nil,
+)
+
+func splitLANOutput(impitoolOutput string) ([]lanData, error) {
+	var result []lanData
+
+	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+
+	var err error
+	for scanner.Scan() {
+		var data lanData
+		line := scanner.Text()
+		if len(line) > 0 {
+			ipSource := ipSourceRegex.FindStringSubmatch(line)
+			if ipSource != nil {
+				for i, name := range ipSourceRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "IPSource"
+					data.Value = strings.ReplaceAll(ipSource[i], " ", "")
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			ipAddress := ipAddressRegex.FindStringSubmatch(line)
+			if ipAddress != nil {
+				for i, name := range ipAddressRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "IPAddress"
+					data.Value = ipAddress[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			subnetMask := subnetMaskRegex.FindStringSubmatch(line)
+			if subnetMask != nil {
+				for i, name := range subnetMaskRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "SubnetMask"
+					data.Value = subnetMask[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			macMatch := macAddressRegex.FindStringSubmatch(line)
+			if macMatch != nil {
+				for i, name := range macAddressRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "MACAddress"
+					data.Value = macMatch[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			defGateway := defaultGatewayRegex.FindStringSubmatch(line)
+			if defGateway != nil {
+				for i, name := range defaultGatewayRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "DefaultGateway"
+					data.Value = defGateway[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			vlanID := vlanIDRegex.FindStringSubmatch(line)
+			if vlanID != nil {
+				for i, name := range vlanIDRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "VLANID"
+					data.Value = vlanID[i]
+					result = append(result, data)
+					break
+				}
+				continue
+			}
+			vlanPriority := vlanPriorityRegex.FindStringSubmatch(line)
+			if vlanPriority != nil {
+				for i, name := range vlanPriorityRegex.SubexpNames() {
+					if name != "value" {
+						continue
+					}
+					data.Name = "VLANPriority"
+					data.Value = vlanPriority[i]
+					result = append(result, data)
+					break
+				}
+				break
+			}
+		}
+	}
+	return result, err
+}
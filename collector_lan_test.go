@@ -0,0 +1,76 @@
+//go:build !nolan
+// +build !nolan
+
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSplitLANOutput(t *testing.T) {
+	collLANOutput := `Set in Progress         : Set Complete
+IP Address Source       : DHCP Address
+IP Address              : 10.1.2.23
+Subnet Mask             : 255.255.255.0
+MAC Address             : 0c:c4:7a:00:00:01
+Default Gateway IP      : 10.1.2.1
+802.1q VLAN ID          : Disabled
+802.1q VLAN Priority    : 0`
+	res, err := splitLANOutput(collLANOutput)
+	if err != nil {
+		t.Errorf("splitLANOutput() call failed. Reason: %s", err)
+	}
+	expect := map[string]string{
+		"IPSource":   "DHCPAddress",
+		"IPAddress":  "10.1.2.23",
+		"MACAddress": "0c:c4:7a:00:00:01",
+	}
+	for _, data := range res {
+		if value, ok := expect[data.Name]; ok && value != data.Value {
+			t.Errorf("LAN field %s check failed.\n Expect:\n value: %s\n Got:\n value: %s", data.Name, value, data.Value)
+		}
+		delete(expect, data.Name)
+	}
+	if len(expect) != 0 {
+		t.Errorf("LAN fields missing: %v", expect)
+	}
+}
+
+func TestLANChannels(t *testing.T) {
+	config := IPMIConfig{Anonymize: "none", LANChannels: []int{1, 8}}
+	expect := [][]string{{"lan", "print", "1"}, {"lan", "print", "8"}}
+	if res := (lanCollector{}).Commands(config); !reflect.DeepEqual(res, expect) {
+		t.Errorf("LAN commands check failed.\n Expect: %v\n Got: %v", expect, res)
+	}
+	if res := (lanCollector{}).Commands(IPMIConfig{}); !reflect.DeepEqual(res, [][]string{{"lan", "print"}}) {
+		t.Errorf("LAN commands without channels check failed.\n Expect: [[lan print]]\n Got: %v", res)
+	}
+
+	data, err := (lanCollector{}).Parse([]string{"IP Address              : 0.0.0.0\n", "IP Address              : 10.1.2.23\n"})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	ch := make(chan prometheus.Metric, 10)
+	(lanCollector{}).Emit(ch, ipmiTarget{config: config}, data)
+	close(ch)
+	got := make(map[string]string)
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("Writing metric failed: %s", err)
+		}
+		labels := make(map[string]string)
+		for _, l := range metric.Label {
+			labels[l.GetName()] = l.GetValue()
+		}
+		got[labels["channel"]] = labels["value"]
+	}
+	expectAddresses := map[string]string{"1": "0.0.0.0", "8": "10.1.2.23"}
+	if !reflect.DeepEqual(got, expectAddresses) {
+		t.Errorf("LAN channel label check failed.\n Expect: %v\n Got: %v", expectAddresses, got)
+	}
+}
@@ -0,0 +1,126 @@
+//go:build !nonicselection
+// +build !nonicselection
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(nicSelectionCollector{})
+}
+
+// nicSelectionCollector collects which network port the BMC uses, through
+// the Dell OEM commands of ipmitool. It is skipped for other vendors by their
+// profiles.
+type nicSelectionCollector struct{}
+
+func (nicSelectionCollector) Name() string {
+	return "nic-selection"
+}
+
+func (nicSelectionCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"delloem", "lan", "get"}, {"delloem", "lan", "get", "active"}}
+}
+
+func (nicSelectionCollector) Parse(outputs []string) (interface{}, error) {
+	return splitNICSelectionOutput(outputs[0], outputs[1])
+}
+
+func (nicSelectionCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	nic := data.(nicSelection)
+	ch <- prometheus.MustNewConstMetric(nicSelectionInfoDesc, prometheus.GaugeValue, 1, nic.Selection, nic.Primary)
+	ch <- prometheus.MustNewConstMetric(nicActiveInfoDesc, prometheus.GaugeValue, 1, nic.Active)
+	ch <- prometheus.MustNewConstMetric(nicDedicatedDesc, prometheus.GaugeValue, boolToFloat(nic.Active == "dedicated"))
+	ch <- prometheus.MustNewConstMetric(nicFailoverActiveDesc, prometheus.GaugeValue, boolToFloat(nic.Active != nic.Primary))
+}
+
+// ScrapeInterval implements scrapeIntervalHinter, as the NIC selection is
+// configuration and failovers are rare.
+func (nicSelectionCollector) ScrapeInterval() time.Duration {
+	return 5 * time.Minute
+}
+
+// nicSelectionRegex matches the NIC selection modes printed by ipmitool, e.g.
+// "dedicated", "shared with lom2" or "shared with failover all loms".
+var nicSelectionRegex = regexp.MustCompile(`(?i)\b(dedicated|shared with (failover )?(lom\d|all loms))\b`)
+
+// nicSelection is the NIC the BMC is configured to use and the one it
+// actually uses: "dedicated", "lom1" to "lom4" or "none".
+type nicSelection struct {
+	Selection string
+	Primary   string
+	Active    string
+}
+
+var (
+	nicSelectionInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "nic_selection_info"),
+		"Constant metric with value '1' providing the NIC selection mode of the BMC and the primary NIC it implies.",
+		[]string{"selection", "primary"},
+		nil,
+	)
+
+	nicActiveInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "nic_active_info"),
+		"Constant metric with value '1' providing the NIC the BMC currently uses.",
+		[]string{"nic"},
+		nil,
+	)
+
+	nicDedicatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "nic_dedicated"),
+		"Whether the BMC currently uses its dedicated NIC (1) or a shared one (0).",
+		nil,
+		nil,
+	)
+
+	nicFailoverActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "nic_failover_active"),
+		"Whether the BMC uses another NIC than its primary one (1) or not (0).",
+		nil,
+		nil,
+	)
+)
+
+// splitNICSelectionOutput parses the outputs of `ipmitool delloem lan get`
+// and `ipmitool delloem lan get active`. With failover, the BMC shares LOM1
+// and fails over to the named LOMs.
+func splitNICSelectionOutput(selectionOutput, activeOutput string) (nicSelection, error) {
+	var result nicSelection
+	match := nicSelectionRegex.FindStringSubmatch(selectionOutput)
+	if match == nil {
+		return result, fmt.Errorf("no NIC selection in output: %q", selectionOutput)
+	}
+	result.Selection = strings.ToLower(match[1])
+	switch {
+	case result.Selection == "dedicated":
+		result.Primary = "dedicated"
+	case match[2] != "":
+		result.Primary = "lom1"
+	default:
+		result.Primary = strings.ToLower(match[3])
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(activeOutput))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.LastIndex(line, ":"); i >= 0 {
+			line = strings.TrimSpace(line[i+1:])
+		}
+		if line != "" {
+			result.Active = strings.ToLower(line)
+		}
+	}
+	if result.Active == "" {
+		return result, fmt.Errorf("no active NIC in output: %q", activeOutput)
+	}
+	return result, nil
+}
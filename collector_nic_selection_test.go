@@ -0,0 +1,34 @@
+//go:build !nonicselection
+// +build !nonicselection
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitNICSelectionOutput(t *testing.T) {
+	for _, c := range []struct {
+		selection, active string
+		expect            nicSelection
+	}{
+		{"\ndedicated\n", "\nActive LOM: dedicated\n", nicSelection{"dedicated", "dedicated", "dedicated"}},
+		{"\nshared with lom2\n", "\nLOM2\n", nicSelection{"shared with lom2", "lom2", "lom2"}},
+		{"\nShared with Failover All LOMs\n", "\nActive LOM: LOM2\n", nicSelection{"shared with failover all loms", "lom1", "lom2"}},
+	} {
+		res, err := splitNICSelectionOutput(c.selection, c.active)
+		if err != nil {
+			t.Errorf("splitNICSelectionOutput() call failed. Reason: %s", err)
+		}
+		if res != c.expect {
+			t.Errorf("NIC selection check failed.\n Expect: %+v\n Got: %+v", c.expect, res)
+		}
+	}
+
+	if _, err := splitNICSelectionOutput("Invalid OEM command\n", "dedicated"); err == nil {
+		t.Errorf("Output without NIC selection was accepted")
+	}
+	if _, err := splitNICSelectionOutput("dedicated", "\n"); err == nil {
+		t.Errorf("Output without active NIC was accepted")
+	}
+}
@@ -0,0 +1,223 @@
+//go:build !nonm
+// +build !nonm
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(nmCollector{})
+}
+
+// nmCollector collects the statistics and policies of Intel Node Manager,
+// which report power, thermal and airflow data in more detail than DCMI.
+type nmCollector struct{}
+
+func (nmCollector) Name() string {
+	return "nm"
+}
+
+func (nmCollector) Commands(config IPMIConfig) [][]string {
+	var commands [][]string
+	for _, statistic := range config.NMStatistics {
+		commands = append(commands, []string{"nm", "statistics", statistic})
+	}
+	for _, id := range config.NMPolicyIDs {
+		commands = append(commands, []string{"nm", "policy", "get", "policy_id", strconv.Itoa(id)})
+	}
+	return commands
+}
+
+func (nmCollector) Parse(outputs []string) (interface{}, error) {
+	var records []nmRecord
+	for _, output := range outputs {
+		record, err := splitNMOutput(output)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (nmCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	records := data.([]nmRecord)
+	statistics := len(target.config.NMStatistics)
+	for i, statistic := range target.config.NMStatistics {
+		record := records[i]
+		for _, reading := range nmReadings {
+			ch <- prometheus.MustNewConstMetric(
+				nmStatisticDesc,
+				prometheus.GaugeValue,
+				record.value(reading),
+				statistic, reading,
+			)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			nmReportingPeriodDesc,
+			prometheus.GaugeValue,
+			record.value("statistics reporting period", "statistics reporting time period", "reporting period"),
+			statistic,
+		)
+	}
+	for i, id := range target.config.NMPolicyIDs {
+		record := records[statistics+i]
+		policyID := strconv.Itoa(id)
+		ch <- prometheus.MustNewConstMetric(
+			nmPolicyEnabledDesc,
+			prometheus.GaugeValue,
+			boolToFloat(record.enabled),
+			policyID,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			nmPolicyPowerLimitDesc,
+			prometheus.GaugeValue,
+			record.value("power limit"),
+			policyID,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			nmPolicyCorrectionTimeDesc,
+			prometheus.GaugeValue,
+			record.value("correction time limit")/1000,
+			policyID,
+		)
+	}
+}
+
+// Records implements recordCounter.
+func (nmCollector) Records(data interface{}) int {
+	return len(data.([]nmRecord))
+}
+
+// ValidateConfig implements configValidator.
+func (nmCollector) ValidateConfig(config IPMIConfig) error {
+	for _, statistic := range config.NMStatistics {
+		if !containsString(nmStatistics, statistic) {
+			return fmt.Errorf("unknown nm_statistics statistic: %s (must be one of %s)", statistic, strings.Join(nmStatistics, ", "))
+		}
+	}
+	for _, id := range config.NMPolicyIDs {
+		if id < 0 || id > 255 {
+			return fmt.Errorf("invalid nm_policy_ids policy ID: %d (must be 0-255)", id)
+		}
+	}
+	return nil
+}
+
+// RequiresIPMI20 implements ipmi20Requirer, as Node Manager commands are
+// bridged to the management engine.
+func (nmCollector) RequiresIPMI20() bool {
+	return true
+}
+
+// nmStatistics are the statistics `ipmitool nm statistics` reports.
+var nmStatistics = []string{
+	"power", "temps", "throttling", "performance", "cpu_temp", "mem_temp",
+	"requests", "response", "policy_power", "policy_temps", "policy_throt",
+}
+
+// nmReadings are the readings of a Node Manager statistic.
+var nmReadings = []string{"current", "minimum", "maximum", "average"}
+
+// nmRecord holds the "name: value" lines of an `ipmitool nm` output, keyed by
+// the lowercased name.
+type nmRecord struct {
+	values map[string]string
+	// enabled is true if a policy is reported as enabled.
+	enabled bool
+}
+
+// value returns the number at the start of the first of the named values
+// found, or NaN. ipmitool versions differ in the names of some values.
+func (r nmRecord) value(names ...string) float64 {
+	for _, name := range names {
+		fields := strings.Fields(r.values[name])
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return value
+	}
+	return math.NaN()
+}
+
+var (
+	nmStatisticDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nm", "statistic"),
+		"Reading of an Intel Node Manager statistic over its reporting period, in the units of the statistic (e.g. Watts for power, degree Celsius for temps).",
+		[]string{"statistic", "reading"},
+		nil,
+	)
+
+	nmReportingPeriodDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nm", "statistic_reporting_period_seconds"),
+		"Period the readings of an Intel Node Manager statistic are collected over.",
+		[]string{"statistic"},
+		nil,
+	)
+
+	nmPolicyEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nm", "policy_enabled"),
+		"Whether an Intel Node Manager policy is enabled (1) or not (0).",
+		[]string{"policy_id"},
+		nil,
+	)
+
+	nmPolicyPowerLimitDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nm", "policy_power_limit_watts"),
+		"Power limit of an Intel Node Manager policy in Watts.",
+		[]string{"policy_id"},
+		nil,
+	)
+
+	nmPolicyCorrectionTimeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "nm", "policy_correction_time_seconds"),
+		"Time an Intel Node Manager policy may exceed its limit before correcting it.",
+		[]string{"policy_id"},
+		nil,
+	)
+)
+
+// splitNMOutput parses the output of `ipmitool nm statistics` and `ipmitool
+// nm policy get`. Policies report whether they are enabled on a line like
+// "Policy is enabled" or "Policy is not enabled".
+func splitNMOutput(ipmitoolOutput string) (nmRecord, error) {
+	record := nmRecord{values: make(map[string]string)}
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Policy is ") {
+			state := strings.TrimPrefix(line, "Policy is ")
+			record.enabled = strings.HasPrefix(state, "enabled")
+			continue
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "error" {
+			return record, fmt.Errorf("ipmitool error: %s", strings.TrimSpace(fields[1]))
+		}
+		record.values[name] = strings.TrimSpace(fields[1])
+		if name == "policy enabled" {
+			record.enabled = strings.EqualFold(record.values[name], "true") || strings.EqualFold(record.values[name], "enabled")
+		}
+	}
+	if len(record.values) == 0 {
+		return record, fmt.Errorf("no Node Manager data in output: %q", ipmitoolOutput)
+	}
+	return record, nil
+}
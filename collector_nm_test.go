@@ -0,0 +1,62 @@
+//go:build !nonm
+// +build !nonm
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitNMOutput(t *testing.T) {
+	collStatisticsOutput := `    Power Statistics:
+        Statistics:
+            Current:                                   177 Watts
+            Minimum:                                   110 Watts
+            Maximum:                                   350 Watts
+            Average:                                   180 Watts
+            Timestamp:                                 Mon Jun 28 18:07:26 2021
+            Statistics reporting time period:          34023 seconds
+            Domain Id:                                 platform
+            Power global administrative state:         enabled`
+	res, err := splitNMOutput(collStatisticsOutput)
+	if err != nil {
+		t.Errorf("splitNMOutput() call failed. Reason: %s", err)
+	}
+	for name, expect := range map[string]float64{"current": 177, "minimum": 110, "maximum": 350, "average": 180} {
+		if got := res.value(name); got != expect {
+			t.Errorf("NM statistic check failed for %s.\n Expect: %v\n Got: %v", name, expect, got)
+		}
+	}
+	if got := res.value("statistics reporting period", "statistics reporting time period"); got != 34023 {
+		t.Errorf("NM reporting period check failed.\n Expect: 34023\n Got: %v", got)
+	}
+	if got := res.value("domain id"); !math.IsNaN(got) {
+		t.Errorf("NaN conversion failed.\n Value: %f is not math.NaN", got)
+	}
+
+	collPolicyOutput := `    Power domain:                             platform
+    Policy is enabled globally enabled per-domain
+    Policy Trigger Type:                      none
+    Policy Exception Actions:                 alert
+    Power Limit:                              350 Watts
+    Correction Time Limit:                    6000 milliseconds
+    Trigger Limit:                            0 units
+    Statistics Reporting Period:              10 seconds`
+	res, err = splitNMOutput(collPolicyOutput)
+	if err != nil {
+		t.Errorf("splitNMOutput() call failed. Reason: %s", err)
+	}
+	if !res.enabled || res.value("power limit") != 350 || res.value("correction time limit") != 6000 {
+		t.Errorf("NM policy check failed.\n Expect: enabled, 350 Watts, 6000 ms\n Got: %v, %v, %v", res.enabled, res.value("power limit"), res.value("correction time limit"))
+	}
+
+	res, err = splitNMOutput("    Policy is not enabled\n    Power Limit: 0 Watts")
+	if err != nil || res.enabled {
+		t.Errorf("Disabled NM policy check failed.\n Expect: false, <nil>\n Got: %v, %v", res.enabled, err)
+	}
+
+	if _, err := splitNMOutput("Error: NM request failed\n"); err == nil {
+		t.Errorf("Output without Node Manager data was accepted")
+	}
+}
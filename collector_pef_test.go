@@ -0,0 +1,43 @@
+//go:build !nopef
+// +build !nopef
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitPEFOutput(t *testing.T) {
+	collPEFOutput := ` Version                 : 1.5 (IPMI 2.0)
+ PEF table size          : 40
+ Alert policy table size : 60
+ PEF actions             : Alert, Power-off, Reset, Power-cycle
+
+ Last SEL addition       : 06/28/2021 18:07:26
+ Last SEL record ID      : 0x0157
+ Last S/W processed ID   : 0x0150
+ Last BMC processed ID   : 0x0157
+ PEF control             : PEF, event messages
+ PEF action              : Alert`
+	res, err := splitPEFOutput(collPEFOutput)
+	if err != nil {
+		t.Errorf("splitPEFOutput() call failed. Reason: %s", err)
+	}
+	expect := pefData{Enabled: true, FilterEntries: 40, LastSELRecord: 343, LastBMCProcessed: 343, LastSWProcessed: 336}
+	if res != expect {
+		t.Errorf("PEF check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	res, err = splitPEFOutput(" PEF control             : disabled")
+	if err != nil {
+		t.Errorf("splitPEFOutput() call failed. Reason: %s", err)
+	}
+	if res.Enabled || !math.IsNaN(res.FilterEntries) {
+		t.Errorf("PEF check failed for disabled PEF.\n Got: %+v", res)
+	}
+
+	if _, err := splitPEFOutput("Error: PEF not supported"); err == nil {
+		t.Errorf("Output without PEF control was accepted")
+	}
+}
@@ -0,0 +1,153 @@
+//go:build !nopicmg
+// +build !nopicmg
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+func init() {
+	registerCollector(picmgCollector{})
+}
+
+// picmgCollector collects the PICMG (AdvancedTCA) properties and site of the
+// controller and the hot-swap states of the FRUs it manages.
+type picmgCollector struct{}
+
+func (picmgCollector) Name() string {
+	return "picmg"
+}
+
+func (picmgCollector) Commands(config IPMIConfig) [][]string {
+	// Sensor type F0h is the PICMG FRU Hot Swap sensor.
+	return [][]string{{"picmg", "properties"}, {"picmg", "addrinfo"}, {"sdr", "type", "0xf0"}}
+}
+
+func (picmgCollector) Parse(outputs []string) (interface{}, error) {
+	data := picmgData{
+		Properties: splitColonOutput(outputs[0]),
+		Address:    splitColonOutput(outputs[1]),
+		HotSwap:    splitHotSwapOutput(outputs[2]),
+	}
+	if data.Properties["PICMG Ext. Version"] == "" {
+		return nil, fmt.Errorf("no PICMG properties in output: %q", outputs[0])
+	}
+	return data, nil
+}
+
+func (picmgCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	picmg := data.(picmgData)
+	ch <- prometheus.MustNewConstMetric(
+		picmgInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		picmg.Properties["PICMG Ext. Version"], picmg.Properties["Max FRU Device ID"],
+	)
+	if siteType, siteID := picmg.Address["Site Type"], picmg.Address["Site ID"]; siteType != "" || siteID != "" {
+		ch <- prometheus.MustNewConstMetric(
+			picmgSiteInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			siteType, siteID, picmg.Address["Hardware Address"],
+		)
+	}
+	for _, s := range picmg.HotSwap {
+		ch <- prometheus.MustNewConstMetric(
+			picmgHotSwapStateDesc,
+			prometheus.GaugeValue,
+			float64(s.State),
+			s.Name, s.Entity,
+		)
+	}
+}
+
+type picmgData struct {
+	// Properties and Address hold the fields of `ipmitool picmg properties`
+	// and `ipmitool picmg addrinfo` by name.
+	Properties map[string]string
+	Address    map[string]string
+	HotSwap    []hotSwapState
+}
+
+type hotSwapState struct {
+	Name   string
+	Entity string
+	// State is the number of the M-state, e.g. 4 for M4 (FRU active).
+	State int
+}
+
+var (
+	hotSwapStateRegex = regexp.MustCompile(`M([0-7])`)
+
+	picmgInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "picmg", "info"),
+		"Constant metric with value '1' providing the PICMG extension version and the highest FRU device ID of the controller.",
+		[]string{"version", "max_fru_id"},
+		nil,
+	)
+
+	picmgSiteInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "picmg", "site_info"),
+		"Constant metric with value '1' providing the site (slot) of the controller in the shelf.",
+		[]string{"site_type", "site_id", "hardware_address"},
+		nil,
+	)
+
+	picmgHotSwapStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "picmg", "hotswap_state"),
+		"Hot-swap M-state reported by a FRU Hot Swap sensor (0=not installed, 1=inactive, 2=activation request, 3=activation in progress, 4=active, 5=deactivation request, 6=deactivation in progress, 7=communication lost).",
+		[]string{"name", "entity"},
+		nil,
+	)
+)
+
+// splitColonOutput parses "name : value" lines into a map.
+func splitColonOutput(ipmitoolOutput string) map[string]string {
+	result := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		result[strings.TrimSpace(fields[0])] = strings.TrimSpace(fields[1])
+	}
+	return result
+}
+
+// splitHotSwapOutput parses the FRU Hot Swap sensors printed by `ipmitool sdr
+// type`, whose reading is the transition to the current M-state, e.g.
+// "Transition to M4". Sensors without M-state are left out.
+func splitHotSwapOutput(ipmitoolOutput string) []hotSwapState {
+	var result []hotSwapState
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 5 {
+			if strings.TrimSpace(scanner.Text()) != "" {
+				log.Debugf("Skipping malformed hot swap sensor line: %s", scanner.Text())
+				parseFailures.WithLabelValues("picmg").Inc()
+			}
+			continue
+		}
+		states := hotSwapStateRegex.FindAllStringSubmatch(fields[4], -1)
+		if states == nil {
+			continue
+		}
+		state, _ := strconv.Atoi(states[len(states)-1][1])
+		result = append(result, hotSwapState{
+			Name:   strings.ReplaceAll(strings.TrimSpace(fields[0]), " ", ""),
+			Entity: strings.TrimSpace(fields[3]),
+			State:  state,
+		})
+	}
+	return result
+}
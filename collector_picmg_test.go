@@ -0,0 +1,48 @@
+//go:build !nopicmg
+// +build !nopicmg
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPICMGParse(t *testing.T) {
+	collPropertiesOutput := `PICMG identifier   : 0x00
+PICMG Ext. Version : 2.3
+Max FRU Device ID  : 0x08
+FRU Device ID      : 0x00`
+	collAddrInfoOutput := `Hardware Address : 0x41
+IPMB-0 Address   : 0x82
+FRU ID           : 0x00
+Site ID          : 0x01
+Site Type        : Front Board`
+	collHotSwapOutput := `FRU0 Hot Swap    | 82h | ok  | 160.96 | Transition to M4
+AMC1 Hot Swap    | 83h | ok  | 193.97 | Transition to M1
+Shelf Hot Swap   | 84h | ns  | 160.97 | No Reading
+Unable to read sensor: Device Not Present`
+	before := parseFailureCount(t, "picmg")
+	data, err := (picmgCollector{}).Parse([]string{collPropertiesOutput, collAddrInfoOutput, collHotSwapOutput})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	picmg := data.(picmgData)
+	if picmg.Properties["PICMG Ext. Version"] != "2.3" || picmg.Address["Site ID"] != "0x01" || picmg.Address["Site Type"] != "Front Board" {
+		t.Errorf("PICMG properties check failed.\n Got: %v %v", picmg.Properties, picmg.Address)
+	}
+	expect := []hotSwapState{
+		{Name: "FRU0HotSwap", Entity: "160.96", State: 4},
+		{Name: "AMC1HotSwap", Entity: "193.97", State: 1},
+	}
+	if !reflect.DeepEqual(picmg.HotSwap, expect) {
+		t.Errorf("Hot-swap state check failed.\n Expect: %+v\n Got: %+v", expect, picmg.HotSwap)
+	}
+	if got := parseFailureCount(t, "picmg") - before; got != 1 {
+		t.Errorf("Parse failures check failed.\n Expect: 1\n Got: %v", got)
+	}
+
+	if _, err := (picmgCollector{}).Parse([]string{"Invalid command", "", ""}); err == nil {
+		t.Errorf("Output without PICMG properties was accepted")
+	}
+}
@@ -0,0 +1,130 @@
+//go:build !nopower
+// +build !nopower
+
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+func init() {
+	registerCollector(powerCollector{})
+	powerTransitions.onChange = powerStateChanged
+}
+
+// powerCollector collects the chassis power state.
+type powerCollector struct{}
+
+func (powerCollector) Name() string {
+	return "power"
+}
+
+func (powerCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"power", "status"}}
+}
+
+func (powerCollector) Parse(outputs []string) (interface{}, error) {
+	return getChassisPowerState(outputs[0])
+}
+
+func (powerCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	ch <- prometheus.MustNewConstMetric(
+		chassisPowerStateDesc,
+		prometheus.GaugeValue,
+		float64(data.(int)),
+		"PowerState",
+	)
+	t := powerTransitions.observe(target.host, strconv.Itoa(data.(int)))
+	ch <- prometheus.MustNewConstMetric(
+		chassisPowerTransitionsDesc,
+		prometheus.CounterValue,
+		t.Count,
+	)
+	if !t.Changed.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			powerStateChangeTimestampDesc,
+			prometheus.GaugeValue,
+			float64(t.Changed.UnixNano())/1e9,
+		)
+	}
+}
+
+// powerStateEvent is logged and posted to the events webhook when a change of
+// the chassis power state is observed.
+type powerStateEvent struct {
+	Event     string    `json:"event"`
+	Target    string    `json:"target"`
+	Direction string    `json:"direction"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func powerStateChanged(target, from, to string, changed time.Time) {
+	direction := "off"
+	if to == "1" {
+		direction = "on"
+	}
+	log.Infof("Chassis of %s powered %s", targetName(target), direction)
+	postEvent(powerStateEvent{
+		Event:     "power_state_change",
+		Target:    targetName(target),
+		Direction: direction,
+		Timestamp: changed,
+	})
+}
+
+var ipmiCurrentPowerRegex = regexp.MustCompile(`^Chassis\s*Power\s*is\s*(?P<value>on|off*)`)
+
+var (
+	chassisPowerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "power", "state"),
+		"Reported Chassis Power State (0=off, 1=on).",
+		[]string{"name"},
+		nil,
+	)
+
+	chassisPowerTransitionsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "power_transitions_total"),
+		"Number of observed changes of the chassis power state.",
+		nil,
+		nil,
+	)
+
+	powerStateChangeTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "power", "state_change_timestamp_seconds"),
+		"Time the last change of the chassis power state was observed, as seconds since the Unix epoch.",
+		nil,
+		nil,
+	)
+
+	powerTransitions = newTransitionCounter("power_state")
+)
+
+func getChassisPowerState(ipmitoolOutput string) (int, error) {
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+
+	var err error
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > 0 {
+			match := ipmiCurrentPowerRegex.FindStringSubmatch(line)
+			if match == nil {
+				// Output includes stderr, e.g. warnings of ipmitool.
+				log.Debugf("Skipping unknown power status line: %s", line)
+				parseFailures.WithLabelValues("power").Inc()
+				continue
+			}
+			if match[1] == "on" {
+				return 1, err
+			}
+		}
+	}
+	return 0, err
+}
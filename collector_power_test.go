@@ -0,0 +1,31 @@
+//go:build !nopower
+// +build !nopower
+
+package main
+
+import (
+	"testing"
+)
+
+func TestGetChassisPowerStateWarning(t *testing.T) {
+	before := parseFailureCount(t, "power")
+	res, err := getChassisPowerState("Get Session Challenge command failed\nChassis Power is on")
+	if err != nil || res != 1 {
+		t.Errorf("Chassis power state check failed.\n Expect:\n value: 1\n Got:\n value: %v, error: %v", res, err)
+	}
+	if got := parseFailureCount(t, "power") - before; got != 1 {
+		t.Errorf("Parse failures check failed.\n Expect: 1\n Got: %v", got)
+	}
+}
+
+func TestGetChassisPowerState(t *testing.T) {
+	collChassisOutput := `Chassis Power is off`
+	res, err := getChassisPowerState(collChassisOutput)
+	expect := 0
+	if err != nil {
+		t.Errorf("getChassisPowerState() call failed. Reason: %s", err)
+	}
+	if res != expect {
+		t.Errorf("Chassis power state check failed.\n Expect:\n value: %v\n Got:\n value: %v", expect, res)
+	}
+}
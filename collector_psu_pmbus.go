@@ -0,0 +1,142 @@
+//go:build !nopsupmbus
+// +build !nopsupmbus
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(psuPMBusCollector{})
+}
+
+// psuPMBusCollector reads the input and output power of every power supply
+// over PMBus, with Master Write-Read commands the BMC forwards to the power
+// supplies, e.g. on Supermicro boards.
+type psuPMBusCollector struct{}
+
+// PMBus commands reading the input and output power in the LINEAR11 format.
+const (
+	pmbusReadPout = "0x96"
+	pmbusReadPin  = "0x97"
+)
+
+func (psuPMBusCollector) Name() string {
+	return "psu-pmbus"
+}
+
+func (psuPMBusCollector) Commands(config IPMIConfig) [][]string {
+	var commands [][]string
+	for _, address := range config.PSUPMBusAddresses {
+		for _, register := range []string{pmbusReadPin, pmbusReadPout} {
+			// Master Write-Read (NetFn App, command 52h) of 2 bytes.
+			commands = append(commands, []string{"raw", "0x06", "0x52", config.PSUPMBusBus, address, "0x02", register})
+		}
+	}
+	return commands
+}
+
+// Parse returns the input and output power of every power supply. Power
+// supplies that don't respond, e.g. because the slot is empty, are NaN.
+func (psuPMBusCollector) Parse(outputs []string) (interface{}, error) {
+	var result []psuPower
+	var responding bool
+	for i := 0; i+1 < len(outputs); i += 2 {
+		power := psuPower{Input: pmbusWatts(outputs[i]), Output: pmbusWatts(outputs[i+1])}
+		if !math.IsNaN(power.Input) || !math.IsNaN(power.Output) {
+			responding = true
+		}
+		result = append(result, power)
+	}
+	if !responding {
+		return nil, fmt.Errorf("no power supply responded over PMBus")
+	}
+	return result, nil
+}
+
+func (psuPMBusCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	for i, power := range data.([]psuPower) {
+		psu := strconv.Itoa(i + 1)
+		ch <- prometheus.MustNewConstMetric(psuPMBusInputPowerDesc, prometheus.GaugeValue, power.Input, psu)
+		ch <- prometheus.MustNewConstMetric(psuPMBusOutputPowerDesc, prometheus.GaugeValue, power.Output, psu)
+		efficiency := math.NaN()
+		if power.Input > 0 {
+			efficiency = power.Output / power.Input
+		}
+		ch <- prometheus.MustNewConstMetric(psuEfficiencyDesc, prometheus.GaugeValue, efficiency, psu)
+	}
+}
+
+// Records implements recordCounter.
+func (psuPMBusCollector) Records(data interface{}) int {
+	return len(data.([]psuPower))
+}
+
+// IgnoreExitStatus implements exitStatusIgnorer, as ipmitool fails for power
+// supplies that don't respond. Parse checks that any of them responded.
+func (psuPMBusCollector) IgnoreExitStatus() bool {
+	return true
+}
+
+// ValidateConfig implements configValidator.
+func (psuPMBusCollector) ValidateConfig(config IPMIConfig) error {
+	if len(config.PSUPMBusAddresses) == 0 {
+		return fmt.Errorf("psu-pmbus collector needs psu_pmbus_addresses")
+	}
+	for _, b := range append([]string{config.PSUPMBusBus}, config.PSUPMBusAddresses...) {
+		if _, err := strconv.ParseUint(b, 0, 8); err != nil {
+			return fmt.Errorf("invalid psu_pmbus_bus or psu_pmbus_addresses byte: %q", b)
+		}
+	}
+	return nil
+}
+
+type psuPower struct {
+	Input, Output float64
+}
+
+var (
+	psuPMBusInputPowerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu_pmbus", "input_watts"),
+		"Input power of a power supply unit read over PMBus in Watts.",
+		[]string{"psu"},
+		nil,
+	)
+
+	psuPMBusOutputPowerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu_pmbus", "output_watts"),
+		"Output power of a power supply unit read over PMBus in Watts.",
+		[]string{"psu"},
+		nil,
+	)
+
+	psuEfficiencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "efficiency_ratio"),
+		"Ratio of the output to the input power of a power supply unit read over PMBus.",
+		[]string{"psu"},
+		nil,
+	)
+)
+
+// pmbusWatts decodes the response of a PMBus power reading, or returns NaN if
+// the output isn't a 2 byte response.
+func pmbusWatts(output string) float64 {
+	response, err := parseRawBytes(output)
+	if err != nil || len(response) != 2 {
+		return math.NaN()
+	}
+	return decodeLinear11(uint16(decodeRawBytes(response, "little")))
+}
+
+// decodeLinear11 decodes the PMBus LINEAR11 format: an 11 bit two's
+// complement mantissa and a 5 bit two's complement exponent.
+func decodeLinear11(raw uint16) float64 {
+	mantissa := int16(raw<<5) >> 5
+	exponent := int8(raw>>8) >> 3
+	return math.Ldexp(float64(mantissa), int(exponent))
+}
@@ -0,0 +1,54 @@
+//go:build !nopsupmbus
+// +build !nopsupmbus
+
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestDecodeLinear11(t *testing.T) {
+	for raw, expect := range map[uint16]float64{
+		0x012c: 300,
+		0xf9f4: 250,
+		0xe8c8: 25,
+	} {
+		if got := decodeLinear11(raw); got != expect {
+			t.Errorf("LINEAR11 check failed for %#04x.\n Expect: %v\n Got: %v", raw, expect, got)
+		}
+	}
+}
+
+func TestPSUPMBus(t *testing.T) {
+	var config IPMIConfig
+	if err := yaml.Unmarshal([]byte("collectors: [psu-pmbus]\npsu_pmbus_addresses: ['0x78']\n"), &config); err != nil {
+		t.Fatalf("Config with psu_pmbus_addresses not loaded.\n Error is: %s", err)
+	}
+	expect := [][]string{
+		{"raw", "0x06", "0x52", "0x07", "0x78", "0x02", "0x97"},
+		{"raw", "0x06", "0x52", "0x07", "0x78", "0x02", "0x96"},
+	}
+	if res := (psuPMBusCollector{}).Commands(config); !reflect.DeepEqual(res, expect) {
+		t.Errorf("PMBus commands check failed.\n Expect: %v\n Got: %v", expect, res)
+	}
+
+	data, err := (psuPMBusCollector{}).Parse([]string{" f4 f9\n", " 2c 01\n", "Unable to send RAW command", "Unable to send RAW command"})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	powers := data.([]psuPower)
+	if len(powers) != 2 || powers[0] != (psuPower{Input: 250, Output: 300}) || !math.IsNaN(powers[1].Input) {
+		t.Errorf("PMBus power check failed.\n Expect: [{250 300} {NaN NaN}]\n Got: %v", powers)
+	}
+	if _, err := (psuPMBusCollector{}).Parse([]string{"Unable to send RAW command", ""}); err == nil {
+		t.Errorf("Output without responding power supply was accepted")
+	}
+
+	if err := yaml.Unmarshal([]byte("collectors: [psu-pmbus]\npsu_pmbus_addresses: ['0x178']\n"), &IPMIConfig{}); err == nil {
+		t.Errorf("Invalid psu_pmbus_addresses were accepted")
+	}
+}
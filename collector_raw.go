@@ -0,0 +1,81 @@
+//go:build !noraw
+// +build !noraw
+
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/model"
+)
+
+func init() {
+	registerCollector(rawCollector{})
+}
+
+// rawCollector runs the raw IPMI commands configured in the module, for
+// vendor readings that are only reachable through `ipmitool raw`.
+type rawCollector struct{}
+
+func (rawCollector) Name() string {
+	return "raw"
+}
+
+func (rawCollector) Commands(config IPMIConfig) [][]string {
+	var commands [][]string
+	for _, c := range config.RawCommands {
+		commands = append(commands, append([]string{"raw"}, c.Command...))
+	}
+	return commands
+}
+
+func (rawCollector) Parse(outputs []string) (interface{}, error) {
+	var responses [][]byte
+	for _, output := range outputs {
+		response, err := parseRawBytes(output)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+func (rawCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	responses := data.([][]byte)
+	for i, c := range target.config.RawCommands {
+		value := c.value(responses[i])
+		if math.IsNaN(value) {
+			log.Debugf("Response of raw command for %s from %s doesn't contain the value: %q", c.Metric, targetName(target.host), formatRawBytes(responses[i]))
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, value)
+	}
+}
+
+// Records implements recordCounter, counting the raw responses.
+func (rawCollector) Records(data interface{}) int {
+	return len(data.([][]byte))
+}
+
+// ValidateConfig implements configValidator.
+func (rawCollector) ValidateConfig(config IPMIConfig) error {
+	if len(config.RawCommands) == 0 {
+		return fmt.Errorf("the raw collector needs raw_commands")
+	}
+	seen := make(map[string]bool)
+	for _, c := range config.RawCommands {
+		labels := make(model.LabelSet, len(c.Labels))
+		for name, value := range c.Labels {
+			labels[model.LabelName(name)] = model.LabelValue(value)
+		}
+		id := c.Metric + labels.String()
+		if seen[id] {
+			return fmt.Errorf("duplicate raw_commands metric %s with the same labels", c.Metric)
+		}
+		seen[id] = true
+	}
+	return nil
+}
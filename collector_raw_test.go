@@ -0,0 +1,82 @@
+//go:build !noraw
+// +build !noraw
+
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestRawCollector(t *testing.T) {
+	var config IPMIConfig
+	err := yaml.Unmarshal([]byte(`collectors: [raw]
+raw_commands:
+- metric: ipmi_raw_fan_duty_percent
+  labels: {zone: cpu}
+  command: ["0x30", "0x70", "0x66", "0x00", "0x00"]
+  byte_offset: 0
+- metric: ipmi_raw_psu_input_watts
+  command: ["0x06", "0x52", "0x07", "0x78", "0x01", "0x97"]
+  byte_offset: 0
+  byte_length: 2
+  scale: 0.5
+- metric: ipmi_raw_boot_flag
+  command: ["0x00", "0x09", "0x05", "0x00", "0x00"]
+  regex: "^01 05 (..)"
+- metric: ipmi_raw_dedicated_nic
+  command: ["0x30", "0x70", "0x0c", "0x00"]
+  regex: "^00$"
+`), &config)
+	if err != nil {
+		t.Fatalf("Config with raw commands not loaded.\n Error is: %s", err)
+	}
+	expectCommands := [][]string{
+		{"raw", "0x30", "0x70", "0x66", "0x00", "0x00"},
+		{"raw", "0x06", "0x52", "0x07", "0x78", "0x01", "0x97"},
+		{"raw", "0x00", "0x09", "0x05", "0x00", "0x00"},
+		{"raw", "0x30", "0x70", "0x0c", "0x00"},
+	}
+	if res := (rawCollector{}).Commands(config); !reflect.DeepEqual(res, expectCommands) {
+		t.Errorf("Raw commands check failed.\n Expect: %v\n Got: %v", expectCommands, res)
+	}
+
+	data, err := (rawCollector{}).Parse([]string{" 32\n", " 2c 01\n", " 01 05 80 00 00\n", " 01\n"})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	responses := data.([][]byte)
+	expect := []float64{50, 150, 128, 0}
+	for i, c := range config.RawCommands {
+		if res := c.value(responses[i]); res != expect[i] {
+			t.Errorf("Raw value check failed for %s.\n Expect: %v\n Got: %v", c.Metric, expect[i], res)
+		}
+	}
+	if res := config.RawCommands[1].value([]byte{0x2c}); !math.IsNaN(res) {
+		t.Errorf("Short raw response check failed.\n Expect: NaN\n Got: %v", res)
+	}
+	if _, err := (rawCollector{}).Parse([]string{"Unable to send RAW command"}); err == nil {
+		t.Errorf("Error message was parsed as raw response")
+	}
+}
+
+func TestRawCommandValidation(t *testing.T) {
+	for _, bad := range []string{
+		"collectors: [raw]\n",
+		"raw_commands:\n- metric: ipmi-raw\n  command: ['0x30', '0x45']\n  byte_offset: 0\n",
+		"raw_commands:\n- metric: ipmi_raw\n  command: ['0x30']\n  byte_offset: 0\n",
+		"raw_commands:\n- metric: ipmi_raw\n  command: ['0x30', '0x100']\n  byte_offset: 0\n",
+		"raw_commands:\n- metric: ipmi_raw\n  command: ['0x30', '0x45']\n",
+		"raw_commands:\n- metric: ipmi_raw\n  command: ['0x30', '0x45']\n  byte_offset: 0\n  regex: '00'\n",
+		"raw_commands:\n- metric: ipmi_raw\n  command: ['0x30', '0x45']\n  byte_offset: 0\n  byte_length: 9\n",
+		"raw_commands:\n- metric: ipmi_raw\n  command: ['0x30', '0x45']\n  byte_offset: 0\n  byte_order: middle\n",
+		"collectors: [raw]\nraw_commands:\n- metric: ipmi_raw\n  command: ['0x30', '0x45']\n  byte_offset: 0\n- metric: ipmi_raw\n  command: ['0x30', '0x46']\n  byte_offset: 0\n",
+	} {
+		if err := yaml.Unmarshal([]byte(bad), &IPMIConfig{}); err == nil {
+			t.Errorf("Invalid raw commands were accepted: %q", bad)
+		}
+	}
+}
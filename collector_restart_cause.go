@@ -0,0 +1,73 @@
+//go:build !norestartcause
+// +build !norestartcause
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(restartCauseCollector{})
+}
+
+// restartCauseCollector collects the cause of the last system restart.
+type restartCauseCollector struct{}
+
+func (restartCauseCollector) Name() string {
+	return "restart-cause"
+}
+
+func (restartCauseCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"chassis", "restart_cause"}}
+}
+
+func (restartCauseCollector) Parse(outputs []string) (interface{}, error) {
+	return getRestartCause(outputs[0])
+}
+
+func (restartCauseCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	cause := data.(string)
+	ch <- prometheus.MustNewConstMetric(
+		restartCauseInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		cause,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		restartCauseChangesDesc,
+		prometheus.CounterValue,
+		restartCauseChanges.observe(target.host, cause).Count,
+	)
+}
+
+var (
+	restartCauseRegex = regexp.MustCompile(`(?m)^System\srestart\scause:\s*(.*?)\s*$`)
+
+	restartCauseInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "restart_cause_info"),
+		"Constant metric with value '1' providing the cause of the last system restart.",
+		[]string{"cause"},
+		nil,
+	)
+
+	restartCauseChangesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis", "restart_cause_changes_total"),
+		"Number of observed changes of the cause of the last system restart.",
+		nil,
+		nil,
+	)
+
+	restartCauseChanges = newTransitionCounter("restart_cause")
+)
+
+func getRestartCause(ipmitoolOutput string) (string, error) {
+	match := restartCauseRegex.FindStringSubmatch(ipmitoolOutput)
+	if match == nil {
+		return "", fmt.Errorf("no restart cause in output: %q", ipmitoolOutput)
+	}
+	return match[1], nil
+}
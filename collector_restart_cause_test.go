@@ -0,0 +1,20 @@
+//go:build !norestartcause
+// +build !norestartcause
+
+package main
+
+import (
+	"testing"
+)
+
+func TestGetRestartCause(t *testing.T) {
+	collRestartCauseOutput := `System restart cause: power-up via power button`
+	res, err := getRestartCause(collRestartCauseOutput)
+	expect := "power-up via power button"
+	if err != nil {
+		t.Errorf("getRestartCause() call failed. Reason: %s", err)
+	}
+	if res != expect {
+		t.Errorf("Restart cause check failed.\n Expect:\n value: %v\n Got:\n value: %v", expect, res)
+	}
+}
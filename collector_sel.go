@@ -0,0 +1,160 @@
+//go:build !nosel
+// +build !nosel
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(selCollector{})
+}
+
+// selCollector collects a summary of the System Event Log.
+type selCollector struct{}
+
+func (selCollector) Name() string {
+	return "sel"
+}
+
+func (selCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"sel", "info"}}
+}
+
+func (selCollector) Parse(outputs []string) (interface{}, error) {
+	return splitSELInfoOutput(outputs[0])
+}
+
+func (selCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	info := data.(selInfo)
+	ch <- prometheus.MustNewConstMetric(
+		selEntriesDesc,
+		prometheus.GaugeValue,
+		info.Entries,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		selFreeSpaceDesc,
+		prometheus.GaugeValue,
+		info.FreeSpace,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		selUsedRatioDesc,
+		prometheus.GaugeValue,
+		info.UsedRatio,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		selLastAddDesc,
+		prometheus.GaugeValue,
+		info.LastAdd,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		selOverflowDesc,
+		prometheus.GaugeValue,
+		boolToFloat(info.Overflow),
+	)
+}
+
+// selInfo holds the summary printed by ipmitool sel info. Values the BMC
+// doesn't report are NaN.
+type selInfo struct {
+	Entries   float64
+	FreeSpace float64
+	UsedRatio float64
+	LastAdd   float64
+	Overflow  bool
+}
+
+var (
+	selEntriesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "entries"),
+		"Number of entries in the System Event Log.",
+		nil,
+		nil,
+	)
+
+	selFreeSpaceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "free_space_bytes"),
+		"Free space in the System Event Log in bytes.",
+		nil,
+		nil,
+	)
+
+	selUsedRatioDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "used_ratio"),
+		"Used fraction of the System Event Log (0-1).",
+		nil,
+		nil,
+	)
+
+	selLastAddDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "last_add_timestamp_seconds"),
+		"Time the last entry was added to the System Event Log as Unix timestamp.",
+		nil,
+		nil,
+	)
+
+	selOverflowDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "overflow"),
+		"Whether the System Event Log overflowed and entries were dropped (1) or not (0).",
+		nil,
+		nil,
+	)
+)
+
+// selTimeLayout is the format of SEL timestamps printed by ipmitool, in the
+// time zone of the BMC, which is assumed to be UTC.
+const selTimeLayout = "01/02/2006 15:04:05"
+
+func splitSELInfoOutput(ipmitoolOutput string) (selInfo, error) {
+	info := selInfo{
+		Entries:   math.NaN(),
+		FreeSpace: math.NaN(),
+		UsedRatio: math.NaN(),
+		LastAdd:   math.NaN(),
+	}
+	var found bool
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		switch name {
+		case "Entries":
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				info.Entries = v
+				found = true
+			}
+		case "Free Space":
+			// Large logs are reported as "65535+ bytes".
+			value = strings.TrimSuffix(strings.TrimSuffix(value, " bytes"), "+")
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				info.FreeSpace = v
+			}
+		case "Percent Used":
+			if v, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64); err == nil {
+				info.UsedRatio = v / 100
+			}
+		case "Last Add Time":
+			if t, err := time.Parse(selTimeLayout, value); err == nil {
+				info.LastAdd = float64(t.Unix())
+			}
+		case "Overflow":
+			info.Overflow = value == "true"
+		}
+	}
+	if !found {
+		return info, fmt.Errorf("no SEL entry count in output: %q", ipmitoolOutput)
+	}
+	return info, nil
+}
@@ -0,0 +1,223 @@
+//go:build !nosel
+// +build !nosel
+
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+func init() {
+	registerCollector(selEventsCollector{})
+}
+
+// selEventsCollector collects the most recent System Event Log entries and
+// the number of entries per severity.
+type selEventsCollector struct{}
+
+func (selEventsCollector) Name() string {
+	return "sel-events"
+}
+
+func (selEventsCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"sel", "elist"}}
+}
+
+func (selEventsCollector) Parse(outputs []string) (interface{}, error) {
+	return splitSELEventsOutput(outputs[0]), nil
+}
+
+func (selEventsCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	events := data.([]selEvent)
+
+	counts := map[string]float64{"critical": 0, "warning": 0, "info": 0}
+	for _, event := range events {
+		counts[event.Severity]++
+	}
+	for severity, count := range counts {
+		ch <- prometheus.MustNewConstMetric(
+			selEventsDesc,
+			prometheus.GaugeValue,
+			count,
+			severity,
+		)
+	}
+
+	recent := events
+	if limit := target.config.SELEventsLimit; len(recent) > limit {
+		recent = recent[len(recent)-limit:]
+	}
+	for _, event := range recent {
+		ch <- prometheus.MustNewConstMetric(
+			selEventInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			event.ID, event.Sensor, event.Event, event.Severity,
+		)
+		if !event.Time.IsZero() {
+			ch <- prometheus.MustNewConstMetric(
+				selEventTimestampDesc,
+				prometheus.GaugeValue,
+				float64(event.Time.Unix()),
+				event.ID,
+			)
+		}
+	}
+
+	phase, postErr := lastPOSTEvents(events)
+	if phase != nil {
+		ch <- prometheus.MustNewConstMetric(
+			selPOSTPhaseDesc,
+			prometheus.GaugeValue,
+			float64(phase.Time.Unix()),
+			phase.Event,
+		)
+	}
+	if postErr != nil {
+		ch <- prometheus.MustNewConstMetric(
+			selPOSTErrorDesc,
+			prometheus.GaugeValue,
+			float64(postErr.Time.Unix()),
+			postErr.Event,
+		)
+	}
+}
+
+// Records implements recordCounter.
+func (selEventsCollector) Records(data interface{}) int {
+	return len(data.([]selEvent))
+}
+
+type selEvent struct {
+	ID       string
+	Time     time.Time
+	Sensor   string
+	Event    string
+	Severity string
+	// Deasserted is true for events logged when a condition ended.
+	Deasserted bool
+}
+
+var (
+	selEventsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "events"),
+		"Number of entries in the System Event Log by severity.",
+		[]string{"severity"},
+		nil,
+	)
+
+	selEventInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "event_info"),
+		"Constant metric with value '1' describing one of the most recent entries of the System Event Log.",
+		[]string{"id", "sensor", "event", "severity"},
+		nil,
+	)
+
+	selEventTimestampDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "event_timestamp_seconds"),
+		"Time of one of the most recent entries of the System Event Log as Unix timestamp.",
+		[]string{"id"},
+		nil,
+	)
+
+	selPOSTPhaseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "post_phase_timestamp_seconds"),
+		"Time of the latest System Firmware Progress entry of the System Event Log reporting a POST phase, as Unix timestamp.",
+		[]string{"phase"},
+		nil,
+	)
+
+	selPOSTErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sel", "post_error_timestamp_seconds"),
+		"Time of the latest System Firmware Progress entry of the System Event Log reporting a POST error or hang, as Unix timestamp.",
+		[]string{"error"},
+		nil,
+	)
+)
+
+// POST events are logged by the System Firmware Progress sensor (sensor type
+// 0Fh), whose events are printed as the POST phase or error, e.g. "Memory
+// initialization" or "No usable system memory".
+var (
+	selPOSTSensorRegex = regexp.MustCompile(`(?i)^System Firmware`)
+	selPOSTErrorRegex  = regexp.MustCompile(`(?i)^(System Firmware (Error|Hang)|No (usable )?system memory|No video device|Unrecoverable|Removable boot media not found|BIOS corruption|CPU (voltage|speed) mismatch)`)
+)
+
+// lastPOSTEvents returns the latest asserted POST phase and POST error
+// events with a time, or nil if there are none.
+func lastPOSTEvents(events []selEvent) (phase, postErr *selEvent) {
+	for i := range events {
+		event := &events[i]
+		if event.Deasserted || event.Time.IsZero() || !selPOSTSensorRegex.MatchString(event.Sensor) {
+			continue
+		}
+		if selPOSTErrorRegex.MatchString(event.Event) {
+			postErr = event
+		} else {
+			phase = event
+		}
+	}
+	return phase, postErr
+}
+
+// Event descriptions classified as critical or warning. All other events,
+// and all deasserted events, are informational.
+var (
+	selCriticalRegex = regexp.MustCompile(`(?i)uncorrectable|non-recoverable|critical going|failure detected|ac lost|power off|ierr|thermal trip|machine check|bus fatal|fatal|drive fault`)
+	selWarningRegex  = regexp.MustCompile(`(?i)correctable|non-critical going|predictive failure|redundancy (lost|degraded)|throttl|chassis intru|limit exceeded`)
+)
+
+// selEventSeverity classifies a SEL event as critical, warning or info.
+func selEventSeverity(event, direction string) string {
+	switch {
+	case strings.EqualFold(direction, "Deasserted"):
+		return "info"
+	case selCriticalRegex.MatchString(event):
+		return "critical"
+	case selWarningRegex.MatchString(event):
+		return "warning"
+	}
+	return "info"
+}
+
+func splitSELEventsOutput(ipmitoolOutput string) []selEvent {
+	var result []selEvent
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 5 {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && line != "SEL has no entries" {
+				log.Debugf("Skipping malformed SEL line: %s", scanner.Text())
+				parseFailures.WithLabelValues("sel-events").Inc()
+			}
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		var direction string
+		if len(fields) > 5 {
+			direction = fields[5]
+		}
+		event := selEvent{
+			ID:         fields[0],
+			Sensor:     fields[3],
+			Event:      fields[4],
+			Severity:   selEventSeverity(fields[4], direction),
+			Deasserted: strings.EqualFold(direction, "Deasserted"),
+		}
+		// Entries logged before the BMC clock was set show "Pre-Init".
+		if t, err := time.Parse(selTimeLayout, fields[1]+" "+fields[2]); err == nil {
+			event.Time = t
+		}
+		result = append(result, event)
+	}
+	return result
+}
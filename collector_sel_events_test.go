@@ -0,0 +1,61 @@
+//go:build !nosel
+// +build !nosel
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitSELEventsOutput(t *testing.T) {
+	collSELOutput := `   1 | Pre-Init  |  0000000 | System Event #0x01 | Timestamp Clock Sync | Asserted
+   2 | 06/28/2021 | 18:07:26 | Power Supply #0x51 | Power Supply AC lost | Asserted
+   3 | 06/28/2021 | 18:08:02 | Memory #0x02 | Correctable ECC | Asserted
+   4 | 06/28/2021 | 18:09:13 | Temperature #0x30 | Upper Critical going high | Deasserted
+  1a | 06/28/2021 | 18:10:40 | Memory #0x02 | Uncorrectable ECC | Asserted
+SEL has no entries
+Unable to retrieve SEL entry`
+	before := parseFailureCount(t, "sel-events")
+	res := splitSELEventsOutput(collSELOutput)
+	if got := parseFailureCount(t, "sel-events") - before; got != 1 {
+		t.Errorf("Parse failures check failed.\n Expect: 1\n Got: %v", got)
+	}
+	expect := []selEvent{
+		{ID: "1", Sensor: "System Event #0x01", Event: "Timestamp Clock Sync", Severity: "info"},
+		{ID: "2", Sensor: "Power Supply #0x51", Event: "Power Supply AC lost", Severity: "critical"},
+		{ID: "3", Sensor: "Memory #0x02", Event: "Correctable ECC", Severity: "warning"},
+		{ID: "4", Sensor: "Temperature #0x30", Event: "Upper Critical going high", Severity: "info", Deasserted: true},
+		{ID: "1a", Sensor: "Memory #0x02", Event: "Uncorrectable ECC", Severity: "critical"},
+	}
+	if len(res) != len(expect) {
+		t.Fatalf("SEL events count check failed.\n Expect: %d\n Got: %d", len(expect), len(res))
+	}
+	for i, event := range expect {
+		got := res[i]
+		got.Time = event.Time
+		if got != event {
+			t.Errorf("SEL event check failed.\n Expect: %+v\n Got: %+v", event, got)
+		}
+	}
+	if !res[0].Time.IsZero() {
+		t.Errorf("Pre-Init event time check failed.\n Expect: zero\n Got: %v", res[0].Time)
+	}
+	if res[1].Time.Unix() != 1624903646 {
+		t.Errorf("SEL event time check failed.\n Expect: 1624903646\n Got: %d", res[1].Time.Unix())
+	}
+}
+
+func TestLastPOSTEvents(t *testing.T) {
+	res := splitSELEventsOutput(`   1 | 06/28/2021 | 18:07:26 | System Firmware Progress #0x01 | Memory initialization | Asserted
+   2 | 06/28/2021 | 18:07:40 | System Firmware Progress #0x01 | No usable system memory | Asserted
+   3 | 06/28/2021 | 18:08:02 | System Firmware Progress #0x01 | Video initialization | Asserted
+   4 | 06/28/2021 | 18:09:13 | System Firmware Progress #0x01 | Memory initialization | Deasserted
+   5 | 06/28/2021 | 18:10:40 | Memory #0x02 | Correctable ECC | Asserted`)
+	phase, postErr := lastPOSTEvents(res)
+	if phase == nil || phase.ID != "3" {
+		t.Errorf("POST phase check failed.\n Expect: event 3\n Got: %+v", phase)
+	}
+	if postErr == nil || postErr.ID != "2" {
+		t.Errorf("POST error check failed.\n Expect: event 2\n Got: %+v", postErr)
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !nosel
+// +build !nosel
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSplitSELInfoOutput(t *testing.T) {
+	collSELOutput := `SEL Information
+Version          : 1.5 (v1.5, v2 compliant)
+Entries          : 52
+Free Space       : 15552 bytes
+Percent Used     : 5%
+Last Add Time    : 06/28/2021 18:07:26
+Last Del Time    : Not Available
+Overflow         : false
+Supported Cmds   : 'Reserve' 'Get Alloc Info'`
+	res, err := splitSELInfoOutput(collSELOutput)
+	if err != nil {
+		t.Errorf("splitSELInfoOutput() call failed. Reason: %s", err)
+	}
+	expect := selInfo{Entries: 52, FreeSpace: 15552, UsedRatio: 0.05, LastAdd: 1624903646}
+	if res != expect {
+		t.Errorf("SEL info check failed.\n Expect: %+v\n Got: %+v", expect, res)
+	}
+
+	collSELOutput = `SEL Information
+Entries          : 0
+Free Space       : 65535+ bytes
+Percent Used     : unknown
+Last Add Time    : Not Available
+Overflow         : true`
+	res, err = splitSELInfoOutput(collSELOutput)
+	if err != nil {
+		t.Errorf("splitSELInfoOutput() call failed. Reason: %s", err)
+	}
+	if res.FreeSpace != 65535 || !math.IsNaN(res.UsedRatio) || !math.IsNaN(res.LastAdd) || !res.Overflow {
+		t.Errorf("SEL info check failed for empty log.\n Got: %+v", res)
+	}
+}
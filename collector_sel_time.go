@@ -0,0 +1,68 @@
+//go:build !nosel
+// +build !nosel
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(selTimeCollector{})
+}
+
+// selTimeCollector collects the offset of the BMC clock, which timestamps the
+// System Event Log entries, from the clock of the exporter host.
+type selTimeCollector struct{}
+
+func (selTimeCollector) Name() string {
+	return "sel-time"
+}
+
+func (selTimeCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"sel", "time", "get"}}
+}
+
+// Parse returns the offset of the BMC clock. It compares the BMC clock with
+// the time the output is parsed, right after ipmitool exited, so the offset
+// includes the duration of the command.
+func (selTimeCollector) Parse(outputs []string) (interface{}, error) {
+	bmcTime, err := splitSELTimeOutput(outputs[0])
+	if err != nil {
+		return nil, err
+	}
+	return bmcTime.Sub(time.Now()), nil
+}
+
+func (selTimeCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	ch <- prometheus.MustNewConstMetric(
+		bmcClockOffsetDesc,
+		prometheus.GaugeValue,
+		data.(time.Duration).Seconds(),
+	)
+}
+
+var (
+	selTimeRegex = regexp.MustCompile(`\d{2}/\d{2}/\d{4} \d{2}:\d{2}:\d{2}`)
+
+	bmcClockOffsetDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "bmc", "clock_offset_seconds"),
+		"Offset of the BMC clock from the clock of the exporter host in seconds, positive if the BMC clock is ahead.",
+		nil,
+		nil,
+	)
+)
+
+// splitSELTimeOutput parses the output of `ipmitool sel time get`, in the time
+// zone of the BMC, which is assumed to be UTC like for SEL entries.
+func splitSELTimeOutput(ipmitoolOutput string) (time.Time, error) {
+	match := selTimeRegex.FindString(ipmitoolOutput)
+	if match == "" {
+		return time.Time{}, fmt.Errorf("no SEL time in output: %q", ipmitoolOutput)
+	}
+	return time.Parse(selTimeLayout, match)
+}
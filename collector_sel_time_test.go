@@ -0,0 +1,32 @@
+//go:build !nosel
+// +build !nosel
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitSELTimeOutput(t *testing.T) {
+	res, err := splitSELTimeOutput("06/28/2021 18:07:26\n")
+	if err != nil {
+		t.Fatalf("splitSELTimeOutput() call failed. Reason: %s", err)
+	}
+	if res.Unix() != 1624903646 {
+		t.Errorf("SEL time check failed.\n Expect: 1624903646\n Got: %d", res.Unix())
+	}
+
+	if _, err := splitSELTimeOutput("Get SEL Time command failed"); err == nil {
+		t.Errorf("Output without SEL time was accepted")
+	}
+
+	// A BMC clock an hour ahead.
+	data, err := (selTimeCollector{}).Parse([]string{time.Now().UTC().Add(time.Hour).Format(selTimeLayout)})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	if offset := data.(time.Duration); offset < 59*time.Minute || offset > time.Hour {
+		t.Errorf("BMC clock offset check failed.\n Expect: 1h\n Got: %s", offset)
+	}
+}
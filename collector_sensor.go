@@ -0,0 +1,1116 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+func init() {
+	registerCollector(sensorCollector{})
+}
+
+// sensorCollector collects the readings and states of all sensors.
+type sensorCollector struct{}
+
+func (sensorCollector) Name() string {
+	return "sensor"
+}
+
+func (sensorCollector) Commands(config IPMIConfig) [][]string {
+	if len(config.SensorTypes) > 0 {
+		var commands [][]string
+		for _, sensorType := range config.SensorTypes {
+			commands = append(commands, []string{"sdr", "type", sensorType})
+		}
+		return commands
+	}
+	switch config.SensorSource {
+	case "sdr":
+		return [][]string{{"sdr", "elist", "full"}}
+	case "csv":
+		return [][]string{{"-c", "sensor", "list"}}
+	}
+	return [][]string{{"sensor", "list"}}
+}
+
+func (sensorCollector) Parse(outputs []string) (interface{}, error) {
+	// `ipmitool sdr type` prints the sensors of every type in the format
+	// of `ipmitool sdr elist`.
+	output := strings.Join(outputs, "\n")
+	if isSDROutput(output) {
+		return splitSDROutput(output)
+	}
+	if isCSVOutput(output) {
+		return splitSensorCSVOutput(output)
+	}
+	return splitSensorOutput(output)
+}
+
+func (sensorCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	collectSensors(ch, target, data.([]sensorData))
+}
+
+// Records implements recordCounter.
+func (sensorCollector) Records(data interface{}) int {
+	return len(data.([]sensorData))
+}
+
+// ValidateConfig implements configValidator.
+func (sensorCollector) ValidateConfig(config IPMIConfig) error {
+	for _, sensorType := range config.SensorTypes {
+		if strings.TrimSpace(sensorType) == "" {
+			return fmt.Errorf("empty sensor_types entry")
+		}
+	}
+	return nil
+}
+
+// gpuSensorRegex matches GPU and accelerator sensor names such as "GPU1Temp" or
+// "HGX_GPU_SXM_1_TEMP_0" and captures the device index.
+var gpuSensorRegex = regexp.MustCompile(`(?i)^(?:HGX_)?(?:GPU|ACC)(?:_SXM)?_?(\d+)`)
+
+// Power supply sensor names such as "PS1InputPower", "PSU2Vout" or
+// "PS1Status", split into the PSU index and the reading.
+var (
+	psuSensorRegex        = regexp.MustCompile(`(?i)^PSU?_?(\d+)_?(.+)$`)
+	psuInputRegex         = regexp.MustCompile(`(?i)^(Input(Power)?|PowerIn|Pin|In)$`)
+	psuOutputRegex        = regexp.MustCompile(`(?i)^(Output(Power)?|PowerOut|Pout|Out)$`)
+	psuOutputVoltageRegex = regexp.MustCompile(`(?i)^(Vout|OutputVoltage|VoltageOut)$`)
+
+	// AC input line readings, e.g. "PS1InputVoltage", "PSU2_Iin" or
+	// "PS1ACInputFrequency".
+	psuInputVoltageRegex   = regexp.MustCompile(`(?i)^(Vin|(AC)?Input_?Voltage|(AC)?VoltageIn|(AC)?LineVoltage)$`)
+	psuInputCurrentRegex   = regexp.MustCompile(`(?i)^(Iin|(AC)?Input_?Current|(AC)?CurrentIn|(AC)?LineCurrent)$`)
+	psuInputFrequencyRegex = regexp.MustCompile(`(?i)^(Fin|(AC)?Input_?Frequency|(AC)?FrequencyIn|(AC)?LineFrequency)$`)
+
+	// Dell names the input line sensors of its power supplies "Voltage 1"
+	// and "Current 1" without a PS prefix. Other vendors use these names for
+	// unrelated readings, so they are only matched with the dell profile.
+	psuLineSensorRegex = regexp.MustCompile(`(?i)^(Voltage|Current)_?(\d+)$`)
+)
+
+var driveSensorRegex = regexp.MustCompile(`(?i)^(Drive|HDD|Disk|Bay)`)
+
+// fanSensorRegex matches fan sensor names, whose readings in percent or
+// unspecified units are duty cycles.
+var fanSensorRegex = regexp.MustCompile(`(?i)fan`)
+
+// Built-in inlet and exhaust temperature sensor names of common vendors, as
+// reported after whitespace has been stripped.
+var (
+	builtinInletRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^InletTemp$`),         // Dell, Supermicro
+		regexp.MustCompile(`(?i)^\d+-InletAmbient$`),  // HPE
+		regexp.MustCompile(`(?i)^AmbientTemp$`),       // Lenovo
+		regexp.MustCompile(`(?i)^FrontPanelTemp$`),    // Intel
+		regexp.MustCompile(`(?i)^(System)?AirInlet$`), // Fujitsu
+	}
+	builtinExhaustRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^ExhaustTemp$`), // Dell, Lenovo
+		regexp.MustCompile(`(?i)^ExitAirTemp$`), // Intel
+		regexp.MustCompile(`(?i)^OutletTemp$`),
+	}
+)
+
+type sensorData struct {
+	// Name is the sensor name with whitespace stripped, which the name
+	// patterns are matched against, and RawName the name as printed by
+	// ipmitool.
+	Name    string
+	RawName string
+	// Label is the name exposed in the name label, see labelName.
+	Label      string
+	Value      float64
+	Type       string
+	State      string
+	Thresholds map[string]float64
+	// SensorID is the sensor number, e.g. "0x04", and Entity the IPMI
+	// entity ID and instance of the sensor, e.g. "7.1" for the system
+	// board. Only `ipmitool sdr elist` reports them.
+	SensorID string
+	Entity   string
+	// EventOnly is true for event-only sensors, which have no reading and
+	// only generate events.
+	EventOnly bool
+}
+
+// labelName returns the name of the sensor exposed in the name label: Label
+// if set by collectSensors according to the name_sanitization of the module,
+// and Name otherwise.
+func (d sensorData) labelName() string {
+	if d.Label != "" {
+		return d.Label
+	}
+	return d.Name
+}
+
+// sensorLabels are the labels of the typed sensor metrics. Sensor names
+// aren't unique on some boards, so the sensor number and entity are included
+// when known.
+var sensorLabels = []string{"name", "sensor_id", "entity"}
+
+// sensorUnitTypes maps the units reported for analog sensors to the sensor
+// type exposed in ipmi_sensor_info.
+var sensorUnitTypes = map[string]string{
+	"degreesC": "temperature",
+	"RPM":      "fan",
+	"ratio":    "fan",
+	"Volts":    "voltage",
+	"Amps":     "current",
+	"Ampers":   "current",
+	"Watts":    "power",
+	"discrete": "discrete",
+}
+
+// sensorThresholdNames lists the threshold columns of `ipmitool sensor list`
+// in the order they are printed.
+var sensorThresholdNames = []string{
+	"lower_non_recoverable",
+	"lower_critical",
+	"lower_non_critical",
+	"upper_non_critical",
+	"upper_critical",
+	"upper_non_recoverable",
+}
+
+var (
+	sensorStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "state"),
+		"Indicates the severity of the state reported by an IPMI sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
+		[]string{"name", "type", "sensor_id", "entity", "event_only"},
+		nil,
+	)
+
+	sensorThresholdCrossedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "threshold_crossed"),
+		"Threshold crossed by an analog sensor reported in a non-ok state.",
+		[]string{"name", "type", "threshold"},
+		nil,
+	)
+
+	sensorThresholdDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "threshold"),
+		"Threshold configured for an analog sensor, in the units of its reading.",
+		[]string{"name", "type", "threshold"},
+		nil,
+	)
+
+	sensorInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "info"),
+		"Constant metric with value '1' describing a sensor of the target (type is one of temperature, fan, voltage, current, power, discrete or other).",
+		[]string{"name", "type", "units", "entity"},
+		nil,
+	)
+
+	sensorValueDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "value"),
+		"Generic data read from an IPMI sensor of unknown type, relying on labels for context.",
+		[]string{"name", "type", "sensor_id", "entity"},
+		nil,
+	)
+
+	chassisIntrusionDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis_int", "value"),
+		"State of Chassis Intrusion.",
+		sensorLabels,
+		nil,
+	)
+
+	chassisIntrusionStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis_int", "state"),
+		"Reported state of a Chassis Intrusion (0=ok, 1=intrusion).",
+		sensorLabels,
+		nil,
+	)
+
+	sensorsTotalDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensors", "total"),
+		"Number of sensors reported by the target.",
+		nil,
+		nil,
+	)
+
+	sensorsInStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensors", "in_state"),
+		"Number of sensors reported by the target in the given state, as in ipmi_sensor_state (unknown for discrete sensors and sensors without state).",
+		[]string{"state"},
+		nil,
+	)
+
+	chassisPowerDeviceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis_power_dev", "value"),
+		"Chassis Power Supply device status (0=missing, 1=present).",
+		sensorLabels,
+		nil,
+	)
+
+	chassisPowerDeviceStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "chassis_power_dev", "state"),
+		"Reported state of a Power Supply (0=missing, 1=present).",
+		sensorLabels,
+		nil,
+	)
+
+	doorSwitchDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "door_switch", "value"),
+		"State of a door switch.",
+		sensorLabels,
+		nil,
+	)
+
+	doorSwitchStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "door_switch", "state"),
+		"Reported state of a door switch (0=closed, 1=open).",
+		sensorLabels,
+		nil,
+	)
+
+	fanSpeedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fan_speed", "rpm"),
+		"Fan speed in rotations per minute.",
+		sensorLabels,
+		nil,
+	)
+
+	fanDutyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fan_duty", "ratio"),
+		"Fan duty cycle reported in percent, as a ratio of 0 to 1.",
+		sensorLabels,
+		nil,
+	)
+
+	fanSpeedStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fan_speed", "state"),
+		"Reported state of a fan speed or duty cycle sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
+		sensorLabels,
+		nil,
+	)
+
+	temperatureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "temperature", "celsius"),
+		"Temperature reading in degree Celsius.",
+		sensorLabels,
+		nil,
+	)
+
+	temperatureStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "temperature", "state"),
+		"Reported state of a temperature sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
+		sensorLabels,
+		nil,
+	)
+
+	voltageDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "voltage", "volts"),
+		"Voltage reading in Volts.",
+		sensorLabels,
+		nil,
+	)
+
+	voltageStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "voltage", "state"),
+		"Reported state of a voltage sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
+		sensorLabels,
+		nil,
+	)
+
+	currentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "current", "amperes"),
+		"Current reading in Amperes.",
+		sensorLabels,
+		nil,
+	)
+
+	currentStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "current", "state"),
+		"Reported state of a current sensor (0=ok, 1=critical, 2=non-recoverable, 3=non-critical, 4=not-specified).",
+		sensorLabels,
+		nil,
+	)
+
+	powerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "power", "watts"),
+		"Power reading in Watts.",
+		sensorLabels,
+		nil,
+	)
+
+	gpuTemperatureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "gpu_temperature", "celsius"),
+		"GPU or accelerator temperature reading in degree Celsius.",
+		[]string{"name", "gpu", "sensor_id", "entity"},
+		nil,
+	)
+
+	inletTemperatureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inlet_temperature", "celsius"),
+		"Inlet or ambient temperature reading in degree Celsius.",
+		[]string{"name"},
+		nil,
+	)
+
+	exhaustTemperatureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exhaust_temperature", "celsius"),
+		"Exhaust or outlet temperature reading in degree Celsius.",
+		[]string{"name"},
+		nil,
+	)
+
+	psuInputPowerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "input_watts"),
+		"Input power of a power supply unit in Watts.",
+		[]string{"psu", "name"},
+		nil,
+	)
+
+	psuOutputPowerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "output_watts"),
+		"Output power of a power supply unit in Watts.",
+		[]string{"psu", "name"},
+		nil,
+	)
+
+	psuOutputVoltageDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "output_voltage_volts"),
+		"Output voltage of a power supply unit in Volts.",
+		[]string{"psu", "name"},
+		nil,
+	)
+
+	psuInputVoltageDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "input_voltage_volts"),
+		"Input line voltage of a power supply unit in Volts.",
+		[]string{"psu", "name"},
+		nil,
+	)
+
+	psuInputCurrentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "input_current_amperes"),
+		"Input line current of a power supply unit in Amperes.",
+		[]string{"psu", "name"},
+		nil,
+	)
+
+	psuInputFrequencyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "input_frequency_hertz"),
+		"Input line frequency of a power supply unit in Hertz.",
+		[]string{"psu", "name"},
+		nil,
+	)
+
+	psuStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "status"),
+		"Reported status of a power supply unit (0=ok, 1=failure, 2=predictive failure, 3=input lost, 4=not present).",
+		[]string{"psu", "name"},
+		nil,
+	)
+
+	powerStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor_power", "state"),
+		"Reported state of a power sensor (1=ok, 0=critical).",
+		sensorLabels,
+		nil,
+	)
+)
+
+type discreteSensorFamily struct {
+	value, state *prometheus.Desc
+}
+
+// discreteSensorFamilies maps the metrics of discreteSensorMetrics to their
+// descriptors.
+var discreteSensorFamilies = map[string]discreteSensorFamily{
+	"chassis_intrusion": {chassisIntrusionDesc, chassisIntrusionStateDesc},
+	"psu_presence":      {chassisPowerDeviceDesc, chassisPowerDeviceStateDesc},
+	"door_switch":       {doorSwitchDesc, doorSwitchStateDesc},
+}
+
+// discreteSensorState returns the state of a discrete sensor according to
+// its mapping: whether the mapped offset is asserted, or the reported state.
+func discreteSensorState(m discreteSensorMapping, state float64, data sensorData) float64 {
+	if m.Offset == nil {
+		return state
+	}
+	offsets, ok := discreteOffsets(data.State)
+	if !ok {
+		return math.NaN()
+	}
+	return boolToFloat(offsets&(1<<uint(*m.Offset)) != 0)
+}
+
+func splitSensorOutput(impitoolOutput string) ([]sensorData, error) {
+	var result []sensorData
+
+	scanner := bufio.NewScanner(strings.NewReader(impitoolOutput))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > 0 {
+			trimmedL := strings.ReplaceAll(line, " ", "")
+			splittedL := strings.Split(trimmedL, "|")
+			if len(splittedL) < 4 {
+				// Old BMCs interleave messages like "Unable to read
+				// sensor" with the sensor list.
+				log.Debugf("Skipping malformed sensor line: %s", line)
+				parseFailures.WithLabelValues("sensor").Inc()
+				continue
+			}
+			data, ok := sensorFromFields(splittedL)
+			if !ok {
+				log.Debugf("Skipping sensor line with malformed reading: %s", line)
+				parseFailures.WithLabelValues("sensor").Inc()
+				continue
+			}
+			data.RawName = strings.TrimSpace(strings.SplitN(line, "|", 2)[0])
+			result = append(result, data)
+		}
+	}
+	return result, scanner.Err()
+}
+
+// isCSVOutput tells the output of `ipmitool -c sensor list` apart from the
+// table printed without -c, which separates its columns with pipes.
+func isCSVOutput(ipmitoolOutput string) bool {
+	var found bool
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Count(line, "|") >= 3 {
+			return false
+		}
+		if strings.Count(line, ",") >= 3 {
+			found = true
+		}
+	}
+	return found
+}
+
+// splitSensorCSVOutput parses the output of `ipmitool -c sensor list`, which
+// has the columns of `ipmitool sensor list` separated by commas. Unlike the
+// table, the columns are split before whitespace is stripped, so values
+// containing separators can't shift them.
+func splitSensorCSVOutput(ipmitoolOutput string) ([]sensorData, error) {
+	var result []sensorData
+
+	reader := csv.NewReader(strings.NewReader(ipmitoolOutput))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || len(record) < 4 {
+			log.Debugf("Skipping malformed sensor line: %q", record)
+			parseFailures.WithLabelValues("sensor").Inc()
+			continue
+		}
+		fields := make([]string, len(record))
+		for i, field := range record {
+			fields[i] = strings.ReplaceAll(field, " ", "")
+		}
+		data, ok := sensorFromFields(fields)
+		if !ok {
+			log.Debugf("Skipping sensor line with malformed reading: %q", record)
+			parseFailures.WithLabelValues("sensor").Inc()
+			continue
+		}
+		data.RawName = strings.TrimSpace(record[0])
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// sensorFromFields parses the columns of a line of `ipmitool sensor list`,
+// with whitespace stripped: name, reading, units, state and the thresholds.
+// It returns false if the reading isn't a number or "na".
+func sensorFromFields(fields []string) (sensorData, bool) {
+	data := sensorData{
+		Name:       fields[0],
+		Type:       fields[2],
+		State:      fields[3],
+		Thresholds: make(map[string]float64),
+	}
+	valueS := fields[1]
+	if valueS == "na" {
+		data.Value = math.NaN()
+	} else if value, err := strconv.ParseUint(valueS, 0, 64); err == nil {
+		data.Value = float64(value)
+	} else if value, err := strconv.ParseFloat(valueS, 64); err == nil {
+		data.Value = value
+	} else {
+		return data, false
+	}
+	for i, name := range sensorThresholdNames {
+		if len(fields) <= i+4 {
+			break
+		}
+		threshold, err := strconv.ParseFloat(fields[i+4], 64)
+		if err != nil {
+			continue
+		}
+		data.Thresholds[name] = threshold
+	}
+	return data, true
+}
+
+// sdrRecordRegex matches the second column of `ipmitool sdr elist`, the
+// sensor number in hex, e.g. "04h".
+var sdrRecordRegex = regexp.MustCompile(`^[0-9A-Fa-f]{2}h$`)
+
+// sdrStates maps the threshold states printed by `ipmitool sdr elist` to the
+// states printed by `ipmitool sensor list`.
+var sdrStates = map[string]string{
+	"lnc": "nc",
+	"unc": "nc",
+	"lcr": "cr",
+	"ucr": "cr",
+	"lnr": "nr",
+	"unr": "nr",
+}
+
+// isSDROutput tells the output of `ipmitool sdr elist` apart from the output
+// of `ipmitool sensor list` by its sensor number column.
+func isSDROutput(ipmitoolOutput string) bool {
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 5 {
+			continue
+		}
+		return sdrRecordRegex.MatchString(strings.TrimSpace(fields[1]))
+	}
+	return false
+}
+
+// splitSDROutput parses the output of `ipmitool sdr elist full`, which is much
+// faster than `ipmitool sensor list` on some BMCs, but lacks thresholds. The
+// reading of discrete sensors is a description of their state, so they have
+// no value and only the threshold state reported.
+func splitSDROutput(ipmitoolOutput string) ([]sensorData, error) {
+	var result []sensorData
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Split(line, "|")
+		if len(fields) < 5 {
+			if strings.TrimSpace(line) != "" {
+				log.Debugf("Skipping malformed SDR line: %s", line)
+				parseFailures.WithLabelValues("sensor").Inc()
+			}
+			continue
+		}
+		data := sensorData{
+			Name:       strings.ReplaceAll(strings.TrimSpace(fields[0]), " ", ""),
+			RawName:    strings.TrimSpace(fields[0]),
+			Value:      math.NaN(),
+			State:      strings.TrimSpace(fields[2]),
+			Thresholds: make(map[string]float64),
+			SensorID:   "0x" + strings.TrimSuffix(strings.TrimSpace(fields[1]), "h"),
+			Entity:     strings.TrimSpace(fields[3]),
+		}
+		if state, ok := sdrStates[data.State]; ok {
+			data.State = state
+		}
+		reading := strings.SplitN(strings.TrimSpace(fields[4]), " ", 2)
+		if strings.EqualFold(strings.TrimSpace(fields[4]), "Event-Only") {
+			data.EventOnly = true
+			data.Type = "discrete"
+		} else if value, err := strconv.ParseFloat(reading[0], 64); err == nil && len(reading) == 2 {
+			data.Value = value
+			data.Type = strings.ReplaceAll(reading[1], " ", "")
+		} else if data.State != "ns" {
+			data.Type = "discrete"
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+// crossedThreshold returns the most severe threshold crossed by the reading
+// of an analog sensor, or an empty string if the reading is within all known
+// thresholds.
+func crossedThreshold(data sensorData) string {
+	if math.IsNaN(data.Value) {
+		return ""
+	}
+	for _, name := range []string{"upper_non_recoverable", "upper_critical", "upper_non_critical"} {
+		if threshold, ok := data.Thresholds[name]; ok && data.Value >= threshold {
+			return name
+		}
+	}
+	for _, name := range []string{"lower_non_recoverable", "lower_critical", "lower_non_critical"} {
+		if threshold, ok := data.Thresholds[name]; ok && data.Value <= threshold {
+			return name
+		}
+	}
+	return ""
+}
+
+// thresholdStates maps the thresholds returned by crossedThreshold to sensor
+// states.
+var thresholdStates = map[string]string{
+	"":                      "ok",
+	"upper_non_critical":    "nc",
+	"lower_non_critical":    "nc",
+	"upper_critical":        "cr",
+	"lower_critical":        "cr",
+	"upper_non_recoverable": "nr",
+	"lower_non_recoverable": "nr",
+}
+
+// synthesizedState returns the state of an analog sensor reporting "ns"
+// computed from its reading and thresholds. The reported state is returned
+// unchanged if the sensor has no reading or no thresholds.
+func synthesizedState(data sensorData) string {
+	if data.State != "ns" || data.Type == "discrete" || math.IsNaN(data.Value) || len(data.Thresholds) == 0 {
+		return data.State
+	}
+	return thresholdStates[crossedThreshold(data)]
+}
+
+// normalizeSensorUnits converts the readings and thresholds of temperature
+// sensors reported in degrees Fahrenheit or Kelvin to degrees Celsius, and
+// those of fans reported in percent or unspecified units to duty cycle
+// ratios, with type "ratio".
+func normalizeSensorUnits(data sensorData) sensorData {
+	var convert func(float64) float64
+	switch {
+	case data.Type == "degreesF":
+		convert = func(v float64) float64 { return (v - 32) * 5 / 9 }
+		data.Type = "degreesC"
+	case data.Type == "degreesK":
+		convert = func(v float64) float64 { return v - 273.15 }
+		data.Type = "degreesC"
+	case (data.Type == "percent" || data.Type == "unspecified" || strings.HasPrefix(data.Type, "%")) && fanSensorRegex.MatchString(data.Name):
+		convert = func(v float64) float64 { return v / 100 }
+		data.Type = "ratio"
+	default:
+		return data
+	}
+	data.Value = convert(data.Value)
+	thresholds := make(map[string]float64, len(data.Thresholds))
+	for name, threshold := range data.Thresholds {
+		thresholds[name] = convert(threshold)
+	}
+	data.Thresholds = thresholds
+	return data
+}
+
+func collectTypedSensor(ch chan<- prometheus.Metric, desc, stateDesc *prometheus.Desc, state float64, data sensorData) {
+	ch <- prometheus.MustNewConstMetric(
+		desc,
+		prometheus.GaugeValue,
+		data.Value,
+		data.labelName(),
+		data.SensorID,
+		data.Entity,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		stateDesc,
+		prometheus.GaugeValue,
+		state,
+		data.labelName(),
+		data.SensorID,
+		data.Entity,
+	)
+}
+
+func collectGPUTemperature(ch chan<- prometheus.Metric, state float64, gpu string, data sensorData) {
+	ch <- prometheus.MustNewConstMetric(
+		gpuTemperatureDesc,
+		prometheus.GaugeValue,
+		data.Value,
+		data.labelName(),
+		gpu,
+		data.SensorID,
+		data.Entity,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		temperatureStateDesc,
+		prometheus.GaugeValue,
+		state,
+		data.labelName(),
+		data.SensorID,
+		data.Entity,
+	)
+}
+
+// collectTemperatureAlias exposes inlet and exhaust temperature sensors under
+// vendor-independent metric names. Patterns configured in the module take
+// precedence over the built-in ones.
+func collectTemperatureAlias(ch chan<- prometheus.Metric, config IPMIConfig, data sensorData) {
+	var desc *prometheus.Desc
+	switch {
+	case matchAny(config.inletRegexps, data.Name):
+		desc = inletTemperatureDesc
+	case matchAny(config.exhaustRegexps, data.Name):
+		desc = exhaustTemperatureDesc
+	case matchAny(builtinInletRegexps, data.Name):
+		desc = inletTemperatureDesc
+	case matchAny(builtinExhaustRegexps, data.Name):
+		desc = exhaustTemperatureDesc
+	default:
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		desc,
+		prometheus.GaugeValue,
+		data.Value,
+		data.labelName(),
+	)
+}
+
+func collectGenericSensor(ch chan<- prometheus.Metric, state float64, data sensorData) {
+	ch <- prometheus.MustNewConstMetric(
+		sensorValueDesc,
+		prometheus.GaugeValue,
+		data.Value,
+		data.labelName(),
+		data.Type,
+		data.SensorID,
+		data.Entity,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		sensorStateDesc,
+		prometheus.GaugeValue,
+		state,
+		data.labelName(),
+		data.Type,
+		data.SensorID,
+		data.Entity,
+		"",
+	)
+}
+
+// collectEventOnlySensor emits the state of an event-only sensor, which has
+// no reading to expose.
+func collectEventOnlySensor(ch chan<- prometheus.Metric, state float64, data sensorData) {
+	ch <- prometheus.MustNewConstMetric(
+		sensorStateDesc,
+		prometheus.GaugeValue,
+		state,
+		data.labelName(),
+		data.Type,
+		data.SensorID,
+		data.Entity,
+		"true",
+	)
+}
+
+// collectSensorInfo describes a sensor independently of its reading, so that
+// what a target exposes can be discovered from a single metric.
+func collectSensorInfo(ch chan<- prometheus.Metric, data sensorData) {
+	sensorType, ok := sensorUnitTypes[data.Type]
+	if !ok {
+		sensorType = "other"
+	}
+	units := data.Type
+	if units == "discrete" {
+		units = ""
+	}
+	ch <- prometheus.MustNewConstMetric(
+		sensorInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		data.labelName(),
+		sensorType,
+		units,
+		data.Entity,
+	)
+}
+
+// collectSensorThresholds emits the thresholds of a sensor, so that threshold
+// bands can be drawn and alerts defined relative to them.
+func collectSensorThresholds(ch chan<- prometheus.Metric, data sensorData) {
+	for _, name := range sensorThresholdNames {
+		threshold, ok := data.Thresholds[name]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(
+			sensorThresholdDesc,
+			prometheus.GaugeValue,
+			threshold,
+			data.labelName(),
+			data.Type,
+			name,
+		)
+	}
+}
+
+// collectSensors emits the metrics for the parsed sensors of target.
+func collectSensors(ch chan<- prometheus.Metric, target ipmiTarget, results []sensorData) {
+	results = labelSensors(target.config, results)
+	faults := make(chassisFaults)
+	states := make(sensorStateCounts)
+	for _, data := range results {
+		var state float64
+		// discreteMetric is the metric family the discrete sensor is
+		// mapped to, if any.
+		var discreteMetric string
+
+		data = normalizeSensorUnits(data)
+		collectSensorInfo(ch, data)
+		// Discrete sensors have no reading, but a state, at least in the
+		// output of `ipmitool sdr elist`.
+		if math.IsNaN(data.Value) && target.config.MissingSensors == "omit" && data.Type != "discrete" {
+			// Let Prometheus mark the series of the sensor stale.
+			states.observe(math.NaN())
+			continue
+		}
+		collectSensorThresholds(ch, data)
+
+		if target.config.NotSpecifiedState == "thresholds" {
+			data.State = synthesizedState(data)
+		}
+
+		switch data.State {
+		case "ok":
+			state = 0
+		case "cr":
+			state = 1
+		case "nr":
+			state = 2
+		case "nc":
+			state = 3
+		case "ns":
+			state = 4
+		case "0x0000":
+			state = 0
+		case "0x0100":
+			state = 1
+		case "na":
+			state = math.NaN()
+		default:
+			log.Errorf("Unknown sensor state: '%s'\n", data.State)
+			state = math.NaN()
+		}
+		if data.Type == "discrete" {
+			// The states of discrete sensors are raw offsets, e.g. 0x0100
+			// for a present power supply, not a severity.
+			states.observe(math.NaN())
+		} else {
+			states.observe(state)
+		}
+
+		if data.EventOnly {
+			collectEventOnlySensor(ch, state, data)
+			faults.observeSensor(state, data, "")
+			continue
+		}
+
+		switch data.Type {
+		case "RPM":
+			collectTypedSensor(ch, fanSpeedDesc, fanSpeedStateDesc, state, data)
+		case "ratio":
+			collectTypedSensor(ch, fanDutyDesc, fanSpeedStateDesc, state, data)
+		case "degreesC":
+			// Spaces are stripped from all columns by splitSensorOutput.
+			if gpu := gpuSensorRegex.FindStringSubmatch(data.Name); gpu != nil {
+				collectGPUTemperature(ch, state, gpu[1], data)
+			} else {
+				collectTypedSensor(ch, temperatureDesc, temperatureStateDesc, state, data)
+				collectTemperatureAlias(ch, target.config, data)
+			}
+		case "Ampers":
+			collectTypedSensor(ch, currentDesc, currentStateDesc, state, data)
+		case "Volts":
+			collectTypedSensor(ch, voltageDesc, voltageStateDesc, state, data)
+			collectBatteryVoltage(ch, data)
+		case "Watts":
+			collectTypedSensor(ch, powerDesc, powerStateDesc, state, data)
+		case "discrete":
+			if m, ok := discreteSensorMappingFor(target.config, data.Name); ok {
+				discreteMetric = m.Metric
+				family := discreteSensorFamilies[m.Metric]
+				collectTypedSensor(ch, family.value, family.state, discreteSensorState(m, state, data), data)
+			} else {
+				collectDiscreteSensor(ch, data)
+			}
+		default:
+			collectGenericSensor(ch, state, data)
+		}
+
+		if data.Type != "discrete" && (state == 1 || state == 2) {
+			target.summary.markCritical()
+		}
+		if data.Type != "discrete" && (state == 1 || state == 2 || state == 3) {
+			if threshold := crossedThreshold(data); threshold != "" {
+				ch <- prometheus.MustNewConstMetric(
+					sensorThresholdCrossedDesc,
+					prometheus.GaugeValue,
+					1,
+					data.labelName(),
+					data.Type,
+					threshold,
+				)
+			}
+		}
+
+		collectPSUReading(ch, target.config, data)
+		faults.observeSensor(state, data, discreteMetric)
+	}
+	target.summary.addFaults(faults)
+	states.collect(ch, len(results))
+	targetHistories.observeSensors(ch, target, results)
+}
+
+// labelSensors returns a copy of results with the Label of every sensor set
+// according to the name_sanitization of the module.
+func labelSensors(config IPMIConfig, results []sensorData) []sensorData {
+	labeled := make([]sensorData, len(results))
+	for i, data := range results {
+		if data.RawName != "" {
+			data.Label = sanitizeName(config.NameSanitization, data.RawName)
+		}
+		labeled[i] = data
+	}
+	return labeled
+}
+
+// observeSensor marks the fault category of a sensor in a critical or
+// asserted fault state.
+func (f chassisFaults) observeSensor(state float64, data sensorData, discreteMetric string) {
+	offsets, _ := discreteOffsets(data.State)
+	switch {
+	case (data.Type == "RPM" || data.Type == "ratio") && (state == 1 || state == 2):
+		f["cooling"] = true
+	case data.Type != "discrete":
+	case discreteMetric == "chassis_intrusion":
+		if offsets != 0 {
+			f["intrusion"] = true
+		}
+	case driveSensorRegex.MatchString(data.Name):
+		// Drive Slot sensor (type 0Dh) offset 1: Drive Fault.
+		if offsets&(1<<1) != 0 {
+			f["drive"] = true
+		}
+	default:
+		if psu := psuSensorRegex.FindStringSubmatch(data.Name); psu != nil && strings.EqualFold(psu[2], "Status") {
+			if status := psuStatus(data.State); status == 1 || status == 3 {
+				f["power"] = true
+			}
+		}
+	}
+}
+
+// sensorStateCounts rolls the sensor states up into ipmi_sensors_in_state, a
+// cheap per-target series to alert on.
+type sensorStateCounts map[string]int
+
+// sensorStateNames names the states of ipmi_sensor_state by value.
+var sensorStateNames = []string{"ok", "critical", "non_recoverable", "non_critical", "not_specified"}
+
+func (c sensorStateCounts) observe(state float64) {
+	if math.IsNaN(state) {
+		c["unknown"]++
+		return
+	}
+	c[sensorStateNames[int(state)]]++
+}
+
+func (c sensorStateCounts) collect(ch chan<- prometheus.Metric, total int) {
+	ch <- prometheus.MustNewConstMetric(
+		sensorsTotalDesc,
+		prometheus.GaugeValue,
+		float64(total),
+	)
+	for _, state := range append(sensorStateNames, "unknown") {
+		ch <- prometheus.MustNewConstMetric(
+			sensorsInStateDesc,
+			prometheus.GaugeValue,
+			float64(c[state]),
+			state,
+		)
+	}
+}
+
+// collectPSUReading exposes data as a per-PSU metric if its name identifies a
+// power supply sensor.
+func collectPSUReading(ch chan<- prometheus.Metric, config IPMIConfig, data sensorData) {
+	if psu := psuSensorRegex.FindStringSubmatch(data.Name); psu != nil {
+		collectPSUSensor(ch, psu[1], psu[2], data)
+	} else if line := psuLineSensorRegex.FindStringSubmatch(data.Name); line != nil && config.psuLineSensors {
+		collectPSUSensor(ch, line[2], "Input"+line[1], data)
+	}
+}
+
+// collectPSUSensor groups the sensors of a power supply unit into per-PSU
+// metrics. Sensors that can't be classified are ignored.
+func collectPSUSensor(ch chan<- prometheus.Metric, psu, reading string, data sensorData) {
+	var desc *prometheus.Desc
+	value := data.Value
+	switch {
+	case data.Type == "Watts" && psuInputRegex.MatchString(reading):
+		desc = psuInputPowerDesc
+	case data.Type == "Watts" && psuOutputRegex.MatchString(reading):
+		desc = psuOutputPowerDesc
+	case data.Type == "Volts" && psuOutputVoltageRegex.MatchString(reading):
+		desc = psuOutputVoltageDesc
+	case data.Type == "Volts" && psuInputVoltageRegex.MatchString(reading):
+		desc = psuInputVoltageDesc
+	case (data.Type == "Amps" || data.Type == "Ampers") && psuInputCurrentRegex.MatchString(reading):
+		desc = psuInputCurrentDesc
+	case data.Type == "Hz" && psuInputFrequencyRegex.MatchString(reading):
+		desc = psuInputFrequencyDesc
+	case data.Type == "discrete" && strings.EqualFold(reading, "Status"):
+		desc = psuStatusDesc
+		value = psuStatus(data.State)
+	default:
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		desc,
+		prometheus.GaugeValue,
+		value,
+		psu,
+		data.labelName(),
+	)
+}
+
+// psuStatus decodes the offsets of a Power Supply discrete sensor (sensor type
+// 08h) into the values documented for ipmi_psu_status.
+func psuStatus(state string) float64 {
+	offsets, ok := discreteOffsets(state)
+	switch {
+	case !ok:
+		return math.NaN()
+	case offsets&(1<<1) != 0:
+		return 1 // Failure detected
+	case offsets&(1<<3|1<<4) != 0:
+		return 3 // AC lost or out of range
+	case offsets&(1<<2) != 0:
+		return 2 // Predictive failure
+	case offsets&(1<<0) == 0:
+		return 4 // Presence not detected
+	}
+	return 0
+}
+
+// discreteOffsets decodes the state column printed by `ipmitool sensor list`
+// for discrete sensors (e.g. "0x0100") into a bitmask of asserted offsets,
+// bit N being set if offset N is asserted.
+func discreteOffsets(state string) (uint16, bool) {
+	if len(state) != 6 || !strings.HasPrefix(state, "0x") {
+		return 0, false
+	}
+	raw, err := strconv.ParseUint(state[2:], 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	// ipmitool prints the bytes for offsets 0-7 first, then offsets 8-14.
+	return uint16(raw>>8) | uint16(raw&0xff)<<8, true
+}
@@ -0,0 +1,414 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Discrete sensors that aren't mapped by discrete_sensors are decoded by their
+// name into dedicated metrics. The offsets are those of the IPMI sensor and
+// event types, see the sensor type codes table of the IPMI specification.
+
+// psuRedundancySensorRegex matches power supply redundancy sensors such as
+// "PS Redundancy" (Dell), "PSU Redundancy" or "Power Supplies" (HPE).
+var psuRedundancySensorRegex = regexp.MustCompile(`(?i)^(PSU?_?Redundan(cy|t)|PowerSupplies|PwrUnitRedund(ancy)?)$`)
+
+// Memory sensor names such as "DIMM A1", "P1-DIMMA1" or "DIMM_CPU1_A1", split
+// into the parts before and after "DIMM" forming the slot, and generic ones
+// such as "Memory" or "Memory Status" without slot.
+var (
+	dimmSensorRegex   = regexp.MustCompile(`(?i)^(?:(.+?)[_-]?)?DIMM[_-]?(.*)$`)
+	memorySensorRegex = regexp.MustCompile(`(?i)^Mem(ory)?(Status|ECC|Error)?$`)
+)
+
+// cpuSensorRegex matches processor sensor names such as "CPU1 Status",
+// "CPU Stat", "Proc 2" or "P1 Status" and captures the processor index.
+var cpuSensorRegex = regexp.MustCompile(`(?i)^(?:CPU|Proc(?:essor)?)_?(\d*)_?(?:Stat(?:us)?)?$|^P_?(\d+)_?Stat(?:us)?$`)
+
+// cpuStatusReasons are the offsets of Processor sensors (sensor type 07h)
+// exposed as reasons of ipmi_cpu_status.
+var cpuStatusReasons = []struct {
+	reason string
+	offset uint
+}{
+	{"ierr", 0},
+	{"thermal_trip", 1},
+	{"bist_failure", 2},
+	{"post_hang", 3},
+	{"startup_failure", 4},
+	{"configuration_error", 5},
+	{"uncorrectable_error", 6},
+	{"present", 7},
+	{"disabled", 8},
+	{"throttled", 10},
+	{"machine_check", 11},
+}
+
+// driveBayRegex captures the bay of drive slot sensors matched by
+// driveSensorRegex, e.g. "3" for "Drive 3" or "HDD3 Status".
+var driveBayRegex = regexp.MustCompile(`\d+`)
+
+// driveLabels are the labels of the drive slot metrics.
+var driveLabels = []string{"name", "bay", "sensor_id", "entity"}
+
+// batterySensorRegex matches CMOS and RAID battery sensor names such as
+// "CMOS Battery", "ROMB Battery" (Dell), "VBAT" or "BAT_3V".
+var batterySensorRegex = regexp.MustCompile(`(?i)(Batt|^V_?BAT|^BAT)`)
+
+// postSensorRegex matches System Firmware Progress sensor names such as
+// "System Firmware Progress", "Sys Fw Progress", "POST Error" or "BIOS POST".
+var postSensorRegex = regexp.MustCompile(`(?i)^(Sys(tem)?_?F(irm)?w(are)?_?(Progress|Prog|Error)|(BIOS_?)?POST_?(Error|Err|Progress|Status)?)$`)
+
+// fanPresenceSensorRegex matches fan presence sensor names such as
+// "Fan 1 Present", "FAN1_PRSNT" or "Sys Fan 2A Presence" and captures the fan.
+var fanPresenceSensorRegex = regexp.MustCompile(`(?i)^(?:Sys_?)?Fan_?(?:Module_?|Tray_?)?([0-9]+[A-Z]?)?_?(?:Presence|Present|Prsnt|Prs|Pres)$`)
+
+// dimmLabels are the labels of the memory sensor metrics.
+var dimmLabels = []string{"name", "slot", "sensor_id", "entity"}
+
+var (
+	psuRedundancyStateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "psu", "redundancy_state"),
+		"Reported redundancy of the power supplies (0=redundant, 1=degraded, 2=non-redundant, 3=lost).",
+		sensorLabels,
+		nil,
+	)
+
+	dimmPresentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dimm", "present"),
+		"'1' if the memory sensor reports the DIMM present, '0' otherwise.",
+		dimmLabels,
+		nil,
+	)
+
+	dimmCorrectableECCDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dimm", "correctable_ecc"),
+		"'1' if the memory sensor reports correctable ECC errors or reached their logging limit, '0' otherwise.",
+		dimmLabels,
+		nil,
+	)
+
+	dimmUncorrectableECCDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "dimm", "uncorrectable_ecc"),
+		"'1' if the memory sensor reports uncorrectable ECC errors, '0' otherwise.",
+		dimmLabels,
+		nil,
+	)
+
+	drivePresentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "drive", "present"),
+		"'1' if the drive slot sensor reports a drive present, '0' otherwise.",
+		driveLabels,
+		nil,
+	)
+
+	driveFaultDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "drive", "fault"),
+		"'1' if the drive slot sensor reports a drive fault, '0' otherwise.",
+		driveLabels,
+		nil,
+	)
+
+	drivePredictiveFailureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "drive", "predictive_failure"),
+		"'1' if the drive slot sensor reports a predictive failure of the drive, '0' otherwise.",
+		driveLabels,
+		nil,
+	)
+
+	driveRebuildingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "drive", "rebuilding"),
+		"'1' if the drive slot sensor reports a rebuild in progress, '0' otherwise.",
+		driveLabels,
+		nil,
+	)
+
+	batteryPresentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "battery", "present"),
+		"'1' if the battery sensor reports the battery present, '0' otherwise.",
+		sensorLabels,
+		nil,
+	)
+
+	batteryFailedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "battery", "failed"),
+		"'1' if the battery sensor reports the battery failed, '0' otherwise.",
+		sensorLabels,
+		nil,
+	)
+
+	batteryLowDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "battery", "low"),
+		"'1' if the battery sensor reports the battery low, a predictive failure, '0' otherwise.",
+		sensorLabels,
+		nil,
+	)
+
+	batteryVoltageDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "battery", "voltage_volts"),
+		"Voltage reading of a battery in Volts.",
+		sensorLabels,
+		nil,
+	)
+
+	postErrorDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "post", "error"),
+		"'1' if the system firmware progress sensor reports a POST error, '0' otherwise.",
+		sensorLabels,
+		nil,
+	)
+
+	postHangDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "post", "hang"),
+		"'1' if the system firmware progress sensor reports the firmware hanging, '0' otherwise.",
+		sensorLabels,
+		nil,
+	)
+
+	postInProgressDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "post", "in_progress"),
+		"'1' if the system firmware progress sensor reports POST in progress, '0' otherwise.",
+		sensorLabels,
+		nil,
+	)
+
+	fanPresentDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fan", "present"),
+		"'1' if the fan presence sensor reports the fan present, '0' if it reports it absent.",
+		[]string{"name", "fan", "sensor_id", "entity"},
+		nil,
+	)
+
+	cpuStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cpu", "status"),
+		"'1' if the processor sensor reports the reason asserted, '0' otherwise (reason is one of ierr, thermal_trip, bist_failure, post_hang, startup_failure, configuration_error, uncorrectable_error, present, disabled, throttled or machine_check).",
+		[]string{"name", "cpu", "sensor_id", "entity", "reason"},
+		nil,
+	)
+)
+
+// collectDiscreteSensor emits the dedicated metrics of a discrete sensor if
+// its name identifies what it monitors.
+func collectDiscreteSensor(ch chan<- prometheus.Metric, data sensorData) {
+	switch {
+	case fanPresenceSensorRegex.MatchString(data.Name):
+		ch <- prometheus.MustNewConstMetric(
+			fanPresentDesc,
+			prometheus.GaugeValue,
+			devicePresence(data.State),
+			data.labelName(), fanPresenceSensorRegex.FindStringSubmatch(data.Name)[1], data.SensorID, data.Entity,
+		)
+	case psuRedundancySensorRegex.MatchString(data.Name):
+		ch <- prometheus.MustNewConstMetric(
+			psuRedundancyStateDesc,
+			prometheus.GaugeValue,
+			redundancyState(data.State),
+			data.labelName(), data.SensorID, data.Entity,
+		)
+	case dimmSensorRegex.MatchString(data.Name) || memorySensorRegex.MatchString(data.Name):
+		collectMemorySensor(ch, data)
+	case cpuSensorRegex.MatchString(data.Name):
+		collectCPUSensor(ch, data)
+	case driveSensorRegex.MatchString(data.Name):
+		collectDriveSensor(ch, data)
+	case batterySensorRegex.MatchString(data.Name):
+		collectBatterySensor(ch, data)
+	case postSensorRegex.MatchString(data.Name):
+		collectPOSTSensor(ch, data)
+	}
+}
+
+// devicePresence decodes the offsets of a sensor with the Device Presence
+// generic event type (08h): offset 0 is the device absent, offset 1 present.
+// It returns NaN if the sensor asserts neither.
+func devicePresence(state string) float64 {
+	offsets, ok := discreteOffsets(state)
+	switch {
+	case !ok:
+		return math.NaN()
+	case offsets&(1<<1) != 0:
+		return 1
+	case offsets&(1<<0) != 0:
+		return 0
+	}
+	return math.NaN()
+}
+
+// redundancyState decodes the offsets of a sensor with the Redundancy generic
+// event type (0Bh) into the values documented for ipmi_psu_redundancy_state.
+func redundancyState(state string) float64 {
+	offsets, ok := discreteOffsets(state)
+	switch {
+	case !ok:
+		return math.NaN()
+	case offsets&(1<<1) != 0:
+		return 3 // Redundancy lost
+	case offsets&(1<<3|1<<4|1<<5) != 0:
+		return 2 // Non-redundant
+	case offsets&(1<<2|1<<6|1<<7) != 0:
+		return 1 // Redundancy degraded
+	case offsets&(1<<0) != 0:
+		return 0 // Fully redundant
+	}
+	return math.NaN()
+}
+
+// memorySlot returns the slot of a memory sensor, e.g. "A1" for "DIMM A1" or
+// "P1-A1" for "P1-DIMMA1", and an empty string for sensors of all DIMMs.
+func memorySlot(name string) string {
+	match := dimmSensorRegex.FindStringSubmatch(name)
+	if match == nil {
+		return ""
+	}
+	var parts []string
+	for _, part := range match[1:] {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// collectMemorySensor decodes the offsets of a Memory sensor (sensor type
+// 0Ch) into the DIMM metrics.
+func collectMemorySensor(ch chan<- prometheus.Metric, data sensorData) {
+	slot := memorySlot(data.Name)
+	offsets, ok := discreteOffsets(data.State)
+	for _, m := range []struct {
+		desc *prometheus.Desc
+		mask uint16
+	}{
+		{dimmPresentDesc, 1 << 6},             // Presence detected
+		{dimmCorrectableECCDesc, 1<<0 | 1<<5}, // Correctable ECC, logging limit reached
+		{dimmUncorrectableECCDesc, 1 << 1},    // Uncorrectable ECC
+	} {
+		value := math.NaN()
+		if ok {
+			value = boolToFloat(offsets&m.mask != 0)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			m.desc,
+			prometheus.GaugeValue,
+			value,
+			data.labelName(), slot, data.SensorID, data.Entity,
+		)
+	}
+}
+
+// collectCPUSensor decodes the offsets of a Processor sensor (sensor type 07h)
+// into ipmi_cpu_status.
+func collectCPUSensor(ch chan<- prometheus.Metric, data sensorData) {
+	match := cpuSensorRegex.FindStringSubmatch(data.Name)
+	cpu := match[1] + match[2]
+	offsets, ok := discreteOffsets(data.State)
+	for _, r := range cpuStatusReasons {
+		value := math.NaN()
+		if ok {
+			value = boolToFloat(offsets&(1<<r.offset) != 0)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			cpuStatusDesc,
+			prometheus.GaugeValue,
+			value,
+			data.labelName(), cpu, data.SensorID, data.Entity, r.reason,
+		)
+	}
+}
+
+// collectDriveSensor decodes the offsets of a Drive Slot sensor (sensor type
+// 0Dh) into the drive metrics.
+func collectDriveSensor(ch chan<- prometheus.Metric, data sensorData) {
+	bay := driveBayRegex.FindString(data.Name)
+	offsets, ok := discreteOffsets(data.State)
+	for _, m := range []struct {
+		desc   *prometheus.Desc
+		offset uint
+	}{
+		{drivePresentDesc, 0},
+		{driveFaultDesc, 1},
+		{drivePredictiveFailureDesc, 2},
+		{driveRebuildingDesc, 7},
+	} {
+		value := math.NaN()
+		if ok {
+			value = boolToFloat(offsets&(1<<m.offset) != 0)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			m.desc,
+			prometheus.GaugeValue,
+			value,
+			data.labelName(), bay, data.SensorID, data.Entity,
+		)
+	}
+}
+
+// collectBatterySensor decodes the offsets of a Battery sensor (sensor type
+// 29h) into the battery metrics.
+func collectBatterySensor(ch chan<- prometheus.Metric, data sensorData) {
+	offsets, ok := discreteOffsets(data.State)
+	for _, m := range []struct {
+		desc   *prometheus.Desc
+		offset uint
+	}{
+		{batteryPresentDesc, 2},
+		{batteryFailedDesc, 1},
+		{batteryLowDesc, 0},
+	} {
+		value := math.NaN()
+		if ok {
+			value = boolToFloat(offsets&(1<<m.offset) != 0)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			m.desc,
+			prometheus.GaugeValue,
+			value,
+			data.labelName(), data.SensorID, data.Entity,
+		)
+	}
+}
+
+// collectBatteryVoltage duplicates the readings of battery voltage sensors
+// into ipmi_battery_voltage_volts.
+func collectBatteryVoltage(ch chan<- prometheus.Metric, data sensorData) {
+	if !batterySensorRegex.MatchString(data.Name) {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		batteryVoltageDesc,
+		prometheus.GaugeValue,
+		data.Value,
+		data.labelName(), data.SensorID, data.Entity,
+	)
+}
+
+// collectPOSTSensor decodes the offsets of a System Firmware Progress sensor
+// (sensor type 0Fh) into the POST metrics. The POST phase and error code are
+// only logged in the System Event Log, see the sel-events collector.
+func collectPOSTSensor(ch chan<- prometheus.Metric, data sensorData) {
+	offsets, ok := discreteOffsets(data.State)
+	for _, m := range []struct {
+		desc   *prometheus.Desc
+		offset uint
+	}{
+		{postErrorDesc, 0},
+		{postHangDesc, 1},
+		{postInProgressDesc, 2},
+	} {
+		value := math.NaN()
+		if ok {
+			value = boolToFloat(offsets&(1<<m.offset) != 0)
+		}
+		ch <- prometheus.MustNewConstMetric(
+			m.desc,
+			prometheus.GaugeValue,
+			value,
+			data.labelName(), data.SensorID, data.Entity,
+		)
+	}
+}
@@ -0,0 +1,211 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// collectDiscreteValues returns the values collectDiscreteSensor emits for
+// data by metric descriptor.
+func collectDiscreteValues(t *testing.T, data sensorData) map[*prometheus.Desc]float64 {
+	ch := make(chan prometheus.Metric, 10)
+	collectDiscreteSensor(ch, data)
+	close(ch)
+	values := make(map[*prometheus.Desc]float64)
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("Writing metric failed: %s", err)
+		}
+		values[m.Desc()] = metric.GetGauge().GetValue()
+	}
+	return values
+}
+
+func TestRedundancyState(t *testing.T) {
+	for state, expect := range map[string]float64{
+		"0x0100": 0,
+		"0x0200": 3,
+		"0x0400": 1,
+		"0x2000": 2,
+		"0x0300": 3,
+		"0x0000": math.NaN(),
+		"ok":     math.NaN(),
+	} {
+		got := redundancyState(state)
+		if got != expect && !(math.IsNaN(got) && math.IsNaN(expect)) {
+			t.Errorf("Redundancy state check failed for %s.\n Expect: %v\n Got: %v", state, expect, got)
+		}
+	}
+	for _, name := range []string{"PSRedundancy", "PSURedundancy", "PowerSupplies", "PS_Redundant"} {
+		if !psuRedundancySensorRegex.MatchString(name) {
+			t.Errorf("PSU redundancy sensor %s not matched", name)
+		}
+	}
+	if psuRedundancySensorRegex.MatchString("PS1Status") {
+		t.Errorf("PSU status sensor matched as redundancy sensor")
+	}
+}
+
+func TestMemorySensors(t *testing.T) {
+	for name, expect := range map[string]string{
+		"DIMMA1":       "A1",
+		"P1-DIMMA1":    "P1-A1",
+		"DIMM_CPU1_A1": "CPU1_A1",
+		"CPU2_DIMM_B3": "CPU2-B3",
+		"MemoryStatus": "",
+	} {
+		if got := memorySlot(name); got != expect {
+			t.Errorf("Memory slot check failed for %s.\n Expect: %q\n Got: %q", name, expect, got)
+		}
+	}
+
+	// Presence detected and correctable ECC asserted.
+	values := collectDiscreteValues(t, sensorData{Name: "DIMMA1", Type: "discrete", State: "0x4100"})
+	expect := map[*prometheus.Desc]float64{
+		dimmPresentDesc:          1,
+		dimmCorrectableECCDesc:   1,
+		dimmUncorrectableECCDesc: 0,
+	}
+	for desc, value := range expect {
+		if values[desc] != value {
+			t.Errorf("Memory sensor check failed for %s.\n Expect: %v\n Got: %v", desc, value, values[desc])
+		}
+	}
+}
+
+func TestCPUSensors(t *testing.T) {
+	for name, expect := range map[string]string{
+		"CPU1Status": "1",
+		"CPUStat":    "",
+		"Proc2":      "2",
+		"P1Status":   "1",
+	} {
+		match := cpuSensorRegex.FindStringSubmatch(name)
+		if match == nil || match[1]+match[2] != expect {
+			t.Errorf("CPU sensor check failed for %s.\n Expect: %q\n Got: %q", name, expect, match)
+		}
+	}
+	if cpuSensorRegex.MatchString("CPU1Temp") {
+		t.Errorf("CPU temperature sensor matched as processor sensor")
+	}
+
+	// Presence detected and thermal trip asserted.
+	ch := make(chan prometheus.Metric, 20)
+	collectDiscreteSensor(ch, sensorData{Name: "CPU1Status", Type: "discrete", State: "0x8200"})
+	close(ch)
+	got := make(map[string]float64)
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("Writing metric failed: %s", err)
+		}
+		for _, l := range metric.Label {
+			if l.GetName() == "reason" {
+				got[l.GetValue()] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	if len(got) != len(cpuStatusReasons) {
+		t.Errorf("CPU status reasons check failed.\n Expect: %d\n Got: %v", len(cpuStatusReasons), got)
+	}
+	for reason, expect := range map[string]float64{"present": 1, "thermal_trip": 1, "ierr": 0} {
+		if got[reason] != expect {
+			t.Errorf("CPU status check failed for %s.\n Expect: %v\n Got: %v", reason, expect, got[reason])
+		}
+	}
+}
+
+func TestDriveSensors(t *testing.T) {
+	// Drive present with a predictive failure.
+	values := collectDiscreteValues(t, sensorData{Name: "HDD3Status", Type: "discrete", State: "0x0500"})
+	expect := map[*prometheus.Desc]float64{
+		drivePresentDesc:           1,
+		driveFaultDesc:             0,
+		drivePredictiveFailureDesc: 1,
+		driveRebuildingDesc:        0,
+	}
+	for desc, value := range expect {
+		if values[desc] != value {
+			t.Errorf("Drive sensor check failed for %s.\n Expect: %v\n Got: %v", desc, value, values[desc])
+		}
+	}
+	if bay := driveBayRegex.FindString("HDD3Status"); bay != "3" {
+		t.Errorf("Drive bay check failed.\n Expect: 3\n Got: %q", bay)
+	}
+}
+
+func TestBatterySensors(t *testing.T) {
+	// Battery present and low.
+	values := collectDiscreteValues(t, sensorData{Name: "CMOSBattery", Type: "discrete", State: "0x0500"})
+	expect := map[*prometheus.Desc]float64{
+		batteryPresentDesc: 1,
+		batteryFailedDesc:  0,
+		batteryLowDesc:     1,
+	}
+	for desc, value := range expect {
+		if values[desc] != value {
+			t.Errorf("Battery sensor check failed for %s.\n Expect: %v\n Got: %v", desc, value, values[desc])
+		}
+	}
+
+	ch := make(chan prometheus.Metric, 1)
+	collectBatteryVoltage(ch, sensorData{Name: "VBAT", Type: "Volts", Value: 3.1})
+	collectBatteryVoltage(ch, sensorData{Name: "12V", Type: "Volts", Value: 12})
+	close(ch)
+	var metric dto.Metric
+	if m, ok := <-ch; !ok || m.Desc() != batteryVoltageDesc || m.Write(&metric) != nil || metric.GetGauge().GetValue() != 3.1 {
+		t.Errorf("Battery voltage check failed.\n Expect: 3.1\n Got: %v", metric.GetGauge())
+	}
+	if _, ok := <-ch; ok {
+		t.Errorf("Non-battery voltage exposed as battery voltage")
+	}
+}
+
+func TestFanPresenceSensors(t *testing.T) {
+	for name, fan := range map[string]string{"Fan1Present": "1", "FAN1_PRSNT": "1", "SysFan2APresence": "2A", "FanPresence": ""} {
+		match := fanPresenceSensorRegex.FindStringSubmatch(name)
+		if match == nil || match[1] != fan {
+			t.Errorf("Fan presence sensor check failed for %s.\n Expect: %q\n Got: %q", name, fan, match)
+		}
+	}
+	if fanPresenceSensorRegex.MatchString("FAN1") {
+		t.Errorf("Fan speed sensor FAN1 matched as fan presence sensor")
+	}
+	for state, expect := range map[string]float64{"0x0200": 1, "0x0100": 0} {
+		values := collectDiscreteValues(t, sensorData{Name: "Fan1Present", Type: "discrete", State: state})
+		if values[fanPresentDesc] != expect {
+			t.Errorf("Fan presence check failed for %s.\n Expect: %v\n Got: %v", state, expect, values[fanPresentDesc])
+		}
+	}
+	if got := devicePresence("0x0000"); !math.IsNaN(got) {
+		t.Errorf("Fan presence check failed for 0x0000.\n Expect: NaN\n Got: %v", got)
+	}
+}
+
+func TestPOSTSensors(t *testing.T) {
+	for _, name := range []string{"SystemFirmwareProgress", "SysFwProgress", "POSTError", "BIOSPOST"} {
+		if !postSensorRegex.MatchString(name) {
+			t.Errorf("POST sensor %s not matched", name)
+		}
+	}
+
+	// Firmware hang asserted.
+	values := collectDiscreteValues(t, sensorData{Name: "SysFwProgress", Type: "discrete", State: "0x0200"})
+	expect := map[*prometheus.Desc]float64{
+		postErrorDesc:      0,
+		postHangDesc:       1,
+		postInProgressDesc: 0,
+	}
+	for desc, value := range expect {
+		if values[desc] != value {
+			t.Errorf("POST sensor check failed for %s.\n Expect: %v\n Got: %v", desc, value, values[desc])
+		}
+	}
+}
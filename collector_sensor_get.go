@@ -0,0 +1,196 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(sensorGetCollector{})
+}
+
+// sensorGetCollector collects the details of the sensors listed in
+// sensor_get, which `ipmitool sensor list` doesn't show: the hysteresis,
+// asserted and enabled events and the settable thresholds.
+type sensorGetCollector struct{}
+
+func (sensorGetCollector) Name() string {
+	return "sensor-get"
+}
+
+func (sensorGetCollector) Commands(config IPMIConfig) [][]string {
+	var commands [][]string
+	for _, name := range config.SensorGet {
+		commands = append(commands, []string{"sensor", "get", name})
+	}
+	return commands
+}
+
+func (sensorGetCollector) Parse(outputs []string) (interface{}, error) {
+	var result []sensorDetails
+	for _, output := range outputs {
+		if details, ok := splitSensorGetOutput(output); ok {
+			result = append(result, details)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no sensor details in output: %q", strings.Join(outputs, "\n"))
+	}
+	return result, nil
+}
+
+func (sensorGetCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	for _, d := range data.([]sensorDetails) {
+		name := sanitizeName(target.config.NameSanitization, d.Name)
+		for _, direction := range []string{"positive", "negative"} {
+			if hysteresis, ok := d.Hysteresis[direction]; ok {
+				ch <- prometheus.MustNewConstMetric(
+					sensorHysteresisDesc,
+					prometheus.GaugeValue,
+					hysteresis,
+					name, direction,
+				)
+			}
+		}
+		for _, event := range d.AssertedEvents {
+			ch <- prometheus.MustNewConstMetric(sensorAssertedEventDesc, prometheus.GaugeValue, 1, name, event)
+		}
+		for _, event := range d.AssertionsEnabled {
+			ch <- prometheus.MustNewConstMetric(sensorEventEnabledDesc, prometheus.GaugeValue, 1, name, event, "assertion")
+		}
+		for _, event := range d.DeassertionsEnabled {
+			ch <- prometheus.MustNewConstMetric(sensorEventEnabledDesc, prometheus.GaugeValue, 1, name, event, "deassertion")
+		}
+		for _, threshold := range d.SettableThresholds {
+			ch <- prometheus.MustNewConstMetric(sensorThresholdSettableDesc, prometheus.GaugeValue, 1, name, threshold)
+		}
+	}
+}
+
+// Records implements recordCounter.
+func (sensorGetCollector) Records(data interface{}) int {
+	return len(data.([]sensorDetails))
+}
+
+// IgnoreExitStatus implements exitStatusIgnorer, as ipmitool fails for
+// sensors that don't exist. Parse skips their output.
+func (sensorGetCollector) IgnoreExitStatus() bool {
+	return true
+}
+
+// ValidateConfig implements configValidator.
+func (sensorGetCollector) ValidateConfig(config IPMIConfig) error {
+	if len(config.SensorGet) == 0 {
+		return fmt.Errorf("sensor-get collector needs sensor_get")
+	}
+	for _, name := range config.SensorGet {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("empty sensor_get entry")
+		}
+	}
+	return nil
+}
+
+type sensorDetails struct {
+	// Name is the sensor name as printed by ipmitool, exposed like in the
+	// other sensor metrics according to name_sanitization.
+	Name string
+	// Hysteresis holds the positive and negative hysteresis, if specified.
+	Hysteresis          map[string]float64
+	AssertedEvents      []string
+	AssertionsEnabled   []string
+	DeassertionsEnabled []string
+	// SettableThresholds are named like the thresholds of
+	// ipmi_sensor_threshold, e.g. "upper_critical".
+	SettableThresholds []string
+}
+
+// sensorThresholdAbbreviations maps the threshold abbreviations printed by
+// `ipmitool sensor get` to the names of sensorThresholdNames.
+var sensorThresholdAbbreviations = map[string]string{
+	"lnr": "lower_non_recoverable",
+	"lcr": "lower_critical",
+	"lnc": "lower_non_critical",
+	"unc": "upper_non_critical",
+	"ucr": "upper_critical",
+	"unr": "upper_non_recoverable",
+}
+
+var (
+	sensorGetIDRegex = regexp.MustCompile(`^Sensor ID\s*:\s*(.*?)\s*(\(0x[0-9a-fA-F]+\))?$`)
+
+	sensorHysteresisDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "hysteresis"),
+		"Hysteresis of the thresholds of a sensor listed in sensor_get, in the units of its reading.",
+		[]string{"name", "direction"},
+		nil,
+	)
+
+	sensorAssertedEventDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "asserted_event"),
+		"Constant metric with value '1' for every event currently asserted by a sensor listed in sensor_get, e.g. ucr+ for going above the upper critical threshold.",
+		[]string{"name", "event"},
+		nil,
+	)
+
+	sensorEventEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "event_enabled"),
+		"Constant metric with value '1' for every event a sensor listed in sensor_get generates on assertion or deassertion.",
+		[]string{"name", "event", "direction"},
+		nil,
+	)
+
+	sensorThresholdSettableDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "threshold_settable"),
+		"Constant metric with value '1' for every threshold of a sensor listed in sensor_get that can be set.",
+		[]string{"name", "threshold"},
+		nil,
+	)
+)
+
+// splitSensorGetOutput parses the output of `ipmitool sensor get` for one
+// sensor. It returns false if the output doesn't describe a sensor.
+func splitSensorGetOutput(ipmitoolOutput string) (sensorDetails, bool) {
+	result := sensorDetails{Hysteresis: make(map[string]float64)}
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if match := sensorGetIDRegex.FindStringSubmatch(line); match != nil {
+			result.Name = match[1]
+			continue
+		}
+		fields := strings.SplitN(line, ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		switch name {
+		case "Positive Hysteresis", "Negative Hysteresis":
+			if hysteresis, err := strconv.ParseFloat(value, 64); err == nil {
+				result.Hysteresis[strings.ToLower(strings.Fields(name)[0])] = hysteresis
+			}
+		case "Assertion Events":
+			result.AssertedEvents = strings.Fields(value)
+		case "Assertions Enabled":
+			result.AssertionsEnabled = strings.Fields(value)
+		case "Deassertions Enabled":
+			result.DeassertionsEnabled = strings.Fields(value)
+		case "Settable Thresholds":
+			for _, abbreviation := range strings.Fields(value) {
+				if threshold, ok := sensorThresholdAbbreviations[abbreviation]; ok {
+					result.SettableThresholds = append(result.SettableThresholds, threshold)
+				}
+			}
+		}
+	}
+	return result, result.Name != ""
+}
@@ -0,0 +1,51 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSensorGetOutput(t *testing.T) {
+	collSensorGetOutput := `Locating sensor record...
+Sensor ID              : CPU Temp (0x1)
+ Entity ID             : 3.1
+ Sensor Type (Threshold)  : Temperature
+ Sensor Reading        : 92 (+/- 0) degrees C
+ Status                : Upper Critical
+ Upper Non-Critical    : 85.000
+ Upper Critical        : 90.000
+ Positive Hysteresis   : 2.000
+ Negative Hysteresis   : Unspecified
+ Assertion Events      : unc+ ucr+
+ Assertions Enabled    : unc+ ucr+
+ Deassertions Enabled  : ucr+
+ Settable Thresholds   : lnc unc ucr
+ Threshold Read Mask   : lnr lcr lnc unc ucr unr
+`
+	data, err := (sensorGetCollector{}).Parse([]string{collSensorGetOutput, "Sensor \"Foo\" not found!\n"})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	expect := []sensorDetails{{
+		Name:                "CPU Temp",
+		Hysteresis:          map[string]float64{"positive": 2},
+		AssertedEvents:      []string{"unc+", "ucr+"},
+		AssertionsEnabled:   []string{"unc+", "ucr+"},
+		DeassertionsEnabled: []string{"ucr+"},
+		SettableThresholds:  []string{"lower_non_critical", "upper_non_critical", "upper_critical"},
+	}}
+	if !reflect.DeepEqual(data, expect) {
+		t.Errorf("Sensor details check failed.\n Expect: %+v\n Got: %+v", expect, data)
+	}
+
+	if _, err := (sensorGetCollector{}).Parse([]string{"Sensor \"Foo\" not found!\n"}); err == nil {
+		t.Errorf("Output without sensor details was accepted")
+	}
+	expectCommands := [][]string{{"sensor", "get", "CPU Temp"}}
+	if res := (sensorGetCollector{}).Commands(IPMIConfig{SensorGet: []string{"CPU Temp"}}); !reflect.DeepEqual(res, expectCommands) {
+		t.Errorf("Sensor get commands check failed.\n Expect: %v\n Got: %v", expectCommands, res)
+	}
+}
@@ -0,0 +1,394 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSplitSensorOutput(t *testing.T) {
+	collSensorOutput := `CPU1 Temp        | 31.000     | degrees C  | ok    | 0.000     | 0.000     | 0.000     | 90.000    | 95.000    | 95.000
+P1-DIMMA2 Temp   | na         |            | na    | na        | na        | na        | na        | na        | na
+Chassis Intru    | 0x0        | discrete   | 0x0000| na        | na        | na        | na        | na        | na`
+	res, err := splitSensorOutput(collSensorOutput)
+	expectName := "CPU1Temp"
+	expectValue := float64(0)
+	if err != nil {
+		t.Errorf("splitSensorOutput() call failed. Reason: %s", err)
+	}
+	if res[0].Name != expectName {
+		t.Errorf("Whitespace sanitizin failed.\n Expect: %s\n Got: %s", expectName, res[0].Name)
+	}
+	if !math.IsNaN(res[1].Value) {
+		t.Errorf("NaN conversion failed.\n Value: %f is not math.NaN", res[1].Value)
+	}
+	if res[2].Value != expectValue {
+		t.Errorf("HEX to float64 conversion failed.\n Expect: %f\n Got: %f", expectValue, res[2].Value)
+	}
+}
+
+func TestSplitSensorCSVOutput(t *testing.T) {
+	collSensorOutput := `CPU1 Temp,31.000,degrees C,ok,0.000,0.000,0.000,90.000,95.000,95.000
+Unable to read sensor: Device Not Present
+"PS1 | Status",0x1,discrete,0x0100,na,na,na,na,na,na
+P1-DIMMA2 Temp,na,,na,na,na,na,na,na,na
+`
+	if !isCSVOutput(collSensorOutput) {
+		t.Fatalf("CSV sensor output not detected")
+	}
+	res, err := (sensorCollector{}).Parse([]string{collSensorOutput})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	sensors := res.([]sensorData)
+	if len(sensors) != 3 {
+		t.Fatalf("CSV sensor count check failed.\n Expect: 3\n Got: %d", len(sensors))
+	}
+	if sensors[0].Name != "CPU1Temp" || sensors[0].Type != "degreesC" || sensors[0].Value != 31 || sensors[0].Thresholds["upper_critical"] != 95 {
+		t.Errorf("CSV sensor check failed.\n Expect: CPU1Temp degreesC 31 (upper critical 95)\n Got: %+v", sensors[0])
+	}
+	if sensors[1].Name != "PS1|Status" || sensors[1].State != "0x0100" {
+		t.Errorf("CSV sensor with separator in name check failed.\n Expect: PS1|Status 0x0100\n Got: %+v", sensors[1])
+	}
+	if !math.IsNaN(sensors[2].Value) {
+		t.Errorf("NaN conversion failed.\n Value: %f is not math.NaN", sensors[2].Value)
+	}
+
+	expect := [][]string{{"-c", "sensor", "list"}}
+	if got := (sensorCollector{}).Commands(IPMIConfig{SensorSource: "csv"}); !reflect.DeepEqual(got, expect) {
+		t.Errorf("CSV sensor commands check failed.\n Expect: %v\n Got: %v", expect, got)
+	}
+}
+
+func TestSensorFixtureParseFailures(t *testing.T) {
+	checkFixtureParseFailures(t, sensorCollector{})
+}
+
+func TestSplitSensorOutputLegacy(t *testing.T) {
+	collSensorOutput := `Temp             | 35.000     | degrees C  | ok
+Unable to read sensor: Device Not Present
+
+FAN 1            | 3000.000   | RPM        | ok    | na        | 500.000   | na        | na        | na        | na`
+	before := parseFailureCount(t, "sensor")
+	res, err := splitSensorOutput(collSensorOutput)
+	if err != nil {
+		t.Errorf("splitSensorOutput() call failed. Reason: %s", err)
+	}
+	if len(res) != 2 || res[0].Name != "Temp" || res[1].Name != "FAN1" {
+		t.Errorf("Legacy sensor list parsing failed.\n Expect: [Temp FAN1]\n Got: %v", res)
+	}
+	if got := parseFailureCount(t, "sensor") - before; got != 1 {
+		t.Errorf("Parse failures check failed.\n Expect: 1\n Got: %v", got)
+	}
+}
+
+func TestSplitSDROutput(t *testing.T) {
+	collSDROutput := `Inlet Temp       | 04h | ok  |  7.1 | 23 degrees C
+FAN 1            | 30h | lnc | 29.1 | 600 RPM
+CPU2 Temp        | 0Fh | ns  |  3.2 | No Reading
+PS1 Status       | 62h | ok  | 10.1 | Presence detected
+Current 1        | 6Ah | ucr | 10.1 | 0.40 Amps`
+	if !isSDROutput(collSDROutput) {
+		t.Errorf("SDR output was not recognized")
+	}
+	if isSDROutput("Temp             | 35.000     | degrees C  | ok") {
+		t.Errorf("Sensor list output was recognized as SDR output")
+	}
+	res, err := splitSDROutput(collSDROutput)
+	if err != nil {
+		t.Errorf("splitSDROutput() call failed. Reason: %s", err)
+	}
+	expect := []sensorData{
+		{Name: "InletTemp", Value: 23, Type: "degreesC", State: "ok", SensorID: "0x04", Entity: "7.1"},
+		{Name: "FAN1", Value: 600, Type: "RPM", State: "nc", SensorID: "0x30", Entity: "29.1"},
+		{Name: "CPU2Temp", Value: math.NaN(), Type: "", State: "ns", SensorID: "0x0F", Entity: "3.2"},
+		{Name: "PS1Status", Value: math.NaN(), Type: "discrete", State: "ok", SensorID: "0x62", Entity: "10.1"},
+		{Name: "Current1", Value: 0.4, Type: "Amps", State: "cr", SensorID: "0x6A", Entity: "10.1"},
+	}
+	if len(res) != len(expect) {
+		t.Fatalf("SDR sensor count check failed.\n Expect: %d\n Got: %d", len(expect), len(res))
+	}
+	for i, data := range expect {
+		got := res[i]
+		if got.Name != data.Name || got.Type != data.Type || got.State != data.State || got.SensorID != data.SensorID || got.Entity != data.Entity ||
+			(got.Value != data.Value && !(math.IsNaN(got.Value) && math.IsNaN(data.Value))) {
+			t.Errorf("SDR sensor check failed.\n Expect: %+v\n Got: %+v", data, got)
+		}
+	}
+}
+
+func TestCrossedThreshold(t *testing.T) {
+	collSensorOutput := `CPU1 Temp        | 96.000     | degrees C  | cr    | 0.000     | 0.000     | 0.000     | 90.000    | 95.000    | 100.000
+FAN1             | 300.000    | RPM        | nc    | 150.000   | 225.000   | 375.000   | na        | na        | na
+12V              | 12.000     | Volts      | ok    | 10.173    | 10.299    | 10.740    | 13.260    | 13.700    | 13.828`
+	res, err := splitSensorOutput(collSensorOutput)
+	if err != nil {
+		t.Errorf("splitSensorOutput() call failed. Reason: %s", err)
+	}
+	expect := []string{"upper_critical", "lower_non_critical", ""}
+	for i, data := range res {
+		if got := crossedThreshold(data); got != expect[i] {
+			t.Errorf("Crossed threshold check failed for %s.\n Expect: %q\n Got: %q", data.Name, expect[i], got)
+		}
+	}
+}
+
+func TestSynthesizedState(t *testing.T) {
+	collSensorOutput := `FAN1             | 4200.000   | RPM        | ns    | 300.000   | 500.000   | 700.000   | 25300.000 | 25400.000 | 25500.000
+FAN2             | 400.000    | RPM        | ns    | 300.000   | 500.000   | 700.000   | 25300.000 | 25400.000 | 25500.000
+FAN3             | na         | RPM        | ns    | 300.000   | 500.000   | 700.000   | 25300.000 | 25400.000 | 25500.000
+Fan Redundancy   | 0x0        | discrete   | ns    | na        | na        | na        | na        | na        | na`
+	res, err := splitSensorOutput(collSensorOutput)
+	if err != nil {
+		t.Errorf("splitSensorOutput() call failed. Reason: %s", err)
+	}
+	expect := []string{"ok", "cr", "ns", "ns"}
+	for i, data := range res {
+		if got := synthesizedState(data); got != expect[i] {
+			t.Errorf("Synthesized state check failed for %s.\n Expect: %q\n Got: %q", data.Name, expect[i], got)
+		}
+	}
+}
+
+func TestGPUSensorRegex(t *testing.T) {
+	for name, expect := range map[string]string{
+		"GPU1Temp":             "1",
+		"HGX_GPU_SXM_7_TEMP_0": "7",
+		"GPU_3_Temp":           "3",
+		"CPU1Temp":             "",
+	} {
+		var got string
+		if match := gpuSensorRegex.FindStringSubmatch(name); match != nil {
+			got = match[1]
+		}
+		if got != expect {
+			t.Errorf("GPU index check failed for %s.\n Expect: %q\n Got: %q", name, expect, got)
+		}
+	}
+}
+
+func TestCollectPSUInputLine(t *testing.T) {
+	dell, _ := vendorProfileByName("dell")
+	for _, c := range []struct {
+		data   sensorData
+		config IPMIConfig
+		expect *prometheus.Desc
+	}{
+		{sensorData{Name: "PS1InputVoltage", Type: "Volts", Value: 230}, IPMIConfig{}, psuInputVoltageDesc},
+		{sensorData{Name: "PSU2_Iin", Type: "Amps", Value: 0.8}, IPMIConfig{}, psuInputCurrentDesc},
+		{sensorData{Name: "PS1ACInputFrequency", Type: "Hz", Value: 50}, IPMIConfig{}, psuInputFrequencyDesc},
+		{sensorData{Name: "Voltage1", Type: "Volts", Value: 230}, dell.apply(IPMIConfig{}), psuInputVoltageDesc},
+		{sensorData{Name: "Current2", Type: "Amps", Value: 0.8}, dell.apply(IPMIConfig{}), psuInputCurrentDesc},
+		{sensorData{Name: "Voltage1", Type: "Volts", Value: 230}, IPMIConfig{}, nil},
+		{sensorData{Name: "PS1Vout", Type: "Volts", Value: 12}, IPMIConfig{}, psuOutputVoltageDesc},
+	} {
+		ch := make(chan prometheus.Metric, 1)
+		collectPSUReading(ch, c.config, c.data)
+		close(ch)
+		var got *prometheus.Desc
+		if m, ok := <-ch; ok {
+			got = m.Desc()
+		}
+		if got != c.expect {
+			t.Errorf("PSU sensor classification check failed for %s.\n Expect: %v\n Got: %v", c.data.Name, c.expect, got)
+		}
+	}
+}
+
+func TestPSUStatus(t *testing.T) {
+	for state, expect := range map[string]float64{
+		"0x0100": 0,
+		"0x0300": 1,
+		"0x0500": 2,
+		"0x0900": 3,
+		"0x0000": 4,
+	} {
+		if got := psuStatus(state); got != expect {
+			t.Errorf("PSU status check failed for %s.\n Expect: %v\n Got: %v", state, expect, got)
+		}
+	}
+	if got := psuStatus("na"); !math.IsNaN(got) {
+		t.Errorf("NaN conversion failed.\n Value: %f is not math.NaN", got)
+	}
+}
+
+func TestChassisFaults(t *testing.T) {
+	collSensorOutput := `FAN1             | 0.000      | RPM        | cr    | 150.000   | 225.000   | 375.000   | na        | na        | na
+PS1 Status       | 0x1        | discrete   | 0x0100| na        | na        | na        | na        | na        | na
+Chassis Intru    | 0x1        | discrete   | 0x0100| na        | na        | na        | na        | na        | na`
+	res, err := splitSensorOutput(collSensorOutput)
+	if err != nil {
+		t.Errorf("splitSensorOutput() call failed. Reason: %s", err)
+	}
+	faults := make(chassisFaults)
+	faults.observeSensor(1, res[0], "")
+	faults.observeSensor(0, res[1], "psu_presence")
+	faults.observeSensor(1, res[2], "chassis_intrusion")
+	for faultType, expect := range map[string]bool{"power": false, "cooling": true, "drive": false, "intrusion": true} {
+		if faults[faultType] != expect {
+			t.Errorf("Chassis fault check failed for %s.\n Expect: %v\n Got: %v", faultType, expect, faults[faultType])
+		}
+	}
+}
+
+func TestNormalizeSensorUnits(t *testing.T) {
+	for _, tc := range []struct {
+		data              sensorData
+		expectType        string
+		expect, threshold float64
+	}{
+		{sensorData{Name: "AmbientTemp", Type: "degreesF", Value: 77, Thresholds: map[string]float64{"upper_critical": 104}}, "degreesC", 25, 40},
+		{sensorData{Name: "CPUTemp", Type: "degreesK", Value: 323.15, Thresholds: map[string]float64{"upper_critical": 373.15}}, "degreesC", 50, 100},
+		{sensorData{Name: "FAN1", Type: "%unspecified", Value: 45, Thresholds: map[string]float64{"upper_critical": 100}}, "ratio", 0.45, 1},
+		{sensorData{Name: "SYS_FAN2", Type: "unspecified", Value: 30, Thresholds: map[string]float64{"upper_critical": 100}}, "ratio", 0.3, 1},
+		{sensorData{Name: "CPUUsage", Type: "unspecified", Value: 30, Thresholds: map[string]float64{"upper_critical": 100}}, "unspecified", 30, 100},
+	} {
+		got := normalizeSensorUnits(tc.data)
+		if got.Type != tc.expectType || math.Abs(got.Value-tc.expect) > 1e-9 || math.Abs(got.Thresholds["upper_critical"]-tc.threshold) > 1e-9 {
+			t.Errorf("Unit normalization check failed for %s.\n Expect: %s %v (threshold %v)\n Got: %s %v (threshold %v)", tc.data.Name, tc.expectType, tc.expect, tc.threshold, got.Type, got.Value, got.Thresholds["upper_critical"])
+		}
+	}
+}
+
+func TestLabelSensors(t *testing.T) {
+	res, err := splitSensorOutput(`CPU1 Temp        | 31.000     | degrees C  | ok    | 0.000     | 0.000     | 0.000     | 90.000    | 95.000    | 95.000`)
+	if err != nil {
+		t.Fatalf("splitSensorOutput() call failed. Reason: %s", err)
+	}
+	for policy, expect := range map[string]string{"strip": "CPU1Temp", "underscore": "CPU1_Temp", "preserve": "CPU1 Temp"} {
+		labeled := labelSensors(IPMIConfig{NameSanitization: policy}, res)
+		if got := labeled[0].labelName(); got != expect || labeled[0].Name != "CPU1Temp" {
+			t.Errorf("Sensor name label check failed for %s.\n Expect: %s (name CPU1Temp)\n Got: %s (name %s)", policy, expect, got, labeled[0].Name)
+		}
+	}
+	if res[0].Label != "" {
+		t.Errorf("labelSensors() modified the parsed sensors")
+	}
+}
+
+func TestSensorStateCounts(t *testing.T) {
+	counts := make(sensorStateCounts)
+	for _, state := range []float64{0, 0, 1, 3, math.NaN()} {
+		counts.observe(state)
+	}
+	for state, expect := range map[string]int{"ok": 2, "critical": 1, "non_recoverable": 0, "non_critical": 1, "unknown": 1} {
+		if counts[state] != expect {
+			t.Errorf("Sensor state count check failed for %s.\n Expect: %d\n Got: %d", state, expect, counts[state])
+		}
+	}
+}
+
+func TestDiscreteSensorState(t *testing.T) {
+	offset := 1
+	m := discreteSensorMapping{Metric: "door_switch", Offset: &offset}
+	for state, expect := range map[string]float64{"0x0200": 1, "0x0100": 0} {
+		if got := discreteSensorState(m, 4, sensorData{State: state}); got != expect {
+			t.Errorf("Discrete sensor state check failed for %s.\n Expect: %v\n Got: %v", state, expect, got)
+		}
+	}
+	if got := discreteSensorState(discreteSensorMapping{}, 1, sensorData{State: "0x0100"}); got != 1 {
+		t.Errorf("Reported discrete sensor state check failed.\n Expect: 1\n Got: %v", got)
+	}
+}
+
+func TestCollectSensorsMissingPolicy(t *testing.T) {
+	collSensorOutput := `CPU1 Temp        | 31.000     | degrees C  | ok    | 0.000     | 0.000     | 0.000     | 90.000    | 95.000    | 95.000
+P1-DIMMA2 Temp   | na         | degrees C  | na    | na        | na        | na        | na        | na        | na`
+	res, err := splitSensorOutput(collSensorOutput)
+	if err != nil {
+		t.Errorf("splitSensorOutput() call failed. Reason: %s", err)
+	}
+	for policy, expect := range map[string]int{"nan": 2, "omit": 1} {
+		ch := make(chan prometheus.Metric, 100)
+		collectSensors(ch, ipmiTarget{host: "policy-" + policy, config: IPMIConfig{MissingSensors: policy}}, res)
+		close(ch)
+		var got int
+		for m := range ch {
+			if m.Desc() == temperatureDesc {
+				got++
+			}
+		}
+		if got != expect {
+			t.Errorf("Missing sensor policy %s check failed.\n Expect: %d temperature series\n Got: %d", policy, expect, got)
+		}
+	}
+}
+
+func TestCollectSensorsMissingPolicySDR(t *testing.T) {
+	res, err := splitSDROutput(`PS1 Status       | C8h | ok  | 10.1 | Presence detected
+CPU Temp         | 01h | ok  | 3.1 | 40 degrees C
+DIMM Temp        | 02h | ns  | 3.2 | No Reading`)
+	if err != nil {
+		t.Fatalf("splitSDROutput() call failed. Reason: %s", err)
+	}
+	ch := make(chan prometheus.Metric, 100)
+	collectSensors(ch, ipmiTarget{host: "policy-sdr", config: IPMIConfig{MissingSensors: "omit"}}, res)
+	close(ch)
+	var psus, temperatures int
+	for m := range ch {
+		switch m.Desc() {
+		case psuStatusDesc:
+			psus++
+		case temperatureDesc:
+			temperatures++
+		}
+	}
+	if psus != 1 || temperatures != 1 {
+		t.Errorf("Missing sensor policy check failed for SDR output.\n Expect: 1 PSU status, 1 temperature series\n Got: %d PSU status, %d temperature series", psus, temperatures)
+	}
+}
+
+func TestSensorTypes(t *testing.T) {
+	config := IPMIConfig{SensorSource: "sensor", SensorTypes: []string{"Temperature", "Fan"}}
+	expect := [][]string{{"sdr", "type", "Temperature"}, {"sdr", "type", "Fan"}}
+	if res := (sensorCollector{}).Commands(config); !reflect.DeepEqual(res, expect) {
+		t.Errorf("Sensor type commands check failed.\n Expect: %v\n Got: %v", expect, res)
+	}
+
+	res, err := (sensorCollector{}).Parse([]string{
+		"",
+		"FAN 1            | 30h | ok  | 29.1 | 600 RPM\nFAN 2            | 31h | ok  | 29.2 | 640 RPM\n",
+	})
+	if err != nil {
+		t.Fatalf("Parse() call failed. Reason: %s", err)
+	}
+	if sensors := res.([]sensorData); len(sensors) != 2 || sensors[1].Name != "FAN2" || sensors[1].Value != 640 {
+		t.Errorf("Sensor type output check failed. Got: %+v", sensors)
+	}
+
+	if err := (sensorCollector{}).ValidateConfig(IPMIConfig{SensorTypes: []string{" "}}); err == nil {
+		t.Errorf("Empty sensor type was accepted")
+	}
+}
+
+func TestEventOnlySensors(t *testing.T) {
+	res, err := splitSDROutput(`ECC Corr Err     | 01h | ns  | 7.1 | Event-Only
+Temp             | 02h | ok  | 3.1 | 40 degrees C`)
+	if err != nil {
+		t.Fatalf("splitSDROutput() call failed. Reason: %s", err)
+	}
+	if !res[0].EventOnly || res[0].Type != "discrete" || res[1].EventOnly {
+		t.Errorf("Event-only sensor check failed.\n Expect: first sensor event-only\n Got: %+v", res)
+	}
+
+	ch := make(chan prometheus.Metric, 100)
+	collectSensors(ch, ipmiTarget{host: "event-only", config: IPMIConfig{MissingSensors: "omit"}}, res[:1])
+	close(ch)
+	var states int
+	for m := range ch {
+		switch m.Desc() {
+		case sensorStateDesc:
+			states++
+		case sensorValueDesc:
+			t.Errorf("Value of event-only sensor was exposed")
+		}
+	}
+	if states != 1 {
+		t.Errorf("Event-only sensor state check failed.\n Expect: 1 state series\n Got: %d", states)
+	}
+}
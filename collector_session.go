@@ -0,0 +1,119 @@
+//go:build !nosession
+// +build !nosession
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(sessionCollector{})
+}
+
+// sessionCollector collects the active sessions of the BMC.
+type sessionCollector struct{}
+
+func (sessionCollector) Name() string {
+	return "session"
+}
+
+func (sessionCollector) Commands(config IPMIConfig) [][]string {
+	return [][]string{{"session", "info", "all"}}
+}
+
+func (sessionCollector) Parse(outputs []string) (interface{}, error) {
+	return splitSessionOutput(outputs[0])
+}
+
+func (sessionCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	sessions := data.(sessionData)
+	ch <- prometheus.MustNewConstMetric(
+		sessionsActiveDesc,
+		prometheus.GaugeValue,
+		float64(sessions.Active),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		sessionSlotsDesc,
+		prometheus.GaugeValue,
+		float64(sessions.Slots),
+	)
+	for _, s := range sessions.Sessions {
+		// The console address is subject to anonymization, but the
+		// session is still exposed if it is dropped.
+		consoleIP, _ := anonymizeInfo(target.config, "ConsoleIPAddress", s["console ip"])
+		ch <- prometheus.MustNewConstMetric(
+			sessionInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			s["session handle"], s["user id"], s["privilege level"], s["session type"], s["channel number"], consoleIP,
+		)
+	}
+}
+
+type sessionData struct {
+	// Active is the number of active sessions and Slots the number of
+	// sessions the BMC supports.
+	Active int
+	Slots  int
+	// Sessions holds the fields of every active session by name.
+	Sessions []map[string]string
+}
+
+var (
+	sessionsActiveDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sessions", "active"),
+		"Number of active sessions of the BMC, including the one of the exporter.",
+		nil,
+		nil,
+	)
+
+	sessionSlotsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sessions", "slots"),
+		"Number of sessions the BMC supports.",
+		nil,
+		nil,
+	)
+
+	sessionInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "session", "info"),
+		"Constant metric with value '1' describing an active session of the BMC.",
+		[]string{"handle", "user_id", "privilege", "type", "channel", "console_ip"},
+		nil,
+	)
+)
+
+func splitSessionOutput(ipmitoolOutput string) (sessionData, error) {
+	var result sessionData
+	var session map[string]string
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1])
+		switch name {
+		case "session handle":
+			session = map[string]string{}
+			result.Sessions = append(result.Sessions, session)
+		case "slot count":
+			result.Slots, _ = strconv.Atoi(value)
+		case "active sessions":
+			result.Active, _ = strconv.Atoi(value)
+		}
+		if session != nil {
+			session[name] = value
+		}
+	}
+	if result.Slots == 0 {
+		return result, fmt.Errorf("no session information in output: %q", ipmitoolOutput)
+	}
+	return result, nil
+}
@@ -0,0 +1,46 @@
+//go:build !nosession
+// +build !nosession
+
+package main
+
+import (
+	"testing"
+)
+
+func TestSplitSessionOutput(t *testing.T) {
+	collSessionOutput := `session handle                : 1
+slot count                    : 4
+active sessions               : 2
+user id                       : 2
+privilege level               : ADMINISTRATOR
+session type                  : IPMIv2/RMCP+
+channel number                : 0x01
+console ip                    : 10.0.0.5
+console mac                   : 00:00:00:00:00:00
+console port                  : 52343
+
+session handle                : 3
+slot count                    : 4
+active sessions               : 2
+user id                       : 3
+privilege level               : USER
+session type                  : IPMIv2/RMCP+
+channel number                : 0x01
+console ip                    : 10.0.0.6
+console mac                   : 00:00:00:00:00:00
+console port                  : 41234`
+	res, err := splitSessionOutput(collSessionOutput)
+	if err != nil {
+		t.Errorf("splitSessionOutput() call failed. Reason: %s", err)
+	}
+	if res.Active != 2 || res.Slots != 4 || len(res.Sessions) != 2 {
+		t.Fatalf("Session count check failed.\n Expect: 2 active of 4 slots, 2 sessions\n Got: %d active of %d slots, %d sessions", res.Active, res.Slots, len(res.Sessions))
+	}
+	if s := res.Sessions[1]; s["session handle"] != "3" || s["privilege level"] != "USER" || s["console ip"] != "10.0.0.6" {
+		t.Errorf("Session check failed.\n Expect: handle 3, USER from 10.0.0.6\n Got: %v", s)
+	}
+
+	if _, err := splitSessionOutput("Error: Unable to establish IPMI v2 / RMCP+ session"); err == nil {
+		t.Errorf("Output without session information was accepted")
+	}
+}
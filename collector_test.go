@@ -1,18 +1,50 @@
 package main
 
 import (
-	"math"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 )
 
 var (
 	collTestConfig   string
-	collSafeConfTest = &SafeConfig{
-		C: &Config{},
-	}
+	collSafeConfTest = NewSafeConfig(&Config{})
 )
 
+// fakeCollector runs commands and returns their first output as data, so
+// that the tests of the core don't depend on collectors build tags leave out.
+type fakeCollector struct {
+	name     string
+	commands [][]string
+}
+
+// powerStatusCollector reads the `ipmitool power status` recorded for the
+// fake BMCs.
+var powerStatusCollector = fakeCollector{name: "power", commands: [][]string{{"power", "status"}}}
+
+var fakeDesc = prometheus.NewDesc("ipmi_fake", "Metric emitted by fakeCollector.", nil, nil)
+
+func (c fakeCollector) Name() string {
+	return c.name
+}
+
+func (c fakeCollector) Commands(config IPMIConfig) [][]string {
+	return c.commands
+}
+
+func (fakeCollector) Parse(outputs []string) (interface{}, error) {
+	return outputs[0], nil
+}
+
+func (fakeCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	ch <- prometheus.MustNewConstMetric(fakeDesc, prometheus.GaugeValue, 1)
+}
+
 func TestIpmitoolConfig(t *testing.T) {
 	collTestConfig := "./ipmi_remote.yml"
 	collSafeConfTest.ReloadConfig(collTestConfig)
@@ -21,89 +53,237 @@ func TestIpmitoolConfig(t *testing.T) {
 	config := collSafeConfTest.ConfigForTarget(collTarget, collModule)
 	res := ipmitoolConfig(config)
 	resString := strings.Join(res, " ")
-	expect := "-L administrator -U example_user -P example_pass -N 5"
+	expect := "-L user -U example_user -P example_pass -N 5"
 	if resString != expect {
 		t.Errorf("Wrong config line '%s' generatet for module '%s'", resString, collModule)
 	}
 }
 
-func TestSplitSensorOutput(t *testing.T) {
-	collSensorOutput := `CPU1 Temp        | 31.000     | degrees C  | ok    | 0.000     | 0.000     | 0.000     | 90.000    | 95.000    | 95.000
-P1-DIMMA2 Temp   | na         |            | na    | na        | na        | na        | na        | na        | na
-Chassis Intru    | 0x0        | discrete   | 0x0000| na        | na        | na        | na        | na        | na`
-	res, err := splitSensorOutput(collSensorOutput)
-	expectName := "CPU1Temp"
-	expectValue := float64(0)
-	if err != nil {
-		t.Errorf("splitSensorOutput() call failed. Reason: %s", err)
+func TestMaskedArgs(t *testing.T) {
+	collTestConfig := "./ipmi_remote.yml"
+	collSafeConfTest.ReloadConfig(collTestConfig)
+	config := collSafeConfTest.ConfigForTarget("localhost", "example")
+	res := maskedArgs(ipmitoolArgs(ipmiTarget{host: "localhost", config: config}, []string{"sensor", "list"}))
+	resString := strings.Join(res, " ")
+	expect := "-L user -U example_user -P ****** -N 5 -H localhost sensor list"
+	if resString != expect {
+		t.Errorf("Wrong masked argument vector.\n Expect: %s\n Got: %s", expect, resString)
+	}
+}
+
+func TestRegisteredCollectors(t *testing.T) {
+	for name, c := range registeredCollectors {
+		if c.Name() != name {
+			t.Errorf("Collector registered as '%s' is named '%s'", name, c.Name())
+		}
 	}
-	if res[0].Name != expectName {
-		t.Errorf("Whitespace sanitizin failed.\n Expect: %s\n Got: %s", expectName, res[0].Name)
+	// Default collectors excluded with build tags must not be enabled.
+	for _, name := range defaultConfig().Collectors {
+		if _, ok := registeredCollectors[name]; !ok {
+			t.Errorf("Default collector '%s' not registered", name)
+		}
 	}
-	if !math.IsNaN(res[1].Value) {
-		t.Errorf("NaN conversion failed.\n Value: %f is not math.NaN", res[1].Value)
+}
+
+func TestIpmitoolCommand(t *testing.T) {
+	args := []string{"-H", "localhost", "sensor", "list"}
+	name, argv := ipmitoolCommand("", "", args)
+	res := name + " " + strings.Join(argv, " ")
+	expected := "ipmitool -H localhost sensor list"
+	if res != expected {
+		t.Errorf("ipmitool command check failed.\n Expect: %s\n Got: %s", expected, res)
 	}
-	if res[2].Value != expectValue {
-		t.Errorf("HEX to float64 conversion failed.\n Expect: %f\n Got: %f", expectValue, res[2].Value)
+
+	name, argv = ipmitoolCommand("/usr/local/bin", "sudo -n  -u ipmi", args)
+	res = name + " " + strings.Join(argv, " ")
+	expected = "sudo -n -u ipmi /usr/local/bin/ipmitool -H localhost sensor list"
+	if res != expected {
+		t.Errorf("Prefixed ipmitool command check failed.\n Expect: %s\n Got: %s", expected, res)
 	}
 }
 
-func TestSplitFwumOutput(t *testing.T) {
-	collFwumOutput := `FWUM extension Version 1.3
+func TestIpmitoolEnviron(t *testing.T) {
+	env := ipmitoolEnviron([]string{"LC_ALL=en_US.UTF-8", "IPMI_PASSWORD=secret"})
+	expect := []string{"LC_ALL=C", "LANG=C", "LC_ALL=en_US.UTF-8", "IPMI_PASSWORD=secret"}
+	if !strings.HasPrefix(env[0], "PATH=") || !reflect.DeepEqual(env[1:], expect) {
+		t.Errorf("ipmitool environment check failed.\n Expect: PATH=... %v\n Got: %v", expect, env)
+	}
+}
 
-IPMC Info
-=========
-Manufacturer Id           : 10876
-Board Id                  : 2130
-Firmware Revision         : 3.76`
-	res, err := splitFwumOutput(collFwumOutput)
-	expectManID := float64(10876)
-	expectFWVer := 3.76
-	if err != nil {
-		t.Errorf("splitFwumOutput() call failed. Reason: %s", err)
+func TestLegacyArgs(t *testing.T) {
+	config := IPMIConfig{User: "user", Legacy: true}
+	res := strings.Join(ipmitoolArgs(ipmiTarget{host: "10.0.0.1", config: config}, []string{"sensor", "list"}), " ")
+	expect := "-U user -I lan -A MD5 -H 10.0.0.1 sensor list"
+	if res != expect {
+		t.Errorf("Legacy argument vector check failed.\n Expect: %s\n Got: %s", expect, res)
+	}
+
+	res = strings.Join(ipmitoolArgs(ipmiTarget{host: targetLocal, config: config}, []string{"sensor", "list"}), " ")
+	expect = "-U user sensor list"
+	if res != expect {
+		t.Errorf("Legacy argument vector check failed for local target.\n Expect: %s\n Got: %s", expect, res)
 	}
-	if res[4].Name != "ManufacturerId" && res[4].Value != expectManID {
-		t.Errorf("Manufacturer Id check failed.\n Expect:\n value: %f\n Got:\n value: %f", expectManID, res[4].Value)
+}
+
+func TestRunCollectorTimestamps(t *testing.T) {
+	*mockDir = e2eDir
+	*metricTimestamps = true
+	defer func() {
+		*mockDir = ""
+		*metricTimestamps = false
+	}()
+
+	ch := make(chan prometheus.Metric, 16)
+	if result := runCollector(ch, powerStatusCollector, ipmiTarget{host: "dell"}); result.up() != 1 {
+		t.Fatalf("runCollector() call failed. Reason: %+v", result)
 	}
-	if res[6].Name != "FirmwareRevision" && res[6].Value != expectFWVer {
-		t.Errorf("Firmware Revision check failed.\n Expect:\n value: %f\n Got:\n value: %f", expectFWVer, res[6].Value)
+	close(ch)
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("Write() call failed. Reason: %s", err)
+		}
+		if metric.TimestampMs == nil {
+			t.Errorf("Metric %s has no timestamp", m.Desc())
+		}
 	}
 }
 
-func TestSplitFruOutput(t *testing.T) {
-	collFruOutput := `FRU Device Description : Builtin FRU Device (ID 0)
-Chassis Type          : Other
-Chassis Part Number   : CSE-747BTS-R2K04BP
-Chassis Serial        : C7470KH08MS0040
-Board Mfg Date        : Mon Jan  1 03:00:00 1996
-Board Mfg             : Supermicro
-Board Serial          : VM187S012298
-Board Part Number     : X10DRG-Q
-Product Manufacturer  : Supermicro
-Product Part Number   : SYS-7048GR-TR
-Product Serial        : E16953528901097`
-	res, err := splitFruOutput(collFruOutput)
-	expectProductPN := "SYS-7048GR-TR"
-	expectProductMfg := "Supermicro"
-	if err != nil {
-		t.Errorf("splitFruOutput() call failed. Reason: %s", err)
+func TestProbeSession(t *testing.T) {
+	*mockDir = e2eDir
+	defer func() { *mockDir = "" }()
+
+	ch := make(chan prometheus.Metric, 1)
+	probeSession(ch, ipmiTarget{host: "dell"})
+	if len(ch) != 1 {
+		t.Fatalf("Session probe metric check failed.\n Expect: 1\n Got: %d", len(ch))
 	}
-	if res[9].Name != "ProductPartNumber" && res[9].Value != expectProductPN {
-		t.Errorf("Product Part Number check failed.\n Expect:\n value: %s\n Got:\n value: %s", expectProductPN, res[9].Value)
+	if desc := (<-ch).Desc(); desc != sessionSetupDurationDesc {
+		t.Errorf("Session probe metric check failed.\n Expect: %s\n Got: %s", sessionSetupDurationDesc, desc)
 	}
-	if res[5].Name != "FirmwareRevision" && res[5].Value != expectProductMfg {
-		t.Errorf("Board Mfg check failed.\n Expect:\n value: %s\n Got:\n value: %s", expectProductMfg, res[5].Value)
+
+	probeSession(ch, ipmiTarget{host: "kontron"})
+	if len(ch) != 0 {
+		t.Errorf("Failed session probe emitted a metric")
 	}
 }
 
-func TestGetChassisPowerState(t *testing.T) {
-	collChassisOutput := `Chassis Power is off`
-	res, err := getChassisPowerState(collChassisOutput)
-	expect := 0
-	if err != nil {
-		t.Errorf("getChassisPowerState() call failed. Reason: %s", err)
+// recordsCollector reports the lines of its output as records.
+type recordsCollector struct{ fakeCollector }
+
+func (recordsCollector) Records(data interface{}) int {
+	return len(strings.Split(data.(string), "\n"))
+}
+
+func TestParsedRecords(t *testing.T) {
+	for _, tc := range []struct {
+		collector ipmiCollector
+		data      interface{}
+		expect    int
+	}{
+		{recordsCollector{}, "Temp\nFAN1", 2},
+		{powerStatusCollector, "Chassis Power is on", 1},
+		{powerStatusCollector, nil, 0},
+	} {
+		if res := parsedRecords(tc.collector, tc.data); res != tc.expect {
+			t.Errorf("Parsed records check failed for %v.\n Expect: %d\n Got: %d", tc.data, tc.expect, res)
+		}
 	}
-	if res != expect {
-		t.Errorf("Chassis power state check failed.\n Expect:\n value: %v\n Got:\n value: %v", expect, res)
+}
+
+func TestRunCollectorResult(t *testing.T) {
+	*mockDir = e2eDir
+	defer func() { *mockDir = "" }()
+
+	ch := make(chan prometheus.Metric, 16)
+	result := runCollector(ch, powerStatusCollector, ipmiTarget{host: "kontron"})
+	if result.up() != 1 || result.Records != 1 {
+		t.Errorf("Collector result check failed.\n Expect: up, 1 record\n Got: %+v", result)
+	}
+	result = runCollector(ch, fakeCollector{name: "fru", commands: [][]string{{"fru", "list"}}}, ipmiTarget{host: "kontron"})
+	if result.up() != 0 || result.CommandErr == nil || result.ParseErr != nil {
+		t.Errorf("Collector result check failed for missing output.\n Expect: command error\n Got: %+v", result)
+	}
+}
+
+func parseFailureCount(t *testing.T, collector string) float64 {
+	var m dto.Metric
+	if err := parseFailures.WithLabelValues(collector).Write(&m); err != nil {
+		t.Fatalf("Write() call failed. Reason: %s", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+// checkFixtureParseFailures runs c against the fake BMCs, whose recorded
+// outputs are well-formed, and checks that parsing them fails for no line.
+func checkFixtureParseFailures(t *testing.T, c ipmiCollector) {
+	*mockDir = e2eDir
+	defer func() { *mockDir = "" }()
+
+	ch := make(chan prometheus.Metric, 1024)
+	before := parseFailureCount(t, c.Name())
+	for _, host := range []string{"dell", "supermicro"} {
+		if result := runCollector(ch, c, ipmiTarget{host: host}); result.up() != 1 {
+			t.Fatalf("runCollector() call failed. Reason: %+v", result)
+		}
+	}
+	if got := parseFailureCount(t, c.Name()) - before; got != 0 {
+		t.Errorf("Parse failures check failed for %s.\n Expect: 0\n Got: %v", c.Name(), got)
+	}
+}
+
+func TestRunCollectorParseFailures(t *testing.T) {
+	*mockDir = e2eDir
+	defer func() { *mockDir = "" }()
+
+	ch := make(chan prometheus.Metric, 16)
+	before := parseFailureCount(t, "unparsable")
+	if result := runCollector(ch, unparsableCollector{powerStatusCollector}, ipmiTarget{host: "dell"}); result.ParseErr == nil {
+		t.Fatalf("Parsing unparsable output succeeded")
+	}
+	if got := parseFailureCount(t, "unparsable") - before; got != 1 {
+		t.Errorf("Parse failures check failed for unparsable.\n Expect: 1\n Got: %v", got)
+	}
+}
+
+// unparsableCollector runs the commands of a fakeCollector and fails to
+// parse their output.
+type unparsableCollector struct{ fakeCollector }
+
+func (unparsableCollector) Name() string {
+	return "unparsable"
+}
+
+func (unparsableCollector) Parse(outputs []string) (interface{}, error) {
+	return nil, fmt.Errorf("unparsable output: %q", outputs[0])
+}
+
+func TestScrapeBudget(t *testing.T) {
+	budget := make(scrapeBudget)
+	budget["session_setup"] = time.Second
+	budget.add(collectorResult{CommandDuration: 3 * time.Second, ParseDuration: time.Second})
+	budget.add(collectorResult{CommandDuration: 2 * time.Second, EmitDuration: time.Second})
+
+	ch := make(chan prometheus.Metric, len(scrapePhases))
+	budget.collect(ch, 10*time.Second)
+	close(ch)
+	res := make(map[string]float64)
+	for m := range ch {
+		var metric dto.Metric
+		if err := m.Write(&metric); err != nil {
+			t.Fatalf("Write() call failed. Reason: %s", err)
+		}
+		res[metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+	}
+	expect := map[string]float64{
+		"session_setup":    1,
+		"vendor_detection": 0,
+		"command":          5,
+		"parse":            1,
+		"emit":             1,
+		"other":            2,
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("Scrape budget check failed.\n Expect: %v\n Got: %v", expect, res)
 	}
 }
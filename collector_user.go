@@ -0,0 +1,169 @@
+//go:build !nouser
+// +build !nouser
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector(userCollector{})
+}
+
+// userCollector collects the user accounts of the BMC.
+type userCollector struct{}
+
+func (userCollector) Name() string {
+	return "user"
+}
+
+func (userCollector) Commands(config IPMIConfig) [][]string {
+	channel := strconv.Itoa(config.UserChannel)
+	return [][]string{{"user", "summary", channel}, {"user", "list", channel}}
+}
+
+func (userCollector) Parse(outputs []string) (interface{}, error) {
+	summary, err := splitUserSummaryOutput(outputs[0])
+	if err != nil {
+		return nil, err
+	}
+	summary.Users = splitUserListOutput(outputs[1])
+	return summary, nil
+}
+
+func (userCollector) Emit(ch chan<- prometheus.Metric, target ipmiTarget, data interface{}) {
+	users := data.(userData)
+	ch <- prometheus.MustNewConstMetric(
+		usersMaxDesc,
+		prometheus.GaugeValue,
+		float64(users.Max),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		usersEnabledDesc,
+		prometheus.GaugeValue,
+		float64(users.Enabled),
+	)
+	for _, u := range users.Users {
+		ch <- prometheus.MustNewConstMetric(userCallinDesc, prometheus.GaugeValue, boolToFloat(u.Callin), u.ID, u.Name)
+		ch <- prometheus.MustNewConstMetric(userLinkAuthDesc, prometheus.GaugeValue, boolToFloat(u.LinkAuth), u.ID, u.Name)
+		ch <- prometheus.MustNewConstMetric(userIPMIMessagingDesc, prometheus.GaugeValue, boolToFloat(u.IPMIMessaging), u.ID, u.Name)
+		ch <- prometheus.MustNewConstMetric(userPrivilegeDesc, prometheus.GaugeValue, 1, u.ID, u.Name, u.Privilege)
+	}
+}
+
+type userData struct {
+	// Max is the number of user IDs and Enabled the number of enabled
+	// users, as reported by `ipmitool user summary`. ipmitool doesn't
+	// report which users are enabled.
+	Max     int
+	Enabled int
+	Users   []bmcUser
+}
+
+type bmcUser struct {
+	ID            string
+	Name          string
+	Callin        bool
+	LinkAuth      bool
+	IPMIMessaging bool
+	Privilege     string
+}
+
+var (
+	userMaxRegex     = regexp.MustCompile(`(?m)^Maximum\sIDs\s*:\s*(\d+)`)
+	userEnabledRegex = regexp.MustCompile(`(?m)^Enabled\sUser\sCount\s*:\s*(\d+)`)
+	userLineRegex    = regexp.MustCompile(`^(\d+)\s+(.*?)\s+(true|false)\s+(true|false)\s+(true|false)\s+(.+?)\s*$`)
+)
+
+var (
+	usersMaxDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "users", "max"),
+		"Number of user IDs of the BMC channel.",
+		nil,
+		nil,
+	)
+
+	usersEnabledDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "users", "enabled"),
+		"Number of enabled users of the BMC channel.",
+		nil,
+		nil,
+	)
+
+	userCallinDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "callin"),
+		"'1' if callin is allowed for the BMC user, '0' otherwise.",
+		[]string{"id", "name"},
+		nil,
+	)
+
+	userLinkAuthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "link_auth"),
+		"'1' if link authentication is enabled for the BMC user, '0' otherwise.",
+		[]string{"id", "name"},
+		nil,
+	)
+
+	userIPMIMessagingDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "ipmi_messaging"),
+		"'1' if IPMI messaging is enabled for the BMC user, '0' otherwise.",
+		[]string{"id", "name"},
+		nil,
+	)
+
+	userPrivilegeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "privilege_info"),
+		"Constant metric with value '1' providing the privilege limit of the BMC user on the channel.",
+		[]string{"id", "name", "privilege"},
+		nil,
+	)
+)
+
+func splitUserSummaryOutput(ipmitoolOutput string) (userData, error) {
+	var result userData
+	max := userMaxRegex.FindStringSubmatch(ipmitoolOutput)
+	enabled := userEnabledRegex.FindStringSubmatch(ipmitoolOutput)
+	if max == nil || enabled == nil {
+		return result, fmt.Errorf("no user summary in output: %q", ipmitoolOutput)
+	}
+	result.Max, _ = strconv.Atoi(max[1])
+	result.Enabled, _ = strconv.Atoi(enabled[1])
+	return result, nil
+}
+
+// splitUserListOutput parses the output of `ipmitool user list`. Empty user
+// slots without access are left out.
+func splitUserListOutput(ipmitoolOutput string) []bmcUser {
+	var result []bmcUser
+
+	scanner := bufio.NewScanner(strings.NewReader(ipmitoolOutput))
+	for scanner.Scan() {
+		match := userLineRegex.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		user := bmcUser{
+			ID:            match[1],
+			Name:          match[2],
+			Callin:        match[3] == "true",
+			LinkAuth:      match[4] == "true",
+			IPMIMessaging: match[5] == "true",
+			Privilege:     match[6],
+		}
+		if user.Name == "(Empty User)" {
+			user.Name = ""
+		}
+		if user.Name == "" && user.Privilege == "NO ACCESS" {
+			continue
+		}
+		result = append(result, user)
+	}
+	return result
+}
@@ -0,0 +1,37 @@
+//go:build !nouser
+// +build !nouser
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitUserOutput(t *testing.T) {
+	collSummaryOutput := `Maximum IDs	    : 10
+Enabled User Count  : 2
+Fixed Name Count    : 2`
+	res, err := splitUserSummaryOutput(collSummaryOutput)
+	if err != nil {
+		t.Errorf("splitUserSummaryOutput() call failed. Reason: %s", err)
+	}
+	if res.Max != 10 || res.Enabled != 2 {
+		t.Errorf("User summary check failed.\n Expect: 10 IDs, 2 enabled\n Got: %d IDs, %d enabled", res.Max, res.Enabled)
+	}
+
+	collUserOutput := `ID  Name	     Callin  Link Auth	IPMI Msg   Channel Priv Limit
+1                    true    false      false      Unknown (0x00)
+2   ADMIN            false   false      true       ADMINISTRATOR
+3   (Empty User)     true    false      false      NO ACCESS
+4   ops user         true    true       true       OPERATOR`
+	users := splitUserListOutput(collUserOutput)
+	expect := []bmcUser{
+		{ID: "1", Name: "", Callin: true, Privilege: "Unknown (0x00)"},
+		{ID: "2", Name: "ADMIN", IPMIMessaging: true, Privilege: "ADMINISTRATOR"},
+		{ID: "4", Name: "ops user", Callin: true, LinkAuth: true, IPMIMessaging: true, Privilege: "OPERATOR"},
+	}
+	if !reflect.DeepEqual(users, expect) {
+		t.Errorf("User list check failed.\n Expect: %+v\n Got: %+v", expect, users)
+	}
+}
@@ -3,10 +3,15 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
+	"path"
+	"regexp"
 	"strings"
-	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/model"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -14,14 +19,59 @@ import (
 type Config struct {
 	Modules map[string]IPMIConfig `yaml:"modules"`
 
+	// Static target name to address overrides, and how long DNS lookups of
+	// other remote targets are cached (0 disables caching).
+	Hosts       map[string]string `yaml:"hosts"`
+	DNSCacheTTL time.Duration     `yaml:"dns_cache_ttl"`
+
+	// Disables collecting the IPMI metrics of the host the exporter runs on,
+	// for deployments that only scrape remote targets.
+	DisableLocal bool `yaml:"disable_local"`
+
+	// Rules selecting the module of scrapes that don't specify one by their
+	// target. The first matching rule wins, and targets matching no rule use
+	// the default module.
+	ModuleRules []moduleRule `yaml:"module_rules"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// moduleRule maps targets to a module. Target is either a shell pattern
+// matched against the target name, e.g. "*.idrac.example.com" or
+// "10.1.2.*", or a CIDR network matched against target IP addresses, e.g.
+// "10.1.2.0/24".
+type moduleRule struct {
+	Target string `yaml:"target"`
+	Module string `yaml:"module"`
+
+	network *net.IPNet
+
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
 }
 
-// SafeConfig wraps Config for concurrency-safe operations.
+// SafeConfig wraps Config for concurrency-safe operations. A reload replaces
+// the whole Config atomically, so a snapshot obtained through Config stays
+// consistent for as long as it is used. Snapshots must not be modified.
 type SafeConfig struct {
-	sync.RWMutex
-	C *Config
+	c atomic.Value // *Config
+}
+
+// NewSafeConfig returns a SafeConfig holding c.
+func NewSafeConfig(c *Config) *SafeConfig {
+	safeConf := &SafeConfig{}
+	safeConf.c.Store(c)
+	return safeConf
+}
+
+// Config returns the current configuration snapshot.
+func (safeConf *SafeConfig) Config() *Config {
+	c, _ := safeConf.c.Load().(*Config)
+	if c == nil {
+		return &Config{}
+	}
+	return c
 }
 
 // IPMIConfig is the Go representation of a module configuration in the yaml
@@ -33,12 +83,173 @@ type IPMIConfig struct {
 	Interface  string   `yaml:"interface"`
 	Timeout    int64    `yaml:"timeout"`
 	Collectors []string `yaml:"collectors"`
+	// Collectors run first and never skipped near the scrape timeout. All
+	// other collectors are best-effort.
+	CriticalCollectors []string `yaml:"critical_collectors"`
+
+	// Local modules collect from the host the exporter runs on, for in-band
+	// scrapes through /ipmi without a target.
+	Local bool `yaml:"local"`
+
+	// Regular expressions matched against sensor names to identify inlet and
+	// exhaust temperature sensors in addition to the built-in ones.
+	InletSensors   []string `yaml:"inlet_sensors"`
+	ExhaustSensors []string `yaml:"exhaust_sensors"`
+
+	// Mappings of discrete sensors to metric families, matched before the
+	// ones of the vendor profile and the built-in ones.
+	DiscreteSensors []discreteSensorMapping `yaml:"discrete_sensors"`
+
+	// How to expose sensors without a reading: "nan" emits NaN values,
+	// "omit" leaves out all series of the sensor.
+	MissingSensors string `yaml:"missing_sensors"`
+
+	// How to expose the state of analog sensors reporting "ns" (not
+	// specified): "reported" keeps it, "thresholds" computes it from the
+	// reading and the thresholds of the sensor.
+	NotSpecifiedState string `yaml:"ns_state"`
+
+	// How whitespace in sensor, FRU field and BMC field names is exposed in
+	// name labels: "strip" removes it, "underscore" replaces it with
+	// underscores and "preserve" keeps the names as printed by ipmitool.
+	NameSanitization string `yaml:"name_sanitization"`
+
+	// Command the sensor collector reads sensors from: "sensor" for
+	// `ipmitool sensor list`, "csv" for its machine-readable variant
+	// `ipmitool -c sensor list`, or "sdr" for the faster `ipmitool sdr elist
+	// full`, which doesn't report thresholds.
+	SensorSource string `yaml:"sensor_source"`
+
+	// Sensor types the sensor collector reads with `ipmitool sdr type <TYPE>`
+	// instead of reading all sensors, e.g. "Temperature" or "Fan", for BMCs
+	// too slow to list all sensors within the scrape timeout. Overrides
+	// SensorSource.
+	SensorTypes []string `yaml:"sensor_types"`
+
+	// What to do if all collectors of a target fail: "metrics" serves the
+	// metrics with ipmi_up 0, "error" fails the scrape with HTTP 500 so that
+	// Prometheus marks the target down.
+	TotalFailure string `yaml:"total_failure"`
+
+	// Legacy enables compatibility with IPMI 1.5 devices: remote targets are
+	// accessed with the lan interface and MD5 authentication, and collectors
+	// needing IPMI 2.0 are skipped.
+	Legacy bool `yaml:"legacy"`
+
+	// SessionProbe times a "mc info" call on every scrape of a remote target
+	// to expose how long establishing the IPMI session takes.
+	SessionProbe bool `yaml:"session_probe"`
+
+	// Sampling period of the dcmi-power readings, e.g. "1_min" or "1_hour",
+	// or "now" for BMCs that reject periods and only report the BMC's
+	// default statistics.
+	DCMISamplePeriod string `yaml:"dcmi_sample_period"`
+
+	// Entities whose thermal policy the dcmi-thermal collector collects:
+	// inlet, cpu or baseboard.
+	DCMIThermalEntities []string `yaml:"dcmi_thermal_entities"`
+
+	// Intel Node Manager statistics and policies the nm collector collects.
+	NMStatistics []string `yaml:"nm_statistics"`
+	NMPolicyIDs  []int    `yaml:"nm_policy_ids"`
+
+	// Names of the sensors whose details the sensor-get collector collects
+	// with `ipmitool sensor get`.
+	SensorGet []string `yaml:"sensor_get"`
+
+	// Raw IPMI commands the raw collector runs, and how to decode their
+	// responses.
+	RawCommands []rawCommand `yaml:"raw_commands"`
+
+	// Number of most recent System Event Log entries exposed by the
+	// sel-events collector.
+	SELEventsLimit int `yaml:"sel_events_limit"`
+
+	// ipmitool command clearing the latched chassis intrusion state, e.g.
+	// ["raw", "0x30", "0x03"] on Supermicro boards. Defaults to re-arming the
+	// events of the chassis intrusion sensor.
+	IntrusionResetCommand []string `yaml:"intrusion_reset_command"`
+
+	// Channel whose users the user collector collects.
+	UserChannel int `yaml:"user_channel"`
+
+	// Channels whose info the channel collector collects.
+	Channels []int `yaml:"channels"`
+
+	// LAN channels whose configuration the lan collector collects. By
+	// default, ipmitool picks the first LAN channel.
+	LANChannels []int `yaml:"lan_channels"`
+
+	// I2C bus and addresses of the power supplies the psu-pmbus collector
+	// reads over PMBus.
+	PSUPMBusBus       string   `yaml:"psu_pmbus_bus"`
+	PSUPMBusAddresses []string `yaml:"psu_pmbus_addresses"`
+
+	// Anonymization of identifying fru, lan and bmc info values (serial
+	// numbers, asset tags, MAC and IP addresses): "none", "hash" replaces them
+	// with a salted hash that is stable per value, "drop" omits them.
+	Anonymize     string `yaml:"anonymize"`
+	AnonymizeSalt string `yaml:"anonymize_salt"`
+
+	// Vendor profile to apply: "auto" (default) detects the vendor on the
+	// first scrape of a target, "none" disables profiles, or the name of a
+	// profile.
+	Vendor string `yaml:"vendor"`
+
+	// Recommended scrape intervals per collector, overriding the built-in
+	// hints published on /scrape-intervals.
+	ScrapeIntervals map[string]time.Duration `yaml:"scrape_intervals"`
+
+	// collectorsListed is true if the module lists its collectors, which
+	// vendor profiles then leave as they are.
+	collectorsListed bool
+
+	inletRegexps    []*regexp.Regexp
+	exhaustRegexps  []*regexp.Regexp
+	discreteSensors []discreteSensorMapping
+	psuLineSensors  bool
 
 	// Catches all undefined fields and must be empty after parsing.
 	XXX map[string]interface{} `yaml:",inline"`
 }
 
-var emptyConfig = IPMIConfig{Collectors: []string{"sensor", "fwum", "fru", "dcmi-power"}}
+var emptyConfig = IPMIConfig{
+	Privilege:           "user",
+	MissingSensors:      "nan",
+	NotSpecifiedState:   "reported",
+	SensorSource:        "sensor",
+	NameSanitization:    "strip",
+	TotalFailure:        "metrics",
+	Anonymize:           "none",
+	UserChannel:         1,
+	Channels:            []int{1},
+	PSUPMBusBus:         "0x07",
+	PSUPMBusAddresses:   []string{"0x78", "0x7a"},
+	CriticalCollectors:  defaultCriticalCollectors,
+	DCMISamplePeriod:    "1_min",
+	DCMIThermalEntities: []string{"inlet"},
+	NMStatistics:        []string{"power", "temps"},
+	SELEventsLimit:      10,
+	Vendor:              "auto",
+}
+
+// defaultCollectors are enabled in modules that don't list their collectors.
+var defaultCollectors = []string{"sensor", "fwum", "fru", "dcmi-power", "power"}
+
+// defaultConfig returns emptyConfig with those default collectors that are
+// compiled in.
+func defaultConfig() IPMIConfig {
+	config := emptyConfig
+	for _, name := range defaultCollectors {
+		if _, ok := registeredCollectors[name]; ok {
+			config.Collectors = append(config.Collectors, name)
+		}
+	}
+	return config
+}
+
+// privilegeLevels are the session privilege levels accepted by ipmitool -L.
+var privilegeLevels = []string{"callback", "user", "operator", "administrator"}
 
 // CollectorName is used for unmarshaling the list of collectors in the yaml config file
 type CollectorName string
@@ -63,27 +274,164 @@ func (s *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := checkOverflow(s.XXX, "config"); err != nil {
 		return err
 	}
+	for _, rule := range s.ModuleRules {
+		module, ok := s.Modules[rule.Module]
+		if !ok && rule.Module != "default" {
+			return fmt.Errorf("unknown module in module_rules: %s", rule.Module)
+		}
+		if module.Local {
+			return fmt.Errorf("module rule for %s selects local module %s", rule.Target, rule.Module)
+		}
+	}
+	for name, module := range s.Modules {
+		if module.Local && s.DisableLocal {
+			return fmt.Errorf("module %s is local, but local collection is disabled", name)
+		}
+		if strings.EqualFold(module.Privilege, "administrator") && !module.writesToBMC() && !*enableActions {
+			log.Warnf("Module %s uses privilege level %s, but it has no raw commands or actions that change the BMC; consider using user", name, module.Privilege)
+		}
+	}
 	return nil
 }
 
+// writesToBMC reports whether the module configures commands that may change
+// the state of the BMC, which can need a higher privilege level than user.
+func (c IPMIConfig) writesToBMC() bool {
+	return (containsString(c.Collectors, "raw") && len(c.RawCommands) > 0) || len(c.IntrusionResetCommand) > 0
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (s *IPMIConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	*s = emptyConfig
+	*s = defaultConfig()
+	collectors := s.Collectors
+	s.Collectors = nil
 	type plain IPMIConfig
 	if err := unmarshal((*plain)(s)); err != nil {
 		return err
 	}
+	s.collectorsListed = s.Collectors != nil
+	if !s.collectorsListed {
+		s.Collectors = collectors
+	}
 	if err := checkOverflow(s.XXX, "modules"); err != nil {
 		return err
 	}
+	if !validPrivilege(s.Privilege) {
+		return fmt.Errorf("unknown privilege level: %s (must be one of %s)", s.Privilege, strings.Join(privilegeLevels, ", "))
+	}
 	for _, c := range s.Collectors {
-		if !(c == "sensor" || c == "fwum" || c == "fru" || c == "dcmi-power") {
-			return fmt.Errorf("unknown collector name: %s", c)
+		collector, ok := registeredCollectors[c]
+		if !ok {
+			return fmt.Errorf("unknown collector name: %s (known: %s)", c, strings.Join(collectorNames(), ", "))
+		}
+		if validator, ok := collector.(configValidator); ok {
+			if err := validator.ValidateConfig(*s); err != nil {
+				return err
+			}
 		}
 	}
+	for c, interval := range s.ScrapeIntervals {
+		if _, ok := registeredCollectors[c]; !ok {
+			return fmt.Errorf("unknown collector name in scrape_intervals: %s", c)
+		}
+		if interval <= 0 {
+			return fmt.Errorf("invalid scrape interval for collector %s: %s", c, interval)
+		}
+	}
+	if _, ok := vendorProfileByName(s.Vendor); !ok && !strings.EqualFold(s.Vendor, "auto") && !strings.EqualFold(s.Vendor, "none") {
+		return fmt.Errorf("unknown vendor: %s (must be auto, none or one of %s)", s.Vendor, strings.Join(vendorProfileNames(), ", "))
+	}
+	if s.MissingSensors != "nan" && s.MissingSensors != "omit" {
+		return fmt.Errorf("unknown missing_sensors policy: %s (must be nan or omit)", s.MissingSensors)
+	}
+	if s.UserChannel < 0 || s.UserChannel > 15 {
+		return fmt.Errorf("invalid user_channel: %d (must be 0-15)", s.UserChannel)
+	}
+	if s.SELEventsLimit < 0 {
+		return fmt.Errorf("invalid sel_events_limit: %d", s.SELEventsLimit)
+	}
+	if s.Anonymize != "none" && s.Anonymize != "hash" && s.Anonymize != "drop" {
+		return fmt.Errorf("unknown anonymize policy: %s (must be none, hash or drop)", s.Anonymize)
+	}
+	if s.NameSanitization != "strip" && s.NameSanitization != "underscore" && s.NameSanitization != "preserve" {
+		return fmt.Errorf("unknown name_sanitization: %s (must be strip, underscore or preserve)", s.NameSanitization)
+	}
+	if s.SensorSource != "sensor" && s.SensorSource != "csv" && s.SensorSource != "sdr" {
+		return fmt.Errorf("unknown sensor_source: %s (must be sensor, csv or sdr)", s.SensorSource)
+	}
+	if s.TotalFailure != "metrics" && s.TotalFailure != "error" {
+		return fmt.Errorf("unknown total_failure policy: %s (must be metrics or error)", s.TotalFailure)
+	}
+	if s.NotSpecifiedState != "reported" && s.NotSpecifiedState != "thresholds" {
+		return fmt.Errorf("unknown ns_state policy: %s (must be reported or thresholds)", s.NotSpecifiedState)
+	}
+	var err error
+	if s.inletRegexps, err = compileRegexps(s.InletSensors); err != nil {
+		return fmt.Errorf("invalid inlet_sensors pattern: %s", err)
+	}
+	if s.exhaustRegexps, err = compileRegexps(s.ExhaustSensors); err != nil {
+		return fmt.Errorf("invalid exhaust_sensors pattern: %s", err)
+	}
+	s.discreteSensors = s.DiscreteSensors
 	return nil
 }
 
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *moduleRule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain moduleRule
+	if err := unmarshal((*plain)(r)); err != nil {
+		return err
+	}
+	if err := checkOverflow(r.XXX, "module_rules"); err != nil {
+		return err
+	}
+	if r.Target == "" || r.Module == "" {
+		return fmt.Errorf("module rule needs target and module")
+	}
+	if strings.Contains(r.Target, "/") {
+		_, network, err := net.ParseCIDR(r.Target)
+		if err != nil {
+			return fmt.Errorf("invalid module rule network %s: %s", r.Target, err)
+		}
+		r.network = network
+	} else if _, err := path.Match(r.Target, ""); err != nil {
+		return fmt.Errorf("invalid module rule pattern %s: %s", r.Target, err)
+	}
+	return nil
+}
+
+// matches returns true if the rule applies to target. Names are matched
+// case-insensitively.
+func (r moduleRule) matches(target string) bool {
+	if r.network != nil {
+		ip := net.ParseIP(target)
+		return ip != nil && r.network.Contains(ip)
+	}
+	ok, _ := path.Match(strings.ToLower(r.Target), strings.ToLower(target))
+	return ok
+}
+
+func validPrivilege(privilege string) bool {
+	for _, p := range privilegeLevels {
+		if strings.EqualFold(privilege, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileRegexps(patterns []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
 // ReloadConfig reloads the config in a concurrency-safe way. If the configFile
 // is unreadable or unparsable, an error is returned and the old config is kept.
 func (safeConf *SafeConfig) ReloadConfig(configFile string) error {
@@ -105,9 +453,7 @@ func (safeConf *SafeConfig) ReloadConfig(configFile string) error {
 		return err
 	}
 
-	safeConf.Lock()
-	safeConf.C = c
-	safeConf.Unlock()
+	safeConf.c.Store(c)
 
 	if configFile != "" {
 		log.Infoln("Loaded config file", configFile)
@@ -117,24 +463,52 @@ func (safeConf *SafeConfig) ReloadConfig(configFile string) error {
 
 // HasModule returns true if a given module is configured. It is concurrency-safe.
 func (safeConf *SafeConfig) HasModule(module string) bool {
-	safeConf.Lock()
-	defer safeConf.Unlock()
+	return safeConf.Config().HasModule(module)
+}
 
-	_, ok := safeConf.C.Modules[module]
+// HasModule returns true if a given module is configured.
+func (c *Config) HasModule(module string) bool {
+	_, ok := c.Modules[module]
 	return ok
 }
 
+// ModuleForTarget returns module, or the module selected for target by the
+// module rules if module is empty. It is concurrency-safe.
+func (safeConf *SafeConfig) ModuleForTarget(target, module string) string {
+	return safeConf.Config().ModuleForTarget(target, module)
+}
+
+// ModuleForTarget returns module, or the module selected for target by the
+// module rules if module is empty. Targets matching no rule use the default
+// module.
+func (c *Config) ModuleForTarget(target, module string) string {
+	if module != "" {
+		return module
+	}
+	if !targetIsLocal(target) {
+		for _, rule := range c.ModuleRules {
+			if rule.matches(target) {
+				return rule.Module
+			}
+		}
+	}
+	return "default"
+}
+
 // ConfigForTarget returns the config for a given target/module, or the
 // default. It is concurrency-safe.
 func (safeConf *SafeConfig) ConfigForTarget(target, module string) IPMIConfig {
-	safeConf.Lock()
-	defer safeConf.Unlock()
+	return safeConf.Config().ConfigForTarget(target, module)
+}
 
+// ConfigForTarget returns the config for a given target/module, or the
+// default.
+func (c *Config) ConfigForTarget(target, module string) IPMIConfig {
 	var config IPMIConfig
 	var ok = false
 
 	if module != "default" {
-		config, ok = safeConf.C.Modules[module]
+		config, ok = c.Modules[module]
 		if !ok {
 			log.Warnf("Requested module %s for target %s not found, using default", module, targetName(target))
 		}
@@ -142,13 +516,44 @@ func (safeConf *SafeConfig) ConfigForTarget(target, module string) IPMIConfig {
 
 	// If nothing found, fall back to defaults
 	if !ok {
-		config, ok = safeConf.C.Modules["default"]
+		config, ok = c.Modules["default"]
 		if !ok {
 			// This is probably fine for running locally, so not making this a warning
 			log.Debugf("Needed default config for target %s, but none configured, using ipmitool defaults", targetName(target))
-			config = emptyConfig
+			config = defaultConfig()
 		}
 	}
 
 	return config
 }
+
+// ScrapeInterval returns the recommended scrape interval of the named
+// collector in this module.
+func (c IPMIConfig) ScrapeInterval(collector string) time.Duration {
+	if interval, ok := c.ScrapeIntervals[collector]; ok {
+		return interval
+	}
+	if hinter, ok := registeredCollectors[collector].(scrapeIntervalHinter); ok {
+		return hinter.ScrapeInterval()
+	}
+	return defaultScrapeInterval
+}
+
+// ScrapeIntervals returns the recommended scrape interval of every enabled
+// collector, by module. The default module is always included.
+func (c *Config) ScrapeIntervals() map[string]map[string]model.Duration {
+	modules := make(map[string]IPMIConfig, len(c.Modules)+1)
+	modules["default"] = c.ConfigForTarget(targetLocal, "default")
+	for name, module := range c.Modules {
+		modules[name] = module
+	}
+
+	intervals := make(map[string]map[string]model.Duration, len(modules))
+	for name, module := range modules {
+		intervals[name] = make(map[string]model.Duration, len(module.Collectors))
+		for _, collector := range module.Collectors {
+			intervals[name][collector] = model.Duration(module.ScrapeInterval(collector))
+		}
+	}
+	return intervals
+}
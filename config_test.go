@@ -1,16 +1,17 @@
 package main
 
 import (
+	"sync"
 	"testing"
+
+	yaml "gopkg.in/yaml.v2"
 )
 
 var (
 	testConfig     string
 	testBadConfig  string
 	testGoodConfig string
-	safeConfTest   = &SafeConfig{
-		C: &Config{},
-	}
+	safeConfTest   = NewSafeConfig(&Config{})
 )
 
 func TestGoodReloadConfig(t *testing.T) {
@@ -61,3 +62,152 @@ func TestConfigForTarget(t *testing.T) {
 		t.Errorf("Default module not loaded instead of non-existing module '%s'", module)
 	}
 }
+
+func TestPrivilegeValidation(t *testing.T) {
+	var config IPMIConfig
+	if err := yaml.Unmarshal([]byte("user: foo"), &config); err != nil {
+		t.Errorf("Module without privilege not loaded.\n Error is: %s", err)
+	}
+	if config.Privilege != "user" {
+		t.Errorf("Wrong default privilege level.\n Expect: user\n Got: %s", config.Privilege)
+	}
+	if err := yaml.Unmarshal([]byte("privilege: OPERATOR"), &config); err != nil {
+		t.Errorf("Module with privilege OPERATOR not loaded.\n Error is: %s", err)
+	}
+	if err := yaml.Unmarshal([]byte("privilege: root"), &config); err == nil {
+		t.Errorf("Module with unknown privilege level was loaded.\n")
+	}
+}
+
+func TestWritesToBMC(t *testing.T) {
+	rawCommands := []rawCommand{{Metric: "ipmi_raw_test", Command: []string{"0x06", "0x01"}}}
+	for _, c := range []struct {
+		config IPMIConfig
+		expect bool
+	}{
+		{IPMIConfig{Privilege: "administrator", Collectors: []string{"sensor"}}, false},
+		{IPMIConfig{Collectors: []string{"sensor"}, RawCommands: rawCommands}, false},
+		{IPMIConfig{Collectors: []string{"raw"}, RawCommands: rawCommands}, true},
+		{IPMIConfig{IntrusionResetCommand: []string{"raw", "0x04", "0x2a", "0x0b", "0x00"}}, true},
+	} {
+		if got := c.config.writesToBMC(); got != c.expect {
+			t.Errorf("Write check failed for %+v.\n Expect: %t\n Got: %t", c.config, c.expect, got)
+		}
+	}
+}
+
+func TestConcurrentReloadConfig(t *testing.T) {
+	testGoodConfig := "./ipmi_remote.yml"
+	safeConf := NewSafeConfig(&Config{})
+	if err := safeConf.ReloadConfig(testGoodConfig); err != nil {
+		t.Fatalf("Config file %s not loaded.\n Error is: %s", testGoodConfig, err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				safeConf.ReloadConfig(testGoodConfig)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				// A snapshot must stay consistent while reloads happen.
+				config := safeConf.Config()
+				if !config.HasModule("example") {
+					t.Errorf("Module 'example' missing from config snapshot")
+				}
+				if res := config.ConfigForTarget("localhost", "example"); res.User != "example_user" {
+					t.Errorf("Wrong module 'example' loaded from config snapshot: %s", res.User)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestScrapeIntervals(t *testing.T) {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte("modules:\n  slow:\n    collectors: [sensor, fru]\n    scrape_intervals:\n      sensor: 2m\n"), c)
+	if err != nil {
+		t.Fatalf("Config with scrape intervals not loaded.\n Error is: %s", err)
+	}
+	intervals := c.ScrapeIntervals()
+	if got := intervals["slow"]["sensor"].String(); got != "2m" {
+		t.Errorf("Configured scrape interval check failed.\n Expect: 2m\n Got: %s", got)
+	}
+	if got := intervals["slow"]["fru"].String(); got != "1h" {
+		t.Errorf("Hinted scrape interval check failed.\n Expect: 1h\n Got: %s", got)
+	}
+	if got := intervals["default"]["sensor"].String(); got != "30s" {
+		t.Errorf("Default scrape interval check failed.\n Expect: 30s\n Got: %s", got)
+	}
+
+	err = yaml.Unmarshal([]byte("modules:\n  bad:\n    scrape_intervals:\n      foo: 1m\n"), &Config{})
+	if err == nil {
+		t.Errorf("Scrape interval of unknown collector was accepted")
+	}
+}
+
+func TestLocalModules(t *testing.T) {
+	err := yaml.Unmarshal([]byte("disable_local: true\nmodules:\n  inband:\n    local: true\n"), &Config{})
+	if err == nil {
+		t.Errorf("Local module was accepted with local collection disabled")
+	}
+	c := &Config{}
+	err = yaml.Unmarshal([]byte("modules:\n  inband:\n    local: true\n    interface: open\n"), c)
+	if err != nil {
+		t.Fatalf("Config with local module not loaded.\n Error is: %s", err)
+	}
+	if !c.ConfigForTarget(targetLocal, "inband").Local {
+		t.Errorf("Local module check failed")
+	}
+}
+
+func TestModuleRules(t *testing.T) {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte(`modules:
+  dell: {}
+  supermicro: {}
+module_rules:
+- target: "*.idrac.example.com"
+  module: dell
+- target: 10.1.2.0/24
+  module: supermicro
+- target: "10.1.*"
+  module: dell
+`), c)
+	if err != nil {
+		t.Fatalf("Config with module rules not loaded.\n Error is: %s", err)
+	}
+	tests := []struct {
+		target, module, expect string
+	}{
+		{"bmc1.IDRAC.example.com", "", "dell"},
+		{"10.1.2.23", "", "supermicro"},
+		{"10.1.3.23", "", "dell"},
+		{"bmc1.example.com", "", "default"},
+		{targetLocal, "", "default"},
+		{"bmc1.idrac.example.com", "supermicro", "supermicro"},
+	}
+	for _, test := range tests {
+		if res := c.ModuleForTarget(test.target, test.module); res != test.expect {
+			t.Errorf("Module rule check failed for %q.\n Expect: %s\n Got: %s", test.target, test.expect, res)
+		}
+	}
+
+	for _, bad := range []string{
+		"module_rules:\n- target: '*.example.com'\n  module: dell\n",
+		"module_rules:\n- target: '[a-'\n  module: default\n",
+		"module_rules:\n- target: 10.1.2.0/33\n  module: default\n",
+		"module_rules:\n- module: default\n",
+		"modules:\n  inband:\n    local: true\nmodule_rules:\n- target: '*'\n  module: inband\n",
+	} {
+		if err := yaml.Unmarshal([]byte(bad), &Config{}); err == nil {
+			t.Errorf("Invalid module rules were accepted: %q", bad)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// discreteSensorMapping routes the discrete sensors whose name matches
+// Pattern to the metrics of a family, e.g. the chassis intrusion metrics.
+type discreteSensorMapping struct {
+	// Pattern is matched against the sensor name with whitespace stripped.
+	Pattern string `yaml:"pattern"`
+	// Metric is one of discreteSensorMetrics.
+	Metric string `yaml:"metric"`
+	// Offset decodes the state from a single sensor offset: 1 if it is
+	// asserted, 0 otherwise. The reported state is used if nil.
+	Offset *int `yaml:"offset"`
+
+	regexp *regexp.Regexp
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// discreteSensorMetrics are the metric families discrete sensors can be
+// mapped to.
+var discreteSensorMetrics = []string{"chassis_intrusion", "psu_presence", "door_switch"}
+
+// builtinDiscreteSensors are matched after the mappings of the module and of
+// its vendor profile.
+var builtinDiscreteSensors = []discreteSensorMapping{
+	builtinDiscreteSensor(`ChassisIntru`, "chassis_intrusion"),
+	builtinDiscreteSensor(`PS\dStatus`, "psu_presence"),
+}
+
+func builtinDiscreteSensor(pattern, metric string) discreteSensorMapping {
+	return discreteSensorMapping{Pattern: pattern, Metric: metric, regexp: regexp.MustCompile(pattern)}
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (m *discreteSensorMapping) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain discreteSensorMapping
+	if err := unmarshal((*plain)(m)); err != nil {
+		return err
+	}
+	if err := checkOverflow(m.XXX, "discrete_sensors"); err != nil {
+		return err
+	}
+	if !containsString(discreteSensorMetrics, m.Metric) {
+		return fmt.Errorf("unknown discrete_sensors metric: %s (must be one of %s)", m.Metric, strings.Join(discreteSensorMetrics, ", "))
+	}
+	if m.Offset != nil && (*m.Offset < 0 || *m.Offset > 14) {
+		return fmt.Errorf("invalid discrete_sensors offset: %d (must be 0-14)", *m.Offset)
+	}
+	var err error
+	if m.regexp, err = regexp.Compile(m.Pattern); err != nil {
+		return fmt.Errorf("invalid discrete_sensors pattern: %s", err)
+	}
+	return nil
+}
+
+// discreteSensorMappingFor returns the first mapping of the module, its vendor
+// profile or the built-in ones matching the sensor name.
+func discreteSensorMappingFor(config IPMIConfig, name string) (discreteSensorMapping, bool) {
+	for _, mappings := range [][]discreteSensorMapping{config.discreteSensors, builtinDiscreteSensors} {
+		for _, m := range mappings {
+			if m.regexp.MatchString(name) {
+				return m, true
+			}
+		}
+	}
+	return discreteSensorMapping{}, false
+}
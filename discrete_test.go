@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestDiscreteSensorMapping(t *testing.T) {
+	var config IPMIConfig
+	err := yaml.Unmarshal([]byte(`
+discrete_sensors:
+- pattern: "^FrontDoor$"
+  metric: door_switch
+  offset: 0
+- pattern: "^PS1Status$"
+  metric: chassis_intrusion
+`), &config)
+	if err != nil {
+		t.Fatalf("Unmarshal() call failed. Reason: %s", err)
+	}
+	dell, _ := vendorProfileByName("dell")
+	config = dell.apply(config)
+
+	for name, expect := range map[string]string{
+		"FrontDoor":    "door_switch",
+		"PS1Status":    "chassis_intrusion", // Module mappings come first.
+		"PS2Status":    "psu_presence",
+		"Intrusion":    "chassis_intrusion", // Dell profile.
+		"ChassisIntru": "chassis_intrusion",
+		"CPU1Status":   "",
+	} {
+		m, _ := discreteSensorMappingFor(config, name)
+		if m.Metric != expect {
+			t.Errorf("Discrete sensor mapping check failed for %s.\n Expect: %q\n Got: %q", name, expect, m.Metric)
+		}
+	}
+
+	for _, invalid := range []string{
+		"discrete_sensors: [{pattern: x, metric: unknown}]",
+		"discrete_sensors: [{pattern: x, metric: door_switch, offset: 15}]",
+		"discrete_sensors: [{pattern: '(', metric: door_switch}]",
+		"discrete_sensors: [{pattern: x, metric: door_switch, state: open}]",
+	} {
+		if err := yaml.Unmarshal([]byte(invalid), &IPMIConfig{}); err == nil {
+			t.Errorf("Invalid discrete sensor mapping was accepted: %s", invalid)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "Update the golden files of the end-to-end test.")
+
+// e2eDir holds the recorded ipmitool outputs of one fake BMC per vendor in a
+// directory named after the target, and the expected exposition of a scrape
+// of each target in <target>.prom.
+const e2eDir = "testdata/e2e"
+
+// scrapeExposition scrapes target through the /ipmi handler and returns the
+// exposition without the scrape and collector durations, which vary between
+// runs.
+func scrapeExposition(t *testing.T, url, target string) string {
+	resp, err := http.Get(url + "/ipmi?target=" + target)
+	if err != nil {
+		t.Fatalf("Scrape of %s failed. Reason: %s", target, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Scrape of %s failed.\n Expect: status 200\n Got: status %d", target, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Reading scrape of %s failed. Reason: %s", target, err)
+	}
+	return stripVariableMetrics(string(body))
+}
+
+// TestEndToEnd scrapes fake BMCs backed by recorded ipmitool outputs and
+// compares the complete exposition with golden files. Run with -update after
+// intended changes to the exposition and review the diff.
+func TestEndToEnd(t *testing.T) {
+	*mockDir = e2eDir
+	savedConf := safeConf
+	safeConf = NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	defer func() {
+		*mockDir = ""
+		safeConf = savedConf
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(remoteIPMIHandler))
+	defer server.Close()
+
+	targets, err := filepath.Glob(filepath.Join(e2eDir, "*", "bmc_info.txt"))
+	if err != nil || len(targets) == 0 {
+		t.Fatalf("No fake BMCs found in %s", e2eDir)
+	}
+	for _, path := range targets {
+		target := filepath.Base(filepath.Dir(path))
+		got := scrapeExposition(t, server.URL, target)
+		golden := filepath.Join(e2eDir, target+".prom")
+		if *updateGolden {
+			if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+				t.Fatalf("Updating %s failed. Reason: %s", golden, err)
+			}
+			continue
+		}
+		expect, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("Reading %s failed. Reason: %s", golden, err)
+		}
+		if got != string(expect) {
+			t.Errorf("Exposition check failed for %s.\n Expect:\n%s\n Got:\n%s", target, expect, got)
+		}
+	}
+}
+
+func TestRemoteIPMIHandlerTargets(t *testing.T) {
+	*mockDir = e2eDir
+	savedConf := safeConf
+	safeConf = NewSafeConfig(&Config{Modules: map[string]IPMIConfig{
+		"default": defaultConfig(),
+		"inband":  {Local: true, Collectors: []string{"power"}},
+	}})
+	defer func() {
+		*mockDir = ""
+		safeConf = savedConf
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(remoteIPMIHandler))
+	defer server.Close()
+
+	for query, expect := range map[string]int{
+		"":                          http.StatusBadRequest,
+		"target=dell":               http.StatusOK,
+		"module=inband":             http.StatusOK,
+		"module=inband&target=dell": http.StatusBadRequest,
+	} {
+		resp, err := http.Get(server.URL + "/ipmi?" + query)
+		if err != nil {
+			t.Fatalf("Scrape with %q failed. Reason: %s", query, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expect {
+			t.Errorf("Status check failed for %q.\n Expect: %d\n Got: %d", query, expect, resp.StatusCode)
+		}
+	}
+}
+
+func TestCollectorMetricsHandler(t *testing.T) {
+	*mockDir = e2eDir
+	savedConf := safeConf
+	safeConf = NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	savedFleet := fleet
+	fleet = &fleetSummary{targets: make(map[string]fleetEntry), maxAge: time.Minute}
+	defer func() {
+		*mockDir = ""
+		safeConf = savedConf
+		fleet = savedFleet
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(collectorMetricsHandler))
+	defer server.Close()
+
+	for path, expect := range map[string]struct {
+		status   int
+		contains string
+		omits    string
+	}{
+		"/metrics/power?target=dell":     {http.StatusOK, `ipmi_up{collector="power"} 1`, "ipmi_fru_info"},
+		"/metrics/inventory?target=dell": {http.StatusOK, "ipmi_fru_info", "ipmi_power_state"},
+		"/metrics/unknown?target=dell":   {http.StatusNotFound, "", ""},
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Scrape of %s failed. Reason: %s", path, err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != expect.status {
+			t.Errorf("Status check failed for %s.\n Expect: %d\n Got: %d", path, expect.status, resp.StatusCode)
+			continue
+		}
+		if !strings.Contains(string(body), expect.contains) || (expect.omits != "" && strings.Contains(string(body), expect.omits)) {
+			t.Errorf("Exposition check failed for %s.\n Expect: %q and no %q\n Got:\n%s", path, expect.contains, expect.omits, body)
+		}
+	}
+	if len(fleet.targets) != 0 {
+		t.Errorf("Scrapes of single collectors were recorded in the fleet summary: %v", fleet.targets)
+	}
+}
+
+func TestCollectorMetricsHandlerDisableLocal(t *testing.T) {
+	savedConf := safeConf
+	safeConf = NewSafeConfig(&Config{DisableLocal: true, Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	defer func() { safeConf = savedConf }()
+
+	w := httptest.NewRecorder()
+	collectorMetricsHandler(w, httptest.NewRequest("GET", "/metrics/power", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Status check failed for disabled local collection.\n Expect: %d\n Got: %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestTotalFailure(t *testing.T) {
+	*mockDir = e2eDir
+	savedConf := safeConf
+	strict := defaultConfig()
+	strict.Collectors = []string{"power"}
+	strict.TotalFailure = "error"
+	safeConf = NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig(), "strict": strict}})
+	defer func() {
+		*mockDir = ""
+		safeConf = savedConf
+	}()
+
+	server := httptest.NewServer(http.HandlerFunc(remoteIPMIHandler))
+	defer server.Close()
+
+	for path, expect := range map[string]int{
+		"/ipmi?target=dell&module=strict":    http.StatusOK,
+		"/ipmi?target=missing&module=strict": http.StatusInternalServerError,
+		"/ipmi?target=missing":               http.StatusOK,
+	} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Scrape of %s failed. Reason: %s", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != expect {
+			t.Errorf("Status check failed for %s.\n Expect: %d\n Got: %d", path, expect, resp.StatusCode)
+		}
+	}
+}
+
+func TestScrapeDeadline(t *testing.T) {
+	r := httptest.NewRequest("GET", "/ipmi", nil)
+	if deadline := scrapeDeadline(r); !deadline.IsZero() {
+		t.Errorf("Deadline without scrape timeout check failed.\n Expect: zero\n Got: %v", deadline)
+	}
+	r.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "10")
+	if left := time.Until(scrapeDeadline(r)); left < 9*time.Second || left > 10*time.Second {
+		t.Errorf("Deadline check failed.\n Expect: 10s from now\n Got: %v from now", left)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "time"
+
+// targetRetention is how long data kept in memory per target, e.g. sensor
+// histories or cached DNS results, outlives the last scrape of the target, so
+// that scrapes of arbitrary target names can't grow it without bounds.
+const targetRetention = 24 * time.Hour
+
+// expirySweepInterval is how often a targetExpiry looks for expired keys.
+const expirySweepInterval = time.Minute
+
+// targetExpiry tracks when the per-target entries of a map were last used, so
+// that the entries of targets that are no longer scraped can be removed. It
+// relies on the lock of the map it tracks.
+type targetExpiry struct {
+	used      map[string]time.Time
+	lastSweep time.Time
+}
+
+// touch records a use of key at now. It returns the keys that weren't used
+// within targetRetention, which the caller must remove from its map. Keys are
+// checked at most every expirySweepInterval.
+func (e *targetExpiry) touch(key string, now time.Time) []string {
+	if e.used == nil {
+		e.used = make(map[string]time.Time)
+	}
+	e.used[key] = now
+	if now.Sub(e.lastSweep) < expirySweepInterval {
+		return nil
+	}
+	e.lastSweep = now
+
+	var expired []string
+	cutoff := now.Add(-targetRetention)
+	for k, used := range e.used {
+		if used.Before(cutoff) {
+			expired = append(expired, k)
+			delete(e.used, k)
+		}
+	}
+	return expired
+}
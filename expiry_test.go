@@ -0,0 +1,28 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTargetExpiry(t *testing.T) {
+	var e targetExpiry
+	now := time.Now()
+	if expired := e.touch("10.0.0.1", now); expired != nil {
+		t.Errorf("Expired keys check failed.\n Expect: []\n Got: %v", expired)
+	}
+	e.touch("10.0.0.2", now.Add(targetRetention))
+
+	// Sweeps happen at most every expirySweepInterval.
+	if expired := e.touch("10.0.0.2", now.Add(targetRetention+time.Second)); expired != nil {
+		t.Errorf("Expired keys check failed within sweep interval.\n Expect: []\n Got: %v", expired)
+	}
+	expect := []string{"10.0.0.1"}
+	if expired := e.touch("10.0.0.2", now.Add(targetRetention+2*expirySweepInterval)); !reflect.DeepEqual(expired, expect) {
+		t.Errorf("Expired keys check failed.\n Expect: %v\n Got: %v", expect, expired)
+	}
+	if _, ok := e.used["10.0.0.1"]; ok {
+		t.Errorf("Expired key is still tracked")
+	}
+}
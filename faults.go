@@ -0,0 +1,31 @@
+package main
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var chassisFaultDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "chassis", "fault"),
+	"Summary of chassis faults reported by the chassis status and the sensors of a given type (0=ok, 1=fault).",
+	[]string{"type"},
+	nil,
+)
+
+// chassisFaults rolls the chassis status and sensor readings up into the
+// fault categories exposed by ipmi_chassis_fault.
+type chassisFaults map[string]bool
+
+var chassisFaultTypes = []string{"power", "cooling", "drive", "intrusion"}
+
+func (f chassisFaults) collect(ch chan<- prometheus.Metric) {
+	for _, faultType := range chassisFaultTypes {
+		var value float64
+		if f[faultType] {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			chassisFaultDesc,
+			prometheus.GaugeValue,
+			value,
+			faultType,
+		)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// fixtureCommandRegex matches the lines of a captured bundle that introduce
+// the output of an ipmitool call, e.g. "$ ipmitool -I lanplus sensor list".
+var fixtureCommandRegex = regexp.MustCompile(`^\$\s*(?:\S*/)?ipmitool\s+(.*?)\s*$`)
+
+// ipmitoolValueOptions are the options of ipmitool that take a value, and
+// are skipped with it when determining the command of a captured call.
+var ipmitoolValueOptions = []string{"-I", "-H", "-U", "-P", "-L", "-N", "-R", "-A", "-p", "-y", "-k", "-C", "-f", "-t", "-b", "-T", "-B", "-m", "-O", "-o", "-D", "-z"}
+
+// variableMetricPrefixes are the metrics whose values vary between runs and
+// are left out of golden files.
+var variableMetricPrefixes = []string{"ipmi_scrape_duration_seconds ", "ipmi_collector_duration_seconds{", "ipmi_scrape_phase_duration_seconds{"}
+
+// splitFixtureBundle splits a captured bundle, the transcript of a shell
+// session running ipmitool commands, into the output of every command.
+func splitFixtureBundle(r io.Reader) (map[string]string, error) {
+	outputs := make(map[string]string)
+	var command string
+	var output strings.Builder
+
+	flush := func() {
+		if command != "" {
+			outputs[command] = output.String()
+		}
+		output.Reset()
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := fixtureCommandRegex.FindStringSubmatch(line); match != nil {
+			flush()
+			command = fixtureFileName(strings.Fields(match[1]))
+			continue
+		}
+		output.WriteString(line + "\n")
+	}
+	flush()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(outputs) == 0 {
+		return nil, fmt.Errorf("no ipmitool calls found, expected lines like '$ ipmitool sensor list'")
+	}
+	return outputs, nil
+}
+
+// fixtureFileName returns the mock file name of the ipmitool call with args,
+// leaving out the connection options.
+func fixtureFileName(args []string) string {
+	var command []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case containsString(ipmitoolValueOptions, args[i]):
+			i++
+		case strings.HasPrefix(args[i], "-"):
+		default:
+			command = append(command, args[i])
+		}
+	}
+	return mockFileName(command)
+}
+
+// importFixture writes the outputs of a captured bundle as recorded outputs
+// of the fake BMC name below dir, and the exposition of a scrape of it with
+// the default module as golden file <dir>/<name>.prom.
+func importFixture(bundle io.Reader, dir, name string) error {
+	outputs, err := splitFixtureBundle(bundle)
+	if err != nil {
+		return err
+	}
+	if _, ok := outputs[mockFileName([]string{"bmc", "info"})]; !ok {
+		return fmt.Errorf("the bundle must contain the output of 'ipmitool bmc info'")
+	}
+	targetDir := mockTargetDir(dir, name)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return err
+	}
+	for file, output := range outputs {
+		if err := ioutil.WriteFile(filepath.Join(targetDir, file), []byte(output), 0644); err != nil {
+			return err
+		}
+	}
+
+	savedMockDir := *mockDir
+	*mockDir = dir
+	defer func() { *mockDir = savedMockDir }()
+	exposition, err := fixtureExposition(name)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, name+".prom"), []byte(exposition), 0644)
+}
+
+// fixtureExposition scrapes target with the default module and returns the
+// exposition without variable metrics.
+func fixtureExposition(target string) (string, error) {
+	config := NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector{target: target, module: "default", config: config})
+	families, err := registry.Gather()
+	if err != nil {
+		return "", err
+	}
+	var exposition strings.Builder
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(&exposition, family); err != nil {
+			return "", err
+		}
+	}
+	return stripVariableMetrics(exposition.String()), nil
+}
+
+// stripVariableMetrics removes the samples of variable metrics from an
+// exposition in the text format.
+func stripVariableMetrics(exposition string) string {
+	var result strings.Builder
+	for _, line := range strings.SplitAfter(exposition, "\n") {
+		variable := false
+		for _, prefix := range variableMetricPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				variable = true
+			}
+		}
+		if !variable {
+			result.WriteString(line)
+		}
+	}
+	return result.String()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixtureBundleText = `$ ipmitool -I lanplus -H 10.0.0.1 -U admin -P secret -E bmc info
+Device ID                 : 32
+Manufacturer Name         : Super Micro Computer Inc.
+$ /usr/bin/ipmitool -I lanplus -H 10.0.0.1 power status
+Chassis Power is on
+`
+
+func TestSplitFixtureBundle(t *testing.T) {
+	res, err := splitFixtureBundle(strings.NewReader(fixtureBundleText))
+	if err != nil {
+		t.Fatalf("splitFixtureBundle() call failed. Reason: %s", err)
+	}
+	expect := map[string]string{
+		"bmc_info.txt":     "Device ID                 : 32\nManufacturer Name         : Super Micro Computer Inc.\n",
+		"power_status.txt": "Chassis Power is on\n",
+	}
+	if len(res) != len(expect) {
+		t.Errorf("Bundle command count check failed.\n Expect: %d\n Got: %d (%v)", len(expect), len(res), res)
+	}
+	for file, output := range expect {
+		if res[file] != output {
+			t.Errorf("Bundle output check failed for %s.\n Expect: %q\n Got: %q", file, output, res[file])
+		}
+	}
+
+	if _, err := splitFixtureBundle(strings.NewReader("Chassis Power is on\n")); err == nil {
+		t.Errorf("Bundle without ipmitool calls was accepted")
+	}
+}
+
+func TestImportFixture(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipmitool_exporter")
+	if err != nil {
+		t.Fatalf("Creating fixture directory failed. Reason: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := importFixture(strings.NewReader(fixtureBundleText), dir, "imported"); err != nil {
+		t.Fatalf("importFixture() call failed. Reason: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "imported", "power_status.txt")); err != nil {
+		t.Errorf("Recorded output was not written. Reason: %s", err)
+	}
+	golden, err := ioutil.ReadFile(filepath.Join(dir, "imported.prom"))
+	if err != nil {
+		t.Fatalf("Golden file was not written. Reason: %s", err)
+	}
+	for _, expect := range []string{`ipmi_up{collector="power"} 1`, `ipmi_up{collector="sensor"} 0`} {
+		if !strings.Contains(string(golden), expect) {
+			t.Errorf("Golden file check failed.\n Expect: %s\n Got:\n%s", expect, golden)
+		}
+	}
+	if strings.Contains(string(golden), "\nipmi_scrape_duration_seconds ") {
+		t.Errorf("Golden file contains the scrape duration")
+	}
+}
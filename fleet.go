@@ -0,0 +1,192 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrapeSummary is filled in by the collectors during a scrape of a target
+// and recorded in the fleet summary afterwards.
+type scrapeSummary struct {
+	sync.Mutex
+	// up is true if all collectors succeeded.
+	up bool
+	// collectorsUp and collectorsDown count the collectors that succeeded
+	// and failed.
+	collectorsUp, collectorsDown int
+	// critical is true if any analog sensor is in a critical or
+	// non-recoverable state.
+	critical bool
+	// powerWatts is the instantaneous DCMI power reading, if hasPower.
+	powerWatts float64
+	hasPower   bool
+	// localInterfaceProblem is the first problem of the kernel IPMI driver
+	// detected on the local host, see localInterfaceProblem.
+	localInterfaceProblem string
+	// faults are the chassis faults reported by the collectors, nil if
+	// none of them checked for faults.
+	faults chassisFaults
+}
+
+func (s *scrapeSummary) markCritical() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	s.critical = true
+	s.Unlock()
+}
+
+func (s *scrapeSummary) recordCollector(up bool) {
+	s.Lock()
+	if up {
+		s.collectorsUp++
+	} else {
+		s.up = false
+		s.collectorsDown++
+	}
+	s.Unlock()
+}
+
+// allCollectorsFailed returns true if collectors ran and none of them
+// succeeded.
+func (s *scrapeSummary) allCollectorsFailed() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.collectorsDown > 0 && s.collectorsUp == 0
+}
+
+func (s *scrapeSummary) setPower(watts float64) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	s.powerWatts, s.hasPower = watts, true
+	s.Unlock()
+}
+
+// addFaults merges the chassis faults found by a collector into the roll-up
+// of the scrape.
+func (s *scrapeSummary) addFaults(faults chassisFaults) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	if s.faults == nil {
+		s.faults = make(chassisFaults)
+	}
+	for faultType, fault := range faults {
+		s.faults[faultType] = s.faults[faultType] || fault
+	}
+	s.Unlock()
+}
+
+func (s *scrapeSummary) setLocalInterfaceProblem(reason string) {
+	if s == nil || reason == "" {
+		return
+	}
+	s.Lock()
+	if s.localInterfaceProblem == "" {
+		s.localInterfaceProblem = reason
+	}
+	s.Unlock()
+}
+
+type fleetEntry struct {
+	summary *scrapeSummary
+	updated time.Time
+}
+
+// fleetSummary keeps the summary of the latest scrape of every target.
+type fleetSummary struct {
+	sync.Mutex
+	targets map[string]fleetEntry
+	// maxAge is how long a target is included after its last scrape.
+	maxAge time.Duration
+}
+
+var (
+	fleetTargetsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fleet", "targets"),
+		"Number of targets scraped recently by state (up if all collectors succeeded, down otherwise).",
+		[]string{"state"},
+		nil,
+	)
+
+	fleetCriticalTargetsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fleet", "targets_critical"),
+		"Number of targets scraped recently with any sensor in a critical or non-recoverable state.",
+		nil,
+		nil,
+	)
+
+	fleetPowerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fleet", "power_watts"),
+		"Sum of the latest instantaneous DCMI power readings of the targets scraped recently.",
+		nil,
+		nil,
+	)
+
+	fleetPowerTargetsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "fleet", "power_targets"),
+		"Number of targets contributing to ipmi_fleet_power_watts.",
+		nil,
+		nil,
+	)
+
+	fleet = &fleetSummary{targets: make(map[string]fleetEntry), maxAge: 5 * time.Minute}
+)
+
+// record stores the summary of a scrape of target.
+func (f *fleetSummary) record(target string, summary *scrapeSummary, now time.Time) {
+	f.Lock()
+	defer f.Unlock()
+	f.targets[target] = fleetEntry{summary: summary, updated: now}
+}
+
+// Describe implements Prometheus.Collector.
+func (f *fleetSummary) Describe(ch chan<- *prometheus.Desc) {
+	ch <- fleetTargetsDesc
+	ch <- fleetCriticalTargetsDesc
+	ch <- fleetPowerDesc
+	ch <- fleetPowerTargetsDesc
+}
+
+// Collect implements Prometheus.Collector. Targets that weren't scraped
+// within maxAge are forgotten.
+func (f *fleetSummary) Collect(ch chan<- prometheus.Metric) {
+	var up, down, critical, power, powerTargets float64
+	cutoff := time.Now().Add(-f.maxAge)
+
+	f.Lock()
+	for target, entry := range f.targets {
+		if entry.updated.Before(cutoff) {
+			delete(f.targets, target)
+			continue
+		}
+		s := entry.summary
+		s.Lock()
+		if s.up {
+			up++
+		} else {
+			down++
+		}
+		if s.critical {
+			critical++
+		}
+		if s.hasPower {
+			power += s.powerWatts
+			powerTargets++
+		}
+		s.Unlock()
+	}
+	f.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(fleetTargetsDesc, prometheus.GaugeValue, up, "up")
+	ch <- prometheus.MustNewConstMetric(fleetTargetsDesc, prometheus.GaugeValue, down, "down")
+	ch <- prometheus.MustNewConstMetric(fleetCriticalTargetsDesc, prometheus.GaugeValue, critical)
+	ch <- prometheus.MustNewConstMetric(fleetPowerDesc, prometheus.GaugeValue, power)
+	ch <- prometheus.MustNewConstMetric(fleetPowerTargetsDesc, prometheus.GaugeValue, powerTargets)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFleetSummary(t *testing.T) {
+	f := &fleetSummary{targets: make(map[string]fleetEntry), maxAge: time.Minute}
+	now := time.Now()
+
+	healthy := &scrapeSummary{up: true}
+	healthy.setPower(300)
+	critical := &scrapeSummary{up: true}
+	critical.markCritical()
+	critical.setPower(150)
+	f.record("10.0.0.1", healthy, now)
+	f.record("10.0.0.2", critical, now)
+	f.record("10.0.0.3", &scrapeSummary{}, now)
+	f.record("10.0.0.4", &scrapeSummary{up: true}, now.Add(-time.Hour))
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(f)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() call failed. Reason: %s", err)
+	}
+	res := make(map[string]float64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range m.GetLabel() {
+				name += "_" + label.GetValue()
+			}
+			res[name] = m.GetGauge().GetValue()
+		}
+	}
+	expect := map[string]float64{
+		"ipmi_fleet_targets_up":       2,
+		"ipmi_fleet_targets_down":     1,
+		"ipmi_fleet_targets_critical": 1,
+		"ipmi_fleet_power_watts":      450,
+		"ipmi_fleet_power_targets":    2,
+	}
+	for name, value := range expect {
+		if res[name] != value {
+			t.Errorf("Fleet summary check failed for %s.\n Expect: %v\n Got: %v", name, value, res[name])
+		}
+	}
+	if _, ok := f.targets["10.0.0.4"]; ok {
+		t.Errorf("Stale target was not removed from the fleet summary")
+	}
+}
+
+func TestScrapeSummaryFaults(t *testing.T) {
+	s := &scrapeSummary{}
+	s.addFaults(chassisFaults{"cooling": true, "power": false})
+	s.addFaults(chassisFaults{"power": true, "cooling": false})
+	for faultType, expect := range map[string]bool{"power": true, "cooling": true, "drive": false, "intrusion": false} {
+		if s.faults[faultType] != expect {
+			t.Errorf("Chassis fault check failed for %s.\n Expect: %v\n Got: %v", faultType, expect, s.faults[faultType])
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+)
+
+var graphiteInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// graphitePusher periodically sends the metrics of the local target and of
+// a fixed list of remote targets to Graphite using the plaintext protocol.
+type graphitePusher struct {
+	address string
+	prefix  string
+	targets []string
+	config  *SafeConfig
+}
+
+func (g *graphitePusher) run(interval time.Duration) {
+	for {
+		if err := g.push(); err != nil {
+			log.Errorf("Error pushing metrics to Graphite at %s: %s", g.address, err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (g *graphitePusher) push() error {
+	conn, err := net.DialTimeout("tcp", g.address, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	now := time.Now().Unix()
+	if err := g.write(w, "local", prometheus.DefaultGatherer, now); err != nil {
+		return err
+	}
+	for _, target := range g.targets {
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(collector{target: target, module: g.config.ModuleForTarget(target, ""), config: g.config})
+		if err := g.write(w, target, registry, now); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (g *graphitePusher) write(w *bufio.Writer, target string, gatherer prometheus.Gatherer, now int64) error {
+	mfs, err := gatherer.Gather()
+	if err != nil {
+		// Gather returns what it could collect along with the error.
+		log.Warnf("Error gathering metrics of %s for Graphite: %s", target, err)
+	}
+	prefix := graphiteSanitize(target)
+	if g.prefix != "" {
+		prefix = g.prefix + "." + prefix
+	}
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			path := prefix + "." + graphitePath(mf.GetName(), m.GetLabel())
+			for suffix, value := range graphiteValues(mf.GetType(), m) {
+				if math.IsNaN(value) || math.IsInf(value, 0) {
+					continue
+				}
+				if _, err := fmt.Fprintf(w, "%s%s %g %d\n", path, suffix, value, now); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// graphitePath builds a dotted Graphite path from a metric name and the
+// values of its labels, ordered by label name. Empty labels, e.g. the sensor
+// number of sensors read with `ipmitool sensor list`, are left out.
+func graphitePath(name string, labels []*dto.LabelPair) string {
+	sorted := make([]*dto.LabelPair, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].GetName() < sorted[j].GetName() })
+
+	parts := []string{graphiteSanitize(name)}
+	for _, l := range sorted {
+		if l.GetValue() == "" {
+			continue
+		}
+		parts = append(parts, graphiteSanitize(l.GetValue()))
+	}
+	return strings.Join(parts, ".")
+}
+
+func graphiteValues(t dto.MetricType, m *dto.Metric) map[string]float64 {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return map[string]float64{"": m.GetCounter().GetValue()}
+	case dto.MetricType_GAUGE:
+		return map[string]float64{"": m.GetGauge().GetValue()}
+	case dto.MetricType_SUMMARY:
+		return map[string]float64{
+			"_sum":   m.GetSummary().GetSampleSum(),
+			"_count": float64(m.GetSummary().GetSampleCount()),
+		}
+	case dto.MetricType_HISTOGRAM:
+		return map[string]float64{
+			"_sum":   m.GetHistogram().GetSampleSum(),
+			"_count": float64(m.GetHistogram().GetSampleCount()),
+		}
+	}
+	return map[string]float64{"": m.GetUntyped().GetValue()}
+}
+
+func graphiteSanitize(s string) string {
+	if s == "" {
+		return "_"
+	}
+	return graphiteInvalidChars.ReplaceAllString(s, "_")
+}
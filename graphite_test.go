@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGraphiteWrite(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "ipmi_temperature_celsius"}, []string{"name", "sensor_id", "entity"})
+	gauge.WithLabelValues("CPU1 Temp", "", "").Set(31)
+	registry.MustRegister(gauge)
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	g := &graphitePusher{prefix: "dc1"}
+	if err := g.write(w, "10.1.2.23", registry, 1500000000); err != nil {
+		t.Errorf("write() call failed. Reason: %s", err)
+	}
+	w.Flush()
+	expect := "dc1.10_1_2_23.ipmi_temperature_celsius.CPU1_Temp 31 1500000000\n"
+	if res := buf.String(); !strings.Contains(res, expect) {
+		t.Errorf("Wrong Graphite output.\n Expect: %s\n Got: %s", expect, res)
+	}
+}
@@ -0,0 +1,125 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	sensorsAppearedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensors", "appeared_total"),
+		"Number of sensors that appeared since the previous scrape of the target, counted since the exporter started.",
+		nil,
+		nil,
+	)
+
+	sensorsDisappearedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensors", "disappeared_total"),
+		"Number of sensors that disappeared since the previous scrape of the target, counted since the exporter started.",
+		nil,
+		nil,
+	)
+
+	sensorStateChangesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "sensor", "state_changes_total"),
+		"Number of times the state of the sensor changed between scrapes of the target, counted since the exporter started.",
+		[]string{"name"},
+		nil,
+	)
+
+	targetHistories = &scrapeHistory{targets: make(map[string]*targetHistory)}
+)
+
+// targetHistory holds what was observed in previous scrapes of a target.
+type targetHistory struct {
+	// sensors maps the sensors seen in the previous scrape to their state.
+	sensors      map[string]string
+	appeared     float64
+	disappeared  float64
+	stateChanges map[string]float64
+}
+
+// scrapeHistory keeps a targetHistory per target and module across scrapes,
+// as modules may read different sets of sensors of the same target.
+type scrapeHistory struct {
+	sync.Mutex
+	targets map[string]*targetHistory
+	expiry  targetExpiry
+}
+
+func (h *scrapeHistory) target(target ipmiTarget, now time.Time) *targetHistory {
+	key := target.host + "/" + target.module
+	for _, expired := range h.expiry.touch(key, now) {
+		delete(h.targets, expired)
+	}
+	th, ok := h.targets[key]
+	if !ok {
+		th = &targetHistory{stateChanges: make(map[string]float64)}
+		h.targets[key] = th
+	}
+	return th
+}
+
+// observeSensors records the sensors seen in a scrape of target, logs the
+// sensors that appeared or disappeared since the previous scrape, counts the
+// state changes of every sensor and emits the accumulated counts. Flapping
+// sensors show up as a quickly increasing ipmi_sensor_state_changes_total.
+func (h *scrapeHistory) observeSensors(ch chan<- prometheus.Metric, target ipmiTarget, results []sensorData) {
+	seen := make(map[string]string, len(results))
+	for _, data := range results {
+		seen[data.labelName()] = data.State
+	}
+
+	h.Lock()
+	th := h.target(target, time.Now())
+	if th.sensors != nil {
+		for name, state := range seen {
+			previous, ok := th.sensors[name]
+			if !ok {
+				log.Infof("Sensor %s appeared on %s", name, targetName(target.host))
+				th.appeared++
+			} else if state != previous {
+				log.Debugf("Sensor %s on %s changed state from %s to %s", name, targetName(target.host), previous, state)
+				th.stateChanges[name]++
+			}
+		}
+		for name := range th.sensors {
+			if _, ok := seen[name]; !ok {
+				log.Warnf("Sensor %s disappeared from %s", name, targetName(target.host))
+				th.disappeared++
+			}
+		}
+	}
+	th.sensors = seen
+	appeared, disappeared := th.appeared, th.disappeared
+	stateChanges := make(map[string]float64, len(seen))
+	for name := range seen {
+		stateChanges[name] = th.stateChanges[name]
+	}
+	h.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(
+		sensorsAppearedDesc,
+		prometheus.CounterValue,
+		appeared,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		sensorsDisappearedDesc,
+		prometheus.CounterValue,
+		disappeared,
+	)
+	for name, changes := range stateChanges {
+		ch <- prometheus.MustNewConstMetric(
+			sensorStateChangesDesc,
+			prometheus.CounterValue,
+			changes,
+			name,
+		)
+	}
+}
@@ -0,0 +1,65 @@
+//go:build !nosensor
+// +build !nosensor
+
+package main
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestObserveSensors(t *testing.T) {
+	h := &scrapeHistory{targets: make(map[string]*targetHistory)}
+	ch := make(chan prometheus.Metric, 16)
+	observe := func(names ...string) {
+		var results []sensorData
+		for _, name := range names {
+			results = append(results, sensorData{Name: name, State: "ok"})
+		}
+		h.observeSensors(ch, ipmiTarget{host: "localhost", module: "default"}, results)
+		for len(ch) > 0 {
+			<-ch
+		}
+	}
+
+	observe("CPU1Temp", "FAN1")
+	observe("CPU1Temp", "FAN1")
+	observe("CPU1Temp", "FAN2")
+	th := h.targets["localhost/default"]
+	if th.appeared != 1 || th.disappeared != 1 {
+		t.Errorf("Sensor change counts check failed.\n Expect: 1 appeared, 1 disappeared\n Got: %v appeared, %v disappeared", th.appeared, th.disappeared)
+	}
+}
+
+func TestObserveSensorStateChanges(t *testing.T) {
+	h := &scrapeHistory{targets: make(map[string]*targetHistory)}
+	ch := make(chan prometheus.Metric, 16)
+	for _, state := range []string{"ok", "nc", "ok", "ok", "nc"} {
+		h.observeSensors(ch, ipmiTarget{host: "localhost", module: "default"}, []sensorData{{Name: "FAN1", State: state}, {Name: "FAN2", State: "ok"}})
+		for len(ch) > 0 {
+			<-ch
+		}
+	}
+	th := h.targets["localhost/default"]
+	if th.stateChanges["FAN1"] != 3 || th.stateChanges["FAN2"] != 0 {
+		t.Errorf("Sensor state changes check failed.\n Expect: FAN1 3, FAN2 0\n Got: FAN1 %v, FAN2 %v", th.stateChanges["FAN1"], th.stateChanges["FAN2"])
+	}
+}
+
+func TestObserveSensorsModules(t *testing.T) {
+	h := &scrapeHistory{targets: make(map[string]*targetHistory)}
+	ch := make(chan prometheus.Metric, 16)
+	for i := 0; i < 2; i++ {
+		h.observeSensors(ch, ipmiTarget{host: "10.0.0.1", module: "temperatures"}, []sensorData{{Name: "CPU1Temp", State: "ok"}})
+		h.observeSensors(ch, ipmiTarget{host: "10.0.0.1", module: "fans"}, []sensorData{{Name: "FAN1", State: "ok"}})
+		for len(ch) > 0 {
+			<-ch
+		}
+	}
+	for key, th := range h.targets {
+		if th.appeared != 0 || th.disappeared != 0 {
+			t.Errorf("Sensor change counts check failed for %s.\n Expect: 0 appeared, 0 disappeared\n Got: %v appeared, %v disappeared", key, th.appeared, th.disappeared)
+		}
+	}
+}
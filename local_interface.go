@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"regexp"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ipmiDevices are the device nodes of the kernel IPMI driver that ipmitool
+// tries in turn with the open interface.
+var ipmiDevices = []string{"/dev/ipmi0", "/dev/ipmi/0", "/dev/ipmidev/0"}
+
+// localInterfaceProblems classify ipmitool errors caused by the kernel IPMI
+// driver rather than the BMC, in order of precedence.
+var localInterfaceProblems = []struct {
+	reason string
+	regex  *regexp.Regexp
+}{
+	{"device_open_failed", regexp.MustCompile(`(?i)could not open device|unable to open ipmi device`)},
+	{"timeout", regexp.MustCompile(`(?i)timed out|timeout|no data available|insufficient resources`)},
+}
+
+var localInterfaceHealthyDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "local_interface", "healthy"),
+	"'1' if the kernel IPMI driver of the local host worked during the scrape, '0' otherwise, with the reason (ok, device_missing, device_open_failed or timeout).",
+	[]string{"reason"},
+	nil,
+)
+
+// usesLocalInterface returns true if ipmitool talks to the BMC of target
+// through the kernel IPMI driver.
+func usesLocalInterface(target ipmiTarget) bool {
+	return targetIsLocal(target.host) && (target.config.Interface == "" || target.config.Interface == "open")
+}
+
+// localDeviceProblem returns "device_missing" if none of the IPMI device nodes
+// exist, e.g. because the ipmi_devintf module isn't loaded.
+func localDeviceProblem() string {
+	for _, device := range ipmiDevices {
+		if _, err := os.Stat(device); err == nil {
+			return ""
+		}
+	}
+	return "device_missing"
+}
+
+// localInterfaceProblem classifies the output of a failed ipmitool call on
+// the local interface, returning an empty string for failures that aren't
+// caused by the driver.
+func localInterfaceProblem(output string) string {
+	for _, problem := range localInterfaceProblems {
+		if problem.regex.MatchString(output) {
+			return problem.reason
+		}
+	}
+	return ""
+}
+
+func collectLocalInterfaceHealth(ch chan<- prometheus.Metric, reason string) {
+	healthy := reason == ""
+	if healthy {
+		reason = "ok"
+	}
+	ch <- prometheus.MustNewConstMetric(
+		localInterfaceHealthyDesc,
+		prometheus.GaugeValue,
+		boolToFloat(healthy),
+		reason,
+	)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLocalInterfaceProblem(t *testing.T) {
+	for output, expect := range map[string]string{
+		"Could not open device at /dev/ipmi0 or /dev/ipmi/0 or /dev/ipmidev/0: No such file or directory": "device_open_failed",
+		"Unable to send command: Connection timed out":                                                    "timeout",
+		"Get Device ID command failed: 0xc1 Invalid command":                                              "",
+	} {
+		if res := localInterfaceProblem(output); res != expect {
+			t.Errorf("Local interface problem check failed for %q.\n Expect: %q\n Got: %q", output, expect, res)
+		}
+	}
+}
+
+func TestUsesLocalInterface(t *testing.T) {
+	for target, expect := range map[*ipmiTarget]bool{
+		{host: targetLocal}: true,
+		{host: targetLocal, config: IPMIConfig{Interface: "open"}}:    true,
+		{host: targetLocal, config: IPMIConfig{Interface: "lanplus"}}: false,
+		{host: "10.0.0.1"}: false,
+	} {
+		if res := usesLocalInterface(*target); res != expect {
+			t.Errorf("Local interface check failed for %+v.\n Expect: %v\n Got: %v", *target, expect, res)
+		}
+	}
+}
+
+func TestSetLocalInterfaceProblem(t *testing.T) {
+	s := &scrapeSummary{}
+	s.setLocalInterfaceProblem("")
+	s.setLocalInterfaceProblem("device_missing")
+	s.setLocalInterfaceProblem("timeout")
+	if s.localInterfaceProblem != "device_missing" {
+		t.Errorf("Local interface problem check failed.\n Expect: device_missing\n Got: %q", s.localInterfaceProblem)
+	}
+}
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/log"
 	"github.com/prometheus/common/version"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
@@ -23,43 +29,261 @@ var (
 		"ipmitool.path",
 		"Path to IPMITool executables (default: rely on $PATH).",
 	).String()
+	execPrefix = kingpin.Flag(
+		"ipmitool.exec-prefix",
+		"Command to prefix ipmitool invocations with to run it as another user, e.g. 'sudo -n -u ipmi' or 'doas -n -u ipmi' (default: run ipmitool directly).",
+	).String()
+	ipmitoolEnv = kingpin.Flag(
+		"ipmitool.env",
+		"Environment variable (NAME=VALUE) to run ipmitool with. Can be repeated. ipmitool otherwise only gets PATH, LC_ALL=C and LANG=C, so that its output isn't translated.",
+	).Strings()
+	ipmitoolMaxRuntime = kingpin.Flag(
+		"ipmitool.max-runtime",
+		"Kill ipmitool processes of scrapes without a timeout, e.g. of the local host, after running this long (0 disables).",
+	).Default("5m").Duration()
+	mockDir = kingpin.Flag(
+		"ipmitool.mock-dir",
+		"Directory with recorded ipmitool outputs (<dir>/<target>/<command>.txt) to serve instead of running ipmitool, for testing.",
+	).String()
+	stateDir = kingpin.Flag(
+		"state.dir",
+		"Directory to persist per-target state in across restarts (default: disabled).",
+	).String()
+	stateRetention = kingpin.Flag(
+		"state.retention",
+		"How long to keep the state of targets that are no longer scraped.",
+	).Default("720h").Duration()
+	fleetMaxAge = kingpin.Flag(
+		"fleet.max-age",
+		"How long a target is included in the /fleet summary after its last scrape.",
+	).Default("5m").Duration()
+	samplerInterval = kingpin.Flag(
+		"sampler.interval",
+		"Interval to sample the local power consumption and inlet and exhaust temperatures at, e.g. 2s (default: disabled).",
+	).Duration()
+	samplerWindow = kingpin.Flag(
+		"sampler.window",
+		"Time window of the quantiles of the sampled readings, usually the scrape interval.",
+	).Default("1m").Duration()
+	eventsWebhookURL = kingpin.Flag(
+		"events.webhook-url",
+		"URL to POST events like chassis power state changes observed between scrapes to, as JSON (default: disabled).",
+	).String()
 	listenAddress = kingpin.Flag(
 		"web.listen-address",
 		"Address to listen on for web interface and telemetry.",
 	).Default(":9104").String()
+	timeoutOffset = kingpin.Flag(
+		"web.timeout-offset",
+		"Time subtracted from the scrape timeout sent by Prometheus to leave room for delivering the scrape.",
+	).Default("0.5s").Duration()
+	enableActions = kingpin.Flag(
+		"web.enable-actions",
+		"Enable endpoints changing the state of BMCs, like /actions/intrusion-reset. They need a listener with authentication.",
+	).Bool()
+	enableDebugArgs = kingpin.Flag(
+		"web.enable-debug-args",
+		"Enable the /debug/args endpoint previewing the ipmitool arguments of a target, which shows the user names and interfaces of modules.",
+	).Bool()
+	webConfigFile = kingpin.Flag(
+		"web.config.file",
+		"Path to a file configuring several listeners with their own endpoints, TLS and authentication, replacing web.listen-address.",
+	).String()
+	graphiteAddress = kingpin.Flag(
+		"graphite.address",
+		"Address (host:port) of a Graphite server to push metrics to (default: disabled).",
+	).String()
+	graphitePrefix = kingpin.Flag(
+		"graphite.prefix",
+		"Prefix of the Graphite metric paths.",
+	).Default("ipmi").String()
+	graphiteInterval = kingpin.Flag(
+		"graphite.interval",
+		"Interval between pushes to Graphite.",
+	).Default("1m").Duration()
+	graphiteTargets = kingpin.Flag(
+		"graphite.target",
+		"Remote target to push to Graphite in addition to the local metrics, using the module selected by the module rules. Can be repeated.",
+	).Strings()
+	metricTimestamps = kingpin.Flag(
+		"metrics.timestamps",
+		"Attach the time the data was collected from the BMC to collector metrics. Prometheus treats samples older than 5m as stale, so only enable this if scrapes finish well within that.",
+	).Bool()
+	targetLabels = kingpin.Flag(
+		"metrics.target-labels",
+		"Attach 'target' and 'module' labels to every IPMI metric.",
+	).Bool()
 
-	safeConf = &SafeConfig{
+	serveCommand = kingpin.Command("serve", "Serve the metrics (default).").Default()
 
-		C: &Config{},
-	}
+	fixtureCommand  = kingpin.Command("fixture", "Developer tools for the end-to-end test fixtures.")
+	fixtureImport   = fixtureCommand.Command("import", "Import a captured bundle of ipmitool outputs as fake BMC of the end-to-end test, and write its golden file.")
+	fixtureBundle   = fixtureImport.Arg("bundle", "Transcript of ipmitool calls, each starting with a line like '$ ipmitool sensor list' followed by its output. Must include 'bmc info'.").Required().ExistingFile()
+	fixtureName     = fixtureImport.Flag("name", "Name of the fake BMC, e.g. the vendor and model.").Required().String()
+	fixtureTestdata = fixtureImport.Flag("testdata", "Directory of the end-to-end test fixtures.").Default("testdata/e2e").String()
+
+	benchCommand     = kingpin.Command("bench", "Load test the exporter against a simulated fleet of fake BMCs served by the mock backend, using the modules of config.file.")
+	benchMockDir     = benchCommand.Flag("mock-dir", "Directory with the recorded outputs of fake BMCs (<dir>/<bmc>/<command>.txt), assigned to the simulated targets in turn.").Default("testdata/e2e").ExistingDir()
+	benchModule      = benchCommand.Flag("module", "Module to scrape the simulated targets with.").Default("default").String()
+	benchTargets     = benchCommand.Flag("targets", "Number of simulated targets.").Default("500").Int()
+	benchConcurrency = benchCommand.Flag("concurrency", "Number of scrapes running at the same time.").Default("50").Int()
+	benchDuration    = benchCommand.Flag("duration", "How long to scrape the simulated targets.").Default("1m").Duration()
+	benchLatency     = benchCommand.Flag("latency", "Simulated response time of every ipmitool call.").Default("0s").Duration()
+
+	snapshotCommand     = kingpin.Command("snapshot", "Collect the given targets once and write their metrics to a timestamped OpenMetrics file, for sites that can neither be scraped nor push metrics.")
+	snapshotTargets     = snapshotCommand.Arg("target", "Remote targets to collect.").Strings()
+	snapshotLocal       = snapshotCommand.Flag("local", "Collect the host the exporter runs on as well.").Bool()
+	snapshotModule      = snapshotCommand.Flag("module", "Module to collect all targets with, instead of the module selected by the module rules.").String()
+	snapshotDir         = snapshotCommand.Flag("output-dir", "Directory to write the snapshot and its import note to.").Default(".").ExistingDir()
+	snapshotCompress    = snapshotCommand.Flag("compress", "Compress the snapshot with gzip.").Bool()
+	snapshotConcurrency = snapshotCommand.Flag("concurrency", "Number of targets collected at the same time.").Default("10").Int()
+
+	safeConf = NewSafeConfig(&Config{})
 	reloadCh chan chan error
 )
 
 func remoteIPMIHandler(w http.ResponseWriter, r *http.Request) {
 	target := r.URL.Query().Get("target")
-	if target == "" {
-		http.Error(w, "'target' parameter must be specified", 400)
+	module := safeConf.ModuleForTarget(target, r.URL.Query().Get("module"))
+	if !safeConf.HasModule(module) {
+		http.Error(w, fmt.Sprintf("Unknown module %q", module), http.StatusBadRequest)
 		return
 	}
 
-	module := r.URL.Query().Get("module")
-	if module == "" {
-		module = "default"
+	// Local modules scrape the host the exporter runs on, all others need a
+	// remote target.
+	local := safeConf.ConfigForTarget(target, module).Local
+	if local && target != "" {
+		http.Error(w, fmt.Sprintf("Module %q is local, 'target' parameter must not be specified", module), http.StatusBadRequest)
+		return
 	}
-	if !safeConf.HasModule(module) {
-		http.Error(w, fmt.Sprintf("Unknown module %q", module), http.StatusBadRequest)
+	if !local && target == "" {
+		http.Error(w, "'target' parameter must be specified", 400)
 		return
 	}
 
 	log.Debugf("Scraping target '%s' with module '%s'", target, module)
 
 	registry := prometheus.NewRegistry()
-	remoteCollector := collector{target: target, module: module, config: safeConf}
-	registry.MustRegister(remoteCollector)
-	h := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	summary := &scrapeSummary{}
+	remoteCollector := collector{target: target, module: module, config: safeConf, deadline: scrapeDeadline(r), summary: summary}
+	targetRegisterer(registry, target, module).MustRegister(remoteCollector)
+	h := promhttp.HandlerFor(failingGatherer(registry, safeConf.ConfigForTarget(target, module), summary), promhttp.HandlerOpts{})
+	h.ServeHTTP(w, r)
+}
+
+// collectorMetricsHandler serves the metrics of a single collector or group
+// of collectors at /metrics/<name>, so that fast changing and inventory data
+// can be scraped by separate jobs with their own intervals and timeouts. The
+// local host is scraped unless the 'target' parameter is specified.
+func collectorMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	collectors, ok := collectorsByName(strings.TrimPrefix(r.URL.Path, "/metrics/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	target := r.URL.Query().Get("target")
+	module := safeConf.ModuleForTarget(target, r.URL.Query().Get("module"))
+	if module != "default" && !safeConf.HasModule(module) {
+		http.Error(w, fmt.Sprintf("Unknown module %q", module), http.StatusBadRequest)
+		return
+	}
+	if target != "" && safeConf.ConfigForTarget(target, module).Local {
+		http.Error(w, fmt.Sprintf("Module %q is local, 'target' parameter must not be specified", module), http.StatusBadRequest)
+		return
+	}
+	if targetIsLocal(target) && safeConf.Config().DisableLocal {
+		http.Error(w, "Local collection is disabled, 'target' parameter must be specified", http.StatusBadRequest)
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	summary := &scrapeSummary{}
+	c := collector{target: target, module: module, config: safeConf, collectors: collectors, deadline: scrapeDeadline(r), summary: summary}
+	targetRegisterer(registry, target, module).MustRegister(c)
+	h := promhttp.HandlerFor(failingGatherer(registry, safeConf.ConfigForTarget(target, module), summary), promhttp.HandlerOpts{})
 	h.ServeHTTP(w, r)
 }
 
+// failingGatherer wraps g to fail gathering if all collectors of the scrape
+// summarized in summary failed and the module asks for total_failure: error.
+// promhttp then responds with HTTP 500.
+func failingGatherer(g prometheus.Gatherer, config IPMIConfig, summary *scrapeSummary) prometheus.Gatherer {
+	if config.TotalFailure != "error" {
+		return g
+	}
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := g.Gather()
+		if err == nil && summary.allCollectorsFailed() {
+			err = fmt.Errorf("all collectors failed")
+		}
+		return families, err
+	})
+}
+
+// scrapeDeadline returns when the scrape timeout sent by Prometheus expires,
+// minus the timeout offset, or the zero time if no timeout was sent.
+func scrapeDeadline(r *http.Request) time.Time {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		log.Debugf("Ignoring invalid scrape timeout %q", header)
+		return time.Time{}
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > *timeoutOffset {
+		timeout -= *timeoutOffset
+	}
+	return time.Now().Add(timeout)
+}
+
+// argsPreviewHandler shows the ipmitool commands that a scrape of the given
+// target and module would run, with the password masked.
+func argsPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	module := safeConf.ModuleForTarget(target, r.URL.Query().Get("module"))
+	if module != "default" && !safeConf.HasModule(module) {
+		http.Error(w, fmt.Sprintf("Unknown module %q", module), http.StatusBadRequest)
+		return
+	}
+
+	config := safeConf.ConfigForTarget(target, module)
+	ipmiTarget := ipmiTarget{host: target, address: targetResolver.resolve(safeConf.Config(), target, time.Time{}), config: config}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	for _, name := range config.Collectors {
+		ipmiCollector, ok := registeredCollectors[name]
+		if !ok {
+			continue
+		}
+		for _, command := range ipmiCollector.Commands(config) {
+			args := maskedArgs(ipmitoolArgs(ipmiTarget, command))
+			fmt.Fprintf(w, "%s: ipmitool %s\n", name, strings.Join(args, " "))
+		}
+	}
+}
+
+// scrapeIntervalsHandler publishes the recommended scrape interval of every
+// collector by module as JSON, for generating scrape configurations that
+// scrape slowly changing data less often.
+func scrapeIntervalsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(safeConf.Config().ScrapeIntervals()); err != nil {
+		log.Errorf("Error encoding scrape intervals: %s", err)
+	}
+}
+
+// targetRegisterer wraps r to attach the target and module labels to all
+// metrics if requested on the command line.
+func targetRegisterer(r prometheus.Registerer, target, module string) prometheus.Registerer {
+	if !*targetLabels {
+		return r
+	}
+	return prometheus.WrapRegistererWith(prometheus.Labels{"target": targetName(target), "module": module}, r)
+}
+
 func updateConfiguration(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case "POST":
@@ -79,7 +303,63 @@ func main() {
 	log.AddFlags(kingpin.CommandLine)
 	kingpin.HelpFlag.Short('h')
 	kingpin.Version(version.Print("ipmitool_exporter"))
-	kingpin.Parse()
+	switch kingpin.Parse() {
+	case fixtureImport.FullCommand():
+		bundle, err := os.Open(*fixtureBundle)
+		if err != nil {
+			log.Fatalf("Error opening bundle: %s", err)
+		}
+		defer bundle.Close()
+		if err := importFixture(bundle, *fixtureTestdata, *fixtureName); err != nil {
+			log.Fatalf("Error importing bundle: %s", err)
+		}
+		log.Infof("Imported fake BMC %s, review %s", *fixtureName, filepath.Join(*fixtureTestdata, *fixtureName+".prom"))
+		return
+	case benchCommand.FullCommand():
+		if err := safeConf.ReloadConfig(*configFile); err != nil {
+			log.Fatalf("Error parsing config file: %s", err)
+		}
+		if *benchModule != "default" && !safeConf.HasModule(*benchModule) {
+			log.Fatalf("Unknown module %q", *benchModule)
+		}
+		if *benchTargets < 1 || *benchConcurrency < 1 {
+			log.Fatalf("The number of targets and the concurrency must be positive")
+		}
+		log.Infof("Scraping %d simulated targets with %d concurrent scrapes for %s", *benchTargets, *benchConcurrency, *benchDuration)
+		result, err := runBench(benchConfig{
+			mockDir:     *benchMockDir,
+			module:      *benchModule,
+			targets:     *benchTargets,
+			concurrency: *benchConcurrency,
+			duration:    *benchDuration,
+			latency:     *benchLatency,
+		}, safeConf)
+		if err != nil {
+			log.Fatalf("Error running load test: %s", err)
+		}
+		result.report(os.Stdout)
+		return
+	case snapshotCommand.FullCommand():
+		if err := safeConf.ReloadConfig(*configFile); err != nil {
+			log.Fatalf("Error parsing config file: %s", err)
+		}
+		if *snapshotModule != "" && *snapshotModule != "default" && !safeConf.HasModule(*snapshotModule) {
+			log.Fatalf("Unknown module %q", *snapshotModule)
+		}
+		path, err := runSnapshot(snapshotConfig{
+			targets:     *snapshotTargets,
+			local:       *snapshotLocal,
+			module:      *snapshotModule,
+			dir:         *snapshotDir,
+			compress:    *snapshotCompress,
+			concurrency: *snapshotConcurrency,
+		}, safeConf, time.Now())
+		if err != nil {
+			log.Fatalf("Error writing snapshot: %s", err)
+		}
+		log.Infof("Wrote snapshot %s, see %s.README for importing it", path, path)
+		return
+	}
 	log.Infoln("Starting ipmitool_exporter")
 
 	// Bail early if the config is bad.
@@ -87,7 +367,7 @@ func main() {
 		log.Fatalf("Error parsing config file: %s", err)
 	}
 
-	hup := make(chan os.Signal)
+	hup := make(chan os.Signal, 1)
 	reloadCh = make(chan chan error)
 	signal.Notify(hup, syscall.SIGHUP)
 	go func() {
@@ -108,12 +388,51 @@ func main() {
 		}
 	}()
 
+	if *stateDir != "" {
+		if err := os.MkdirAll(*stateDir, 0700); err != nil {
+			log.Fatalf("Error creating state directory: %s", err)
+		}
+		targetState.dir = *stateDir
+		go targetState.run(*stateRetention)
+	}
+
+	prometheus.MustRegister(dnsResolutionFailures, parseFailures)
+	prometheus.MustRegister(childProcessesRunning, childProcessesKilled, childProcessesOrphaned, zombieProcessesReaped)
+	go childProcesses.runJanitor(10 * time.Second)
+
 	localCollector := collector{target: targetLocal, module: "default", config: safeConf}
-	prometheus.MustRegister(&localCollector)
+	targetRegisterer(prometheus.DefaultRegisterer, targetLocal, "default").MustRegister(&localCollector)
+
+	if *samplerInterval > 0 {
+		startSampler(safeConf, *samplerInterval, *samplerWindow)
+	}
+
+	fleet.maxAge = *fleetMaxAge
+	fleetRegistry := prometheus.NewRegistry()
+	fleetRegistry.MustRegister(fleet)
+	fleetHandler := promhttp.HandlerFor(fleetRegistry, promhttp.HandlerOpts{})
+
+	http.Handle("/metrics", promhttp.Handler())                  // Regular metrics endpoint for local IPMI metrics.
+	http.HandleFunc("/metrics/", collectorMetricsHandler)        // Endpoints for single collectors.
+	http.HandleFunc("/ipmi", remoteIPMIHandler)                  // Endpoint to do IPMI scrapes.
+	http.HandleFunc("/-/reload", updateConfiguration)            // Endpoint to reload configuration.
+	http.HandleFunc("/scrape-intervals", scrapeIntervalsHandler) // Endpoint to publish scrape interval hints.
+	http.Handle("/fleet", fleetHandler)                          // Endpoint to summarize all targets.
+
+	var debugArgsLink string
+	if *enableDebugArgs {
+		http.HandleFunc("/debug/args", argsPreviewHandler) // Endpoint to preview ipmitool arguments.
+		debugArgsLink = `<p><a href="/debug/args">Preview ipmitool arguments</a></p>`
+	}
 
-	http.Handle("/metrics", promhttp.Handler())       // Regular metrics endpoint for local IPMI metrics.
-	http.HandleFunc("/ipmi", remoteIPMIHandler)       // Endpoint to do IPMI scrapes.
-	http.HandleFunc("/-/reload", updateConfiguration) // Endpoint to reload configuration.
+	if *enableActions {
+		if len(actionHandlers) == 0 {
+			log.Warnln("web.enable-actions is set, but the exporter was built without actions")
+		}
+		for path, handler := range actionHandlers {
+			http.HandleFunc(path, handler) // Endpoints changing the state of BMCs.
+		}
+	}
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
@@ -140,13 +459,41 @@ func main() {
 			</form>
 			<p><a href="/metrics">Local metrics</a></p>
 			<p><a href="/-/reload">Reload Config</a></p>
+			` + debugArgsLink + `
+			<p><a href="/scrape-intervals">Scrape interval hints</a></p>
+			<p><a href="/fleet">Fleet summary</a></p>
             </body>
             </html>`))
 	})
 
-	log.Infof("Listening on %s", *listenAddress)
-	err := http.ListenAndServe(*listenAddress, nil)
-	if err != nil {
-		log.Fatal(err)
+	if *graphiteAddress != "" {
+		pusher := &graphitePusher{
+			address: *graphiteAddress,
+			prefix:  *graphitePrefix,
+			targets: *graphiteTargets,
+			config:  safeConf,
+		}
+		log.Infof("Pushing metrics to Graphite at %s every %s", *graphiteAddress, *graphiteInterval)
+		go pusher.run(*graphiteInterval)
+	}
+
+	listeners := []listenerConfig{{Address: *listenAddress}}
+	if *webConfigFile != "" {
+		webConf, err := loadWebConfig(*webConfigFile)
+		if err != nil {
+			log.Fatalf("Error parsing web config file: %s", err)
+		}
+		if len(webConf.Listeners) == 0 {
+			log.Fatalf("No listeners in web config file %s", *webConfigFile)
+		}
+		listeners = webConf.Listeners
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l listenerConfig) {
+			errCh <- l.serve(http.DefaultServeMux)
+		}(l)
 	}
+	log.Fatal(<-errCh)
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// mockLatency delays every recorded output, to simulate the response time of
+// real BMCs in load tests.
+var mockLatency time.Duration
+
+// mockTargetDir returns the directory holding the recorded outputs of target
+// below dir: the escaped target name, or "local" for the local target.
+func mockTargetDir(dir, target string) string {
+	if targetIsLocal(target) {
+		return filepath.Join(dir, "local")
+	}
+	return filepath.Join(dir, escapeTargetName(target))
+}
+
+// mockFileName returns the name of the file holding the recorded output of
+// an ipmitool command, e.g. sensor_list.txt for "sensor list".
+func mockFileName(command []string) string {
+	return strings.Join(command, "_") + ".txt"
+}
+
+// mockOutput returns the output of command for target recorded in dir
+// instead of running ipmitool. A missing recording is treated like a failed
+// command, so that collectors without fixtures report ipmi_up 0.
+func mockOutput(dir, target string, command []string) (string, error) {
+	time.Sleep(mockLatency)
+	path := filepath.Join(mockTargetDir(dir, target), mockFileName(command))
+	output, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no recorded output for ipmitool %s: %s", strings.Join(command, " "), path)
+	}
+	return string(output), err
+}
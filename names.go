@@ -0,0 +1,19 @@
+package main
+
+import (
+	"strings"
+)
+
+// sanitizeName turns a name as printed by ipmitool, e.g. "CPU1 Temp", into
+// the value of a name label according to the name_sanitization policy of
+// the module: "strip" removes all whitespace ("CPU1Temp"), "underscore"
+// replaces it with underscores ("CPU1_Temp") and "preserve" keeps the name.
+func sanitizeName(policy, name string) string {
+	switch policy {
+	case "underscore":
+		return strings.Join(strings.Fields(name), "_")
+	case "preserve":
+		return strings.TrimSpace(name)
+	}
+	return strings.Join(strings.Fields(name), "")
+}
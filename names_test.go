@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSanitizeName(t *testing.T) {
+	for policy, expect := range map[string]string{
+		"strip":      "CPU1Temp",
+		"underscore": "CPU1_Temp",
+		"preserve":   "CPU1  Temp",
+	} {
+		if got := sanitizeName(policy, " CPU1  Temp "); got != expect {
+			t.Errorf("Name sanitization check failed for %s.\n Expect: %q\n Got: %q", policy, expect, got)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultCriticalCollectors are run first and never skipped, while all other
+// collectors are best-effort.
+var defaultCriticalCollectors = []string{"sensor", "power", "dcmi-power", "chassis"}
+
+// unknownCollectorDuration is assumed for collectors that haven't run against
+// a target yet.
+const unknownCollectorDuration = time.Second
+
+// maxConsecutiveSkips is how many scrapes in a row a best-effort collector is
+// skipped before it runs anyway, so that its duration is measured again
+// instead of a single slow run starving it for good.
+const maxConsecutiveSkips = 10
+
+var collectorSkippedDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "collector", "skipped"),
+	"'1' if the best-effort collector was skipped because it wouldn't have finished before the scrape timeout.",
+	[]string{"collector"},
+	nil,
+)
+
+// collectorOrder returns the collectors of config with the critical ones
+// first, keeping the configured order otherwise.
+func collectorOrder(config IPMIConfig) []string {
+	var critical, bestEffort []string
+	for _, name := range config.Collectors {
+		if containsString(config.CriticalCollectors, name) {
+			critical = append(critical, name)
+		} else {
+			bestEffort = append(bestEffort, name)
+		}
+	}
+	return append(critical, bestEffort...)
+}
+
+// durationTracker remembers how long every collector took on its last run
+// against a target, and how often it was skipped since.
+type durationTracker struct {
+	sync.Mutex
+	last    map[string]time.Duration
+	skipped map[string]int
+	expiry  targetExpiry
+}
+
+var collectorDurations = &durationTracker{last: make(map[string]time.Duration), skipped: make(map[string]int)}
+
+func (d *durationTracker) record(target, collector string, duration time.Duration) {
+	d.Lock()
+	defer d.Unlock()
+	for _, expired := range d.expiry.touch(target+"/"+collector, time.Now()) {
+		delete(d.last, expired)
+		delete(d.skipped, expired)
+	}
+	d.last[target+"/"+collector] = duration
+	delete(d.skipped, target+"/"+collector)
+}
+
+// skip counts a skipped run of collector against target. It returns false
+// once the collector was skipped maxConsecutiveSkips times in a row, in which
+// case it should run.
+func (d *durationTracker) skip(target, collector string) bool {
+	d.Lock()
+	defer d.Unlock()
+	key := target + "/" + collector
+	if d.skipped[key] >= maxConsecutiveSkips {
+		delete(d.skipped, key)
+		return false
+	}
+	d.skipped[key]++
+	return true
+}
+
+func (d *durationTracker) estimate(target, collector string) time.Duration {
+	d.Lock()
+	defer d.Unlock()
+	if duration, ok := d.last[target+"/"+collector]; ok {
+		return duration
+	}
+	return unknownCollectorDuration
+}
+
+// skipBestEffort returns true if the collector name is best-effort and would
+// likely not finish before the deadline of the scrape, judged by its last
+// run against the target, unless it was skipped too often in a row.
+func skipBestEffort(target ipmiTarget, name string, now time.Time) bool {
+	if target.deadline.IsZero() || containsString(target.config.CriticalCollectors, name) {
+		return false
+	}
+	if target.deadline.Sub(now) >= collectorDurations.estimate(target.host, name) {
+		return false
+	}
+	return collectorDurations.skip(target.host, name)
+}
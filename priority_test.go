@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCollectorOrder(t *testing.T) {
+	config := IPMIConfig{
+		Collectors:         []string{"fru", "sensor", "fwum", "power"},
+		CriticalCollectors: []string{"power", "sensor"},
+	}
+	expect := []string{"sensor", "power", "fru", "fwum"}
+	if res := collectorOrder(config); !reflect.DeepEqual(res, expect) {
+		t.Errorf("Collector order check failed.\n Expect: %v\n Got: %v", expect, res)
+	}
+}
+
+func TestSkipBestEffort(t *testing.T) {
+	now := time.Now()
+	target := ipmiTarget{
+		host:     "10.0.0.1",
+		config:   IPMIConfig{CriticalCollectors: []string{"sensor"}},
+		deadline: now.Add(3 * time.Second),
+	}
+	collectorDurations.record(target.host, "fru", 5*time.Second)
+	collectorDurations.record(target.host, "sensor", 5*time.Second)
+
+	for name, expect := range map[string]bool{
+		"fru":    true,  // took longer than the time left last time
+		"fwum":   false, // unknown duration fits
+		"sensor": false, // critical
+	} {
+		if res := skipBestEffort(target, name, now); res != expect {
+			t.Errorf("Skip check failed for %s.\n Expect: %v\n Got: %v", name, expect, res)
+		}
+	}
+
+	target.deadline = time.Time{}
+	if skipBestEffort(target, "fru", now) {
+		t.Errorf("Collector was skipped without a scrape timeout")
+	}
+}
+
+func TestSkipBestEffortStarvation(t *testing.T) {
+	now := time.Now()
+	target := ipmiTarget{host: "10.0.0.2", deadline: now.Add(3 * time.Second)}
+	collectorDurations.record(target.host, "fru", 8*time.Second)
+
+	for i := 0; i < maxConsecutiveSkips; i++ {
+		if !skipBestEffort(target, "fru", now) {
+			t.Fatalf("Collector ran after %d skips, expected %d", i, maxConsecutiveSkips)
+		}
+	}
+	if skipBestEffort(target, "fru", now) {
+		t.Errorf("Collector was skipped more than %d times in a row", maxConsecutiveSkips)
+	}
+	if !skipBestEffort(target, "fru", now) {
+		t.Errorf("Collector ran again right after its forced run")
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// processWaitDelay is how long the exporter waits for the output of an exited
+// or killed ipmitool process, and how long a process may outlive its deadline before
+// the janitor kills it. Processes started through ipmitool.exec-prefix may
+// leave ipmitool running after the prefix command was killed, holding its
+// output open.
+const processWaitDelay = 5 * time.Second
+
+var (
+	childProcessesKilled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "child_processes_killed_total",
+		Help:      "Number of ipmitool processes killed, by reason (deadline if the scrape timed out, janitor if the process outlived its deadline).",
+	}, []string{"reason"})
+
+	childProcessesOrphaned = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "child_processes_orphaned_total",
+		Help:      "Number of ipmitool processes whose output was abandoned, because processes they started kept it open after they ended.",
+	})
+
+	zombieProcessesReaped = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "zombie_processes_reaped_total",
+		Help:      "Number of exited processes reparented to the exporter that the janitor reaped.",
+	})
+
+	childProcessesRunning = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "child_processes",
+		Help:      "Number of ipmitool processes currently running.",
+	}, func() float64 {
+		return float64(childProcesses.count())
+	})
+
+	childProcesses = &processTracker{children: make(map[int]*childProcess)}
+)
+
+func init() {
+	for _, reason := range []string{"deadline", "janitor"} {
+		childProcessesKilled.WithLabelValues(reason)
+	}
+}
+
+type childProcess struct {
+	cmd *exec.Cmd
+	// deadline is when the process should have finished.
+	deadline time.Time
+	// killed is when the process was killed, if it was.
+	killed time.Time
+}
+
+// processTracker keeps track of the running ipmitool processes, so that the
+// janitor can kill those outliving their deadline and tell them apart from
+// processes that were reparented to the exporter.
+type processTracker struct {
+	sync.Mutex
+	children map[int]*childProcess
+}
+
+func (t *processTracker) count() int {
+	t.Lock()
+	defer t.Unlock()
+	return len(t.children)
+}
+
+// run runs cmd, kills it once ctx is done and returns its combined output.
+// It expects cmd to finish by deadline. Processes without a deadline are
+// expected to finish within maxRuntime, if set.
+func (t *processTracker) run(ctx context.Context, cmd *exec.Cmd, deadline time.Time, maxRuntime time.Duration) ([]byte, error) {
+	child := &childProcess{cmd: cmd, deadline: deadline}
+	if deadline.IsZero() && maxRuntime > 0 {
+		child.deadline = time.Now().Add(maxRuntime)
+	}
+
+	// Read the output through a pipe of our own rather than letting Wait
+	// copy it, so that processes started by ipmitool keeping it open can't
+	// block the scrape after ipmitool exited.
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer pr.Close()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+
+	// Hold the lock until the process is tracked, so that the janitor
+	// doesn't mistake it for a reparented process if it exits right away.
+	t.Lock()
+	err = cmd.Start()
+	pw.Close()
+	if err != nil {
+		t.Unlock()
+		return nil, err
+	}
+	pid := cmd.Process.Pid
+	t.children[pid] = child
+	t.Unlock()
+
+	var output bytes.Buffer
+	drained := make(chan struct{})
+	go func() {
+		io.Copy(&output, pr)
+		close(drained)
+	}()
+
+	exited, watched := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(watched)
+		select {
+		case <-ctx.Done():
+			t.kill(child, "deadline")
+		case <-exited:
+		}
+	}()
+	err = cmd.Wait()
+	close(exited)
+	<-watched
+
+	t.Lock()
+	delete(t.children, pid)
+	t.Unlock()
+
+	select {
+	case <-drained:
+	case <-time.After(processWaitDelay):
+		log.Warnf("Output of ipmitool process %d was abandoned, processes it started may still be running", pid)
+		childProcessesOrphaned.Inc()
+		pr.Close()
+		<-drained
+	}
+	return output.Bytes(), err
+}
+
+// kill kills the process of child unless it was killed before.
+func (t *processTracker) kill(child *childProcess, reason string) {
+	t.Lock()
+	if !child.killed.IsZero() {
+		t.Unlock()
+		return
+	}
+	child.killed = time.Now()
+	t.Unlock()
+
+	if err := child.cmd.Process.Kill(); err != nil {
+		log.Debugf("Killing ipmitool process %d failed: %s", child.cmd.Process.Pid, err)
+		return
+	}
+	childProcessesKilled.WithLabelValues(reason).Inc()
+}
+
+// sweep kills the processes that outlived their deadline by more than
+// processWaitDelay, and reaps exited processes reparented to the exporter.
+func (t *processTracker) sweep(now time.Time) {
+	var overdue []*childProcess
+	t.Lock()
+	for _, child := range t.children {
+		if !child.deadline.IsZero() && child.killed.IsZero() && now.After(child.deadline.Add(processWaitDelay)) {
+			overdue = append(overdue, child)
+		}
+	}
+	t.Unlock()
+	for _, child := range overdue {
+		log.Warnf("Killing ipmitool process %d, which outlived its deadline", child.cmd.Process.Pid)
+		t.kill(child, "janitor")
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	reaped := reapZombies(func(pid int) bool {
+		_, ok := t.children[pid]
+		return ok
+	})
+	zombieProcessesReaped.Add(float64(reaped))
+}
+
+// runJanitor sweeps the child processes every interval.
+func (t *processTracker) runJanitor(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		t.sweep(time.Now())
+	}
+}
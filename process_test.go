@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func killedCount(t *testing.T, reason string) float64 {
+	var m dto.Metric
+	if err := childProcessesKilled.WithLabelValues(reason).Write(&m); err != nil {
+		t.Fatalf("Write() call failed. Reason: %s", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestProcessTrackerOutput(t *testing.T) {
+	tracker := &processTracker{children: make(map[int]*childProcess)}
+	output, err := tracker.run(context.Background(), exec.Command("sh", "-c", "echo out; echo err >&2"), time.Time{}, 0)
+	if err != nil {
+		t.Fatalf("run() call failed. Reason: %s", err)
+	}
+	if string(output) != "out\nerr\n" {
+		t.Errorf("Process output check failed.\n Expect: %q\n Got: %q", "out\nerr\n", output)
+	}
+	if n := tracker.count(); n != 0 {
+		t.Errorf("Child process count check failed.\n Expect: 0\n Got: %d", n)
+	}
+}
+
+func TestProcessTrackerDeadline(t *testing.T) {
+	tracker := &processTracker{children: make(map[int]*childProcess)}
+	before := killedCount(t, "deadline")
+
+	deadline := time.Now().Add(100 * time.Millisecond)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if _, err := tracker.run(ctx, exec.Command("sleep", "5"), deadline, 0); err == nil {
+		t.Errorf("Process outliving its deadline succeeded")
+	}
+	if got := killedCount(t, "deadline") - before; got != 1 {
+		t.Errorf("Killed processes check failed.\n Expect: 1\n Got: %v", got)
+	}
+	if n := tracker.count(); n != 0 {
+		t.Errorf("Child process count check failed.\n Expect: 0\n Got: %d", n)
+	}
+}
+
+func TestProcessTrackerJanitor(t *testing.T) {
+	tracker := &processTracker{children: make(map[int]*childProcess)}
+	before := killedCount(t, "janitor")
+
+	done := make(chan error)
+	go func() {
+		_, err := tracker.run(context.Background(), exec.Command("sleep", "5"), time.Time{}, time.Millisecond)
+		done <- err
+	}()
+	for tracker.count() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	tracker.sweep(time.Now())
+	if got := killedCount(t, "janitor") - before; got != 0 {
+		t.Errorf("Process within its grace period was killed")
+	}
+	tracker.sweep(time.Now().Add(processWaitDelay + time.Second))
+	if err := <-done; err == nil {
+		t.Errorf("Process killed by the janitor succeeded")
+	}
+	if got := killedCount(t, "janitor") - before; got != 1 {
+		t.Errorf("Killed processes check failed.\n Expect: 1\n Got: %v", got)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+)
+
+// rawCommand is a raw IPMI command run by the raw collector, and how to turn
+// its response into the value of a metric. The value is either decoded from
+// the response bytes at ByteOffset, or extracted with Regex.
+type rawCommand struct {
+	// Metric is the full name of the metric, e.g. "ipmi_raw_fan_duty_percent".
+	Metric string            `yaml:"metric"`
+	Help   string            `yaml:"help"`
+	Labels map[string]string `yaml:"labels"`
+	// Command holds the bytes passed to `ipmitool raw`: network function,
+	// command and data, e.g. ["0x30", "0x70", "0x66", "0x00", "0x00"].
+	Command []string `yaml:"command"`
+
+	// ByteOffset and ByteLength select the response bytes forming the value,
+	// in ByteOrder ("little", as used by IPMI, or "big").
+	ByteOffset *int   `yaml:"byte_offset"`
+	ByteLength int    `yaml:"byte_length"`
+	ByteOrder  string `yaml:"byte_order"`
+	// Regex is matched against the response bytes as printed by ipmitool,
+	// separated by single spaces, e.g. "01 2a 00". The bytes captured by the
+	// first group are decoded like a byte range, and the value is 1 if the
+	// response matches and 0 otherwise without group.
+	Regex string `yaml:"regex"`
+	// Scale multiplies the decoded value, e.g. 0.01 for readings in 1/100.
+	Scale float64 `yaml:"scale"`
+
+	regexp *regexp.Regexp
+	desc   *prometheus.Desc
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *rawCommand) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	*c = rawCommand{ByteLength: 1, ByteOrder: "little", Scale: 1, Help: "Reading of a raw IPMI command configured in the module."}
+	type plain rawCommand
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if err := checkOverflow(c.XXX, "raw_commands"); err != nil {
+		return err
+	}
+	if !model.IsValidMetricName(model.LabelValue(c.Metric)) {
+		return fmt.Errorf("invalid raw_commands metric name: %q", c.Metric)
+	}
+	for name := range c.Labels {
+		if !model.LabelName(name).IsValid() {
+			return fmt.Errorf("invalid raw_commands label name in %s: %q", c.Metric, name)
+		}
+	}
+	if len(c.Command) < 2 {
+		return fmt.Errorf("raw_commands command of %s needs at least network function and command", c.Metric)
+	}
+	for _, b := range c.Command {
+		if _, err := strconv.ParseUint(b, 0, 8); err != nil {
+			return fmt.Errorf("invalid raw_commands command byte of %s: %q", c.Metric, b)
+		}
+	}
+	if (c.ByteOffset == nil) == (c.Regex == "") {
+		return fmt.Errorf("raw_commands entry %s needs either byte_offset or regex", c.Metric)
+	}
+	if c.ByteOffset != nil && *c.ByteOffset < 0 {
+		return fmt.Errorf("invalid raw_commands byte_offset of %s: %d", c.Metric, *c.ByteOffset)
+	}
+	if c.ByteLength < 1 || c.ByteLength > 8 {
+		return fmt.Errorf("invalid raw_commands byte_length of %s: %d (must be 1-8)", c.Metric, c.ByteLength)
+	}
+	if c.ByteOrder != "little" && c.ByteOrder != "big" {
+		return fmt.Errorf("unknown raw_commands byte_order of %s: %s (must be little or big)", c.Metric, c.ByteOrder)
+	}
+	if c.Regex != "" {
+		var err error
+		if c.regexp, err = regexp.Compile(c.Regex); err != nil {
+			return fmt.Errorf("invalid raw_commands regex of %s: %s", c.Metric, err)
+		}
+	}
+	c.desc = prometheus.NewDesc(c.Metric, c.Help, nil, c.Labels)
+	return nil
+}
+
+// value decodes the value of the command from its response bytes. It returns
+// NaN if the response is too short or unparsable.
+func (c rawCommand) value(response []byte) float64 {
+	if c.regexp != nil {
+		m := c.regexp.FindStringSubmatch(formatRawBytes(response))
+		if m == nil {
+			return 0
+		}
+		if len(m) < 2 {
+			return 1
+		}
+		captured, err := parseRawBytes(m[1])
+		if err != nil || len(captured) == 0 || len(captured) > 8 {
+			return math.NaN()
+		}
+		return float64(decodeRawBytes(captured, c.ByteOrder)) * c.Scale
+	}
+	end := *c.ByteOffset + c.ByteLength
+	if end > len(response) {
+		return math.NaN()
+	}
+	return float64(decodeRawBytes(response[*c.ByteOffset:end], c.ByteOrder)) * c.Scale
+}
+
+// parseRawBytes parses the output of `ipmitool raw`, hex bytes separated by
+// whitespace and newlines.
+func parseRawBytes(output string) ([]byte, error) {
+	var result []byte
+	for _, field := range strings.Fields(output) {
+		b, err := strconv.ParseUint(field, 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid byte in raw response: %q", field)
+		}
+		result = append(result, byte(b))
+	}
+	return result, nil
+}
+
+func formatRawBytes(response []byte) string {
+	fields := make([]string, len(response))
+	for i, b := range response {
+		fields[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(fields, " ")
+}
+
+func decodeRawBytes(b []byte, order string) uint64 {
+	var value uint64
+	for i := range b {
+		if order == "little" {
+			value |= uint64(b[i]) << (8 * uint(i))
+		} else {
+			value = value<<8 | uint64(b[i])
+		}
+	}
+	return value
+}
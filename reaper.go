@@ -0,0 +1,69 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/common/log"
+)
+
+// reapZombies reaps the exited child processes of the exporter that aren't
+// tracked, and returns their number. Such processes are left behind by
+// ipmitool or the exec prefix if the exporter runs as PID 1, e.g. in a
+// container without an init process, which inherits orphaned processes.
+// Tracked processes are reaped by os/exec and must be skipped.
+func reapZombies(tracked func(pid int) bool) int {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return 0
+	}
+	self := os.Getpid()
+	var reaped int
+	for _, path := range stats {
+		pid, state, ppid, ok := readProcStat(path)
+		if !ok || ppid != self || state != "Z" || tracked(pid) {
+			continue
+		}
+		var status syscall.WaitStatus
+		if wpid, err := syscall.Wait4(pid, &status, syscall.WNOHANG, nil); err != nil || wpid != pid {
+			continue
+		}
+		log.Debugf("Reaped zombie process %d", pid)
+		reaped++
+	}
+	return reaped
+}
+
+// readProcStat returns the PID, state and parent PID from a /proc/<pid>/stat
+// file. The command name in parentheses may contain spaces.
+func readProcStat(path string) (pid int, state string, ppid int, ok bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, "", 0, false
+	}
+	stat := string(data)
+	end := strings.LastIndex(stat, ")")
+	if end < 0 {
+		return 0, "", 0, false
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return 0, "", 0, false
+	}
+	pid, err = strconv.Atoi(strings.Fields(stat)[0])
+	if err != nil {
+		return 0, "", 0, false
+	}
+	ppid, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, "", 0, false
+	}
+	return pid, fields[0], ppid, true
+}
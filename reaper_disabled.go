@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+// reapZombies is only implemented on Linux, where the exporter commonly runs
+// as PID 1 in containers.
+func reapZombies(tracked func(pid int) bool) int {
+	return 0
+}
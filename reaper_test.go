@@ -0,0 +1,19 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadProcStat(t *testing.T) {
+	pid, state, ppid, ok := readProcStat("/proc/self/stat")
+	if !ok || pid != os.Getpid() || ppid != os.Getppid() || state == "" {
+		t.Errorf("Process stat check failed.\n Expect: %d, <state>, %d\n Got: %d, %q, %d (ok: %v)", os.Getpid(), os.Getppid(), pid, state, ppid, ok)
+	}
+	if _, _, _, ok := readProcStat("/proc/nonexistent/stat"); ok {
+		t.Errorf("Missing process stat was read")
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	dnsResolutionFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dns_resolution_failures_total",
+		Help:      "Number of failed DNS resolutions of remote targets.",
+	})
+
+	targetResolver = &resolver{cache: make(map[string]resolvedAddress)}
+)
+
+// dnsLookupTimeout bounds the DNS lookups of remote targets that aren't
+// scraped with a deadline.
+const dnsLookupTimeout = 5 * time.Second
+
+type resolvedAddress struct {
+	address string
+	expires time.Time
+}
+
+// resolver resolves remote target names to addresses once per TTL, so that
+// ipmitool doesn't need to query DNS for every command.
+type resolver struct {
+	sync.Mutex
+	cache  map[string]resolvedAddress
+	expiry targetExpiry
+}
+
+// resolve returns the address ipmitool should connect to for host. Static
+// overrides from the config take precedence. If caching is disabled or the
+// lookup fails without a previous result, host is returned unchanged and left
+// to ipmitool to resolve. The lookup is aborted at deadline, or after
+// dnsLookupTimeout if deadline is zero.
+func (r *resolver) resolve(config *Config, host string, deadline time.Time) string {
+	if targetIsLocal(host) {
+		return host
+	}
+	if address, ok := config.Hosts[host]; ok {
+		return address
+	}
+	if config.DNSCacheTTL <= 0 || net.ParseIP(host) != nil {
+		return host
+	}
+
+	r.Lock()
+	for _, expired := range r.expiry.touch(host, time.Now()) {
+		delete(r.cache, expired)
+	}
+	cached, ok := r.cache[host]
+	r.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.address
+	}
+
+	if deadline.IsZero() {
+		deadline = time.Now().Add(dnsLookupTimeout)
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	addresses, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil || len(addresses) == 0 {
+		dnsResolutionFailures.Inc()
+		if ok {
+			log.Warnf("Failed to resolve %s, using previous address %s: %v", host, cached.address, err)
+			return cached.address
+		}
+		log.Warnf("Failed to resolve %s: %v", host, err)
+		return host
+	}
+
+	r.Lock()
+	r.cache[host] = resolvedAddress{address: addresses[0], expires: time.Now().Add(config.DNSCacheTTL)}
+	r.Unlock()
+	return addresses[0]
+}
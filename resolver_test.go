@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	r := &resolver{cache: make(map[string]resolvedAddress)}
+	config := &Config{
+		Hosts:       map[string]string{"bmc1.example.com": "10.0.0.1"},
+		DNSCacheTTL: time.Minute,
+	}
+	for host, expect := range map[string]string{
+		"bmc1.example.com": "10.0.0.1",
+		"10.0.0.2":         "10.0.0.2",
+		targetLocal:        targetLocal,
+	} {
+		if res := r.resolve(config, host, time.Time{}); res != expect {
+			t.Errorf("Wrong address for target '%s'.\n Expect: %s\n Got: %s", host, expect, res)
+		}
+	}
+
+	r.cache["bmc2.example.com"] = resolvedAddress{address: "10.0.0.3", expires: time.Now().Add(time.Minute)}
+	if res := r.resolve(config, "bmc2.example.com", time.Time{}); res != "10.0.0.3" {
+		t.Errorf("Cached address not used.\n Expect: 10.0.0.3\n Got: %s", res)
+	}
+	config.DNSCacheTTL = 0
+	if res := r.resolve(config, "bmc2.example.com", time.Time{}); res != "bmc2.example.com" {
+		t.Errorf("Target resolved with caching disabled.\n Got: %s", res)
+	}
+}
+
+func TestResolveDeadline(t *testing.T) {
+	r := &resolver{cache: make(map[string]resolvedAddress)}
+	config := &Config{DNSCacheTTL: time.Minute}
+	before := time.Now()
+	if res := r.resolve(config, "bmc3.example.com", before.Add(-time.Second)); res != "bmc3.example.com" {
+		t.Errorf("Target resolved after the deadline.\n Got: %s", res)
+	}
+	if elapsed := time.Since(before); elapsed > time.Second {
+		t.Errorf("Resolution after the deadline took %s", elapsed)
+	}
+}
@@ -0,0 +1,142 @@
+//go:build !nosensor && !nodcmi
+// +build !nosensor,!nodcmi
+
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// samplerObjectives are the quantiles of the sampled readings.
+var samplerObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+
+// sampler reads the power consumption and the inlet and exhaust temperatures
+// of the local host much more often than it is scraped, and exposes their
+// distribution over a sliding window as summaries.
+type sampler struct {
+	host         string
+	config       *SafeConfig
+	power        prometheus.Summary
+	temperatures *prometheus.SummaryVec
+	errors       prometheus.Counter
+}
+
+func newSampler(config *SafeConfig, window time.Duration) *sampler {
+	return &sampler{
+		host:   targetLocal,
+		config: config,
+		power: prometheus.NewSummary(prometheus.SummaryOpts{
+			Namespace:  namespace,
+			Subsystem:  "sampled",
+			Name:       "power_watts",
+			Help:       "Distribution of the instantaneous DCMI power readings sampled in the last sampler window.",
+			Objectives: samplerObjectives,
+			MaxAge:     window,
+		}),
+		temperatures: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace:  namespace,
+			Subsystem:  "sampled",
+			Name:       "temperature_celsius",
+			Help:       "Distribution of the inlet and exhaust temperatures sampled in the last sampler window.",
+			Objectives: samplerObjectives,
+			MaxAge:     window,
+		}, []string{"name"}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sampler",
+			Name:      "errors_total",
+			Help:      "Number of samples that failed.",
+		}),
+	}
+}
+
+// Describe implements Prometheus.Collector.
+func (s *sampler) Describe(ch chan<- *prometheus.Desc) {
+	s.power.Describe(ch)
+	s.temperatures.Describe(ch)
+	s.errors.Describe(ch)
+}
+
+// Collect implements Prometheus.Collector.
+func (s *sampler) Collect(ch chan<- prometheus.Metric) {
+	s.power.Collect(ch)
+	s.temperatures.Collect(ch)
+	s.errors.Collect(ch)
+}
+
+// sample takes one sample of all readings, unless local collection is
+// disabled.
+func (s *sampler) sample() {
+	if targetIsLocal(s.host) && s.config.Config().DisableLocal {
+		return
+	}
+	config := s.config.ConfigForTarget(s.host, "default")
+	target := ipmiTarget{host: s.host, config: config}
+
+	if err := s.samplePower(target); err != nil {
+		log.Debugf("Failed to sample power of %s: %s", targetName(s.host), err)
+		s.errors.Inc()
+	}
+	if err := s.sampleTemperatures(target); err != nil {
+		log.Debugf("Failed to sample temperatures of %s: %s", targetName(s.host), err)
+		s.errors.Inc()
+	}
+}
+
+func (s *sampler) samplePower(target ipmiTarget) error {
+	output, err := ipmitoolOutput(target, dcmiPowerReadingCommand(target.config))
+	if err != nil {
+		return err
+	}
+	readings, err := splitDcmiPowerOutput(output)
+	if err != nil {
+		return err
+	}
+	for _, data := range readings {
+		if data.Name == "Instantaneous power consumption" {
+			s.power.Observe(data.Value)
+		}
+	}
+	return nil
+}
+
+func (s *sampler) sampleTemperatures(target ipmiTarget) error {
+	output, err := ipmitoolOutput(target, []string{"sdr", "type", "Temperature"})
+	if err != nil {
+		return err
+	}
+	readings, err := splitSDROutput(output)
+	if err != nil {
+		return err
+	}
+	config := target.config
+	for _, data := range labelSensors(config, readings) {
+		data = normalizeSensorUnits(data)
+		if data.Type != "degreesC" {
+			continue
+		}
+		if matchAny(config.inletRegexps, data.Name) || matchAny(config.exhaustRegexps, data.Name) ||
+			matchAny(builtinInletRegexps, data.Name) || matchAny(builtinExhaustRegexps, data.Name) {
+			s.temperatures.WithLabelValues(data.labelName()).Observe(data.Value)
+		}
+	}
+	return nil
+}
+
+func (s *sampler) run(interval time.Duration) {
+	for {
+		s.sample()
+		time.Sleep(interval)
+	}
+}
+
+// startSampler starts sampling the local host every interval.
+func startSampler(config *SafeConfig, interval, window time.Duration) {
+	s := newSampler(config, window)
+	prometheus.MustRegister(s)
+	log.Infof("Sampling local power and temperatures every %s", interval)
+	go s.run(interval)
+}
@@ -0,0 +1,14 @@
+//go:build nosensor || nodcmi
+// +build nosensor nodcmi
+
+package main
+
+import (
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+func startSampler(config *SafeConfig, interval, window time.Duration) {
+	log.Fatalf("The sampler needs the sensor and dcmi-power collectors, which aren't compiled in")
+}
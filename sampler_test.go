@@ -0,0 +1,70 @@
+//go:build !nosensor && !nodcmi
+// +build !nosensor,!nodcmi
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestSampler(t *testing.T) {
+	*mockDir = e2eDir
+	defer func() { *mockDir = "" }()
+
+	config := NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	s := newSampler(config, time.Minute)
+	s.host = "dell"
+	s.sample()
+	s.sample()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(s)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() call failed. Reason: %s", err)
+	}
+	res := make(map[string]uint64)
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			name := family.GetName()
+			for _, label := range m.GetLabel() {
+				name += "_" + label.GetValue()
+			}
+			res[name] = m.GetSummary().GetSampleCount()
+		}
+	}
+	expect := map[string]uint64{
+		"ipmi_sampled_power_watts":                     2,
+		"ipmi_sampled_temperature_celsius_InletTemp":   2,
+		"ipmi_sampled_temperature_celsius_ExhaustTemp": 2,
+	}
+	for name, count := range expect {
+		if res[name] != count {
+			t.Errorf("Sample count check failed for %s.\n Expect: %d\n Got: %d", name, count, res[name])
+		}
+	}
+	if _, ok := res["ipmi_sampled_temperature_celsius_Temp"]; ok {
+		t.Errorf("Temperature without inlet or exhaust alias was sampled")
+	}
+}
+
+func TestSamplerDisableLocal(t *testing.T) {
+	*mockDir = e2eDir
+	defer func() { *mockDir = "" }()
+
+	config := NewSafeConfig(&Config{DisableLocal: true, Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	s := newSampler(config, time.Minute)
+	s.sample()
+
+	var m dto.Metric
+	if err := s.errors.Write(&m); err != nil {
+		t.Fatalf("Write() call failed. Reason: %s", err)
+	}
+	if got := m.GetCounter().GetValue(); got != 0 {
+		t.Errorf("Sampler errors check failed with local collection disabled.\n Expect: 0\n Got: %v", got)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/log"
+)
+
+// snapshotConfig configures a one-off collection of targets into an
+// OpenMetrics file, for sites that can neither be scraped nor push metrics.
+type snapshotConfig struct {
+	targets []string
+	// local includes the host the exporter runs on.
+	local bool
+	// module is used for all targets if set, otherwise the module rules
+	// select the module of every target.
+	module string
+	dir    string
+	// compress writes the snapshot gzip compressed.
+	compress bool
+	// concurrency is the number of targets collected at the same time.
+	concurrency int
+}
+
+// snapshotImportNote is written next to every snapshot and explains how to
+// import it into Prometheus.
+const snapshotImportNote = `This is an OpenMetrics snapshot of IPMI metrics written by
+ipmitool_exporter at %s. Every sample carries the time of the
+snapshot and the target and module labels.
+
+Import it into the data directory of a Prometheus server with:
+
+%s    promtool tsdb create-blocks-from openmetrics %s <prometheus data dir>
+
+Restart Prometheus afterwards, or copy the created blocks into the data
+directory of a running server. Prometheus only picks up blocks within its
+retention time.
+`
+
+// runSnapshot collects the targets of c once and writes the metrics to a
+// timestamped OpenMetrics file in c.dir. It returns the path of the file.
+func runSnapshot(c snapshotConfig, conf *SafeConfig, now time.Time) (string, error) {
+	targets := c.targets
+	if c.local {
+		targets = append([]string{targetLocal}, targets...)
+	}
+	if len(targets) == 0 {
+		return "", fmt.Errorf("no targets to collect")
+	}
+
+	families, err := gatherSnapshot(targets, c, conf)
+	if err != nil {
+		return "", err
+	}
+	setMissingTimestamps(families, now)
+
+	name := "ipmi-snapshot-" + now.UTC().Format("20060102T150405Z") + ".om"
+	if c.compress {
+		name += ".gz"
+	}
+	path := filepath.Join(c.dir, name)
+	if err := writeSnapshot(path, families, c.compress); err != nil {
+		return "", err
+	}
+
+	decompress := ""
+	if c.compress {
+		decompress = fmt.Sprintf("    gunzip %s\n", name)
+		name = name[:len(name)-len(".gz")]
+	}
+	note := fmt.Sprintf(snapshotImportNote, now.UTC().Format(time.RFC3339), decompress, name)
+	if err := ioutil.WriteFile(path+".README", []byte(note), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// gatherSnapshot collects every target, labelled with its name and module,
+// and merges the metric families of all targets.
+func gatherSnapshot(targets []string, c snapshotConfig, conf *SafeConfig) ([]*dto.MetricFamily, error) {
+	var (
+		mtx    sync.Mutex
+		merged = make(map[string]*dto.MetricFamily)
+		wg     sync.WaitGroup
+		work   = make(chan string)
+	)
+	concurrency := c.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for target := range work {
+				module := conf.ModuleForTarget(target, c.module)
+				registry := prometheus.NewRegistry()
+				labels := prometheus.Labels{"target": targetName(target), "module": module}
+				prometheus.WrapRegistererWith(labels, registry).MustRegister(collector{target: target, module: module, config: conf})
+				families, err := registry.Gather()
+				if err != nil {
+					// Gather returns what it could collect along with the error.
+					log.Warnf("Error gathering metrics of %s: %s", targetName(target), err)
+				}
+				mtx.Lock()
+				for _, mf := range families {
+					if m, ok := merged[mf.GetName()]; ok {
+						m.Metric = append(m.Metric, mf.Metric...)
+					} else {
+						merged[mf.GetName()] = mf
+					}
+				}
+				mtx.Unlock()
+				log.Infof("Collected %s with module %s", targetName(target), module)
+			}
+		}()
+	}
+	for _, target := range targets {
+		work <- target
+	}
+	close(work)
+	wg.Wait()
+
+	if len(merged) == 0 {
+		return nil, fmt.Errorf("no metrics collected")
+	}
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	families := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		families = append(families, merged[name])
+	}
+	return families, nil
+}
+
+// setMissingTimestamps attaches now to every sample without a timestamp, as
+// promtool only imports samples with timestamps.
+func setMissingTimestamps(families []*dto.MetricFamily, now time.Time) {
+	ts := now.UnixNano() / int64(time.Millisecond)
+	for _, mf := range families {
+		for _, m := range mf.Metric {
+			if m.TimestampMs == nil {
+				m.TimestampMs = &ts
+			}
+		}
+	}
+}
+
+func writeSnapshot(path string, families []*dto.MetricFamily, compress bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToOpenMetrics(w, mf); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if _, err := expfmt.FinalizeOpenMetrics(w); err != nil {
+		f.Close()
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSnapshot(t *testing.T) {
+	*mockDir = e2eDir
+	defer func() { *mockDir = "" }()
+	dir, err := ioutil.TempDir("", "ipmitool_exporter")
+	if err != nil {
+		t.Fatalf("Creating snapshot directory failed. Reason: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := NewSafeConfig(&Config{Modules: map[string]IPMIConfig{"default": defaultConfig()}})
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	path, err := runSnapshot(snapshotConfig{targets: []string{"dell", "kontron"}, dir: dir, compress: true, concurrency: 2}, conf, now)
+	if err != nil {
+		t.Fatalf("runSnapshot() call failed. Reason: %s", err)
+	}
+	if !strings.HasSuffix(path, "ipmi-snapshot-20260102T030405Z.om.gz") {
+		t.Errorf("Snapshot file name check failed.\n Expect: ipmi-snapshot-20260102T030405Z.om.gz\n Got: %s", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Opening snapshot failed. Reason: %s", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("Decompressing snapshot failed. Reason: %s", err)
+	}
+	data, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Reading snapshot failed. Reason: %s", err)
+	}
+	snapshot := string(data)
+	for _, expect := range []string{
+		`ipmi_up{collector="sensor",module="default",target="dell"} 1.0 1.767323045e+09`,
+		`ipmi_up{collector="sensor",module="default",target="kontron"} 1.0 1.767323045e+09`,
+	} {
+		if !strings.Contains(snapshot, expect) {
+			t.Errorf("Snapshot check failed.\n Expect: %s\n Got:\n%s", expect, snapshot)
+		}
+	}
+	if strings.Count(snapshot, "# TYPE ipmi_up ") != 1 || !strings.HasSuffix(snapshot, "# EOF\n") {
+		t.Errorf("Snapshot is not valid OpenMetrics:\n%s", snapshot)
+	}
+
+	note, err := ioutil.ReadFile(path + ".README")
+	if err != nil || !strings.Contains(string(note), "promtool tsdb create-blocks-from openmetrics ipmi-snapshot-20260102T030405Z.om ") {
+		t.Errorf("Import note check failed. Got: %s (error: %v)", note, err)
+	}
+
+	if _, err := runSnapshot(snapshotConfig{dir: dir}, conf, now); err == nil {
+		t.Errorf("Snapshot without targets was written")
+	}
+}
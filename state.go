@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+// lastScrapeFile records when a target was last scraped, for the cleanup of
+// departed targets.
+const lastScrapeFile = "last_scrape"
+
+// stateStore persists per-target data across restarts of the exporter. The
+// layout of the state directory is
+//
+//	<dir>/<target>/last_scrape   Unix time of the last scrape of the target
+//	<dir>/<target>/<name>.json   data saved under name, e.g. vendor.json
+//
+// where <target> is "remote_" followed by the escaped target name, or
+// "local" for the local target. Directories of targets that weren't scraped within the retention
+// period are removed by cleanup. Without a directory, nothing is persisted.
+type stateStore struct {
+	dir string
+}
+
+var targetState = &stateStore{}
+
+// targetDirName returns the directory name used for target in the state
+// directory. Remote targets are prefixed, so that a remote target named
+// "local" doesn't share the state of the local target.
+func targetDirName(target string) string {
+	if targetIsLocal(target) {
+		return "local"
+	}
+	return "remote_" + escapeTargetName(target)
+}
+
+// escapeTargetName escapes target for use as a single path element. Besides
+// separators, it escapes the dot segments "." and "..", which would refer to
+// the directory itself or its parent.
+func escapeTargetName(target string) string {
+	name := url.PathEscape(target)
+	if name == "." || name == ".." {
+		return strings.ReplaceAll(name, ".", "%2E")
+	}
+	return name
+}
+
+func (s *stateStore) enabled() bool {
+	return s.dir != ""
+}
+
+func (s *stateStore) path(target, name string) string {
+	return filepath.Join(s.dir, targetDirName(target), name)
+}
+
+// write atomically replaces the file name of target with data.
+func (s *stateStore) write(target, name string, data []byte) error {
+	dir := filepath.Join(s.dir, targetDirName(target))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+// load reads the data saved under name for target into v. It returns false
+// if nothing was saved or the store is disabled.
+func (s *stateStore) load(target, name string, v interface{}) (bool, error) {
+	if !s.enabled() {
+		return false, nil
+	}
+	data, err := ioutil.ReadFile(s.path(target, name+".json"))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// save persists v under name for target.
+func (s *stateStore) save(target, name string, v interface{}) error {
+	if !s.enabled() {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.write(target, name+".json", data)
+}
+
+// touch records a scrape of target at now.
+func (s *stateStore) touch(target string, now time.Time) {
+	if !s.enabled() {
+		return
+	}
+	if err := s.write(target, lastScrapeFile, []byte(strconv.FormatInt(now.Unix(), 10))); err != nil {
+		log.Errorf("Failed to record scrape of %s in state directory: %s", targetName(target), err)
+	}
+}
+
+// cleanup removes the state of targets that weren't scraped since before.
+// Directories without a readable last_scrape file are left alone.
+func (s *stateStore) cleanup(before time.Time) {
+	if !s.enabled() {
+		return
+	}
+	dirs, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		log.Errorf("Failed to read state directory: %s", err)
+		return
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(s.dir, dir.Name(), lastScrapeFile))
+		if err != nil {
+			continue
+		}
+		lastScrape, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil || !time.Unix(lastScrape, 0).Before(before) {
+			continue
+		}
+		log.Infof("Removing state of departed target %s", dir.Name())
+		if err := os.RemoveAll(filepath.Join(s.dir, dir.Name())); err != nil {
+			log.Errorf("Failed to remove state of %s: %s", dir.Name(), err)
+		}
+	}
+}
+
+// run periodically removes the state of targets that weren't scraped within
+// retention.
+func (s *stateStore) run(retention time.Duration) {
+	interval := time.Hour
+	if retention < interval {
+		interval = retention
+	}
+	for {
+		s.cleanup(time.Now().Add(-retention))
+		time.Sleep(interval)
+	}
+}
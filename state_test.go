@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipmitool_exporter")
+	if err != nil {
+		t.Fatalf("Creating state directory failed. Reason: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	s := &stateStore{dir: dir}
+
+	saved := savedVendor{Vendor: "dell", Manufacturer: "DELL Inc"}
+	if err := s.save("10.0.0.1", "vendor", saved); err != nil {
+		t.Fatalf("save() call failed. Reason: %s", err)
+	}
+	var loaded savedVendor
+	ok, err := s.load("10.0.0.1", "vendor", &loaded)
+	if err != nil || !ok || loaded != saved {
+		t.Errorf("State round trip check failed.\n Expect: %v\n Got: %v (found: %v, error: %v)", saved, loaded, ok, err)
+	}
+	if ok, _ := s.load("10.0.0.2", "vendor", &loaded); ok {
+		t.Errorf("State of unknown target was loaded")
+	}
+
+	now := time.Now()
+	s.touch("10.0.0.1", now.Add(-48*time.Hour))
+	s.touch(targetLocal, now)
+	s.cleanup(now.Add(-24 * time.Hour))
+	if _, err := os.Stat(filepath.Join(dir, "remote_10.0.0.1")); !os.IsNotExist(err) {
+		t.Errorf("State of departed target was not removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "local", lastScrapeFile)); err != nil {
+		t.Errorf("State of active target was removed")
+	}
+}
+
+func TestTargetDirName(t *testing.T) {
+	for _, tc := range []struct {
+		target, expect string
+	}{
+		{"", "local"},
+		{"local", "remote_local"},
+		{"10.0.0.1", "remote_10.0.0.1"},
+		{"..", "remote_%2E%2E"},
+		{"../etc", "remote_..%2Fetc"},
+	} {
+		if got := targetDirName(tc.target); got != tc.expect {
+			t.Errorf("Target directory name check failed for %q.\n Expect: %s\n Got: %s", tc.target, tc.expect, got)
+		}
+	}
+	for _, target := range []string{".", ".."} {
+		if got := filepath.Base(mockTargetDir("testdata", target)); got == target {
+			t.Errorf("Mock directory of %q isn't escaped: %s", target, got)
+		}
+	}
+}
+
+func TestStateStoreDisabled(t *testing.T) {
+	s := &stateStore{}
+	if err := s.save("10.0.0.1", "vendor", savedVendor{}); err != nil {
+		t.Errorf("save() call failed on disabled store. Reason: %s", err)
+	}
+	if ok, err := s.load("10.0.0.1", "vendor", &savedVendor{}); ok || err != nil {
+		t.Errorf("load() on disabled store check failed.\n Expect: false, <nil>\n Got: %v, %v", ok, err)
+	}
+}
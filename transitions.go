@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+// transitions is the last observed value of a target, how often it changed
+// since counting started and when the last change was observed.
+type transitions struct {
+	Last    string    `json:"last"`
+	Count   float64   `json:"count"`
+	Changed time.Time `json:"changed"`
+}
+
+// transitionCounter counts changes of a value per target across scrapes,
+// e.g. the chassis power state. The counts are persisted in the state
+// directory under name, so that they survive restarts and changes while the
+// exporter was down are counted as well.
+type transitionCounter struct {
+	sync.Mutex
+	name    string
+	targets map[string]*transitions
+	expiry  targetExpiry
+	// onChange is called with the old and new value when a change is
+	// observed, if set.
+	onChange func(target, from, to string, changed time.Time)
+}
+
+func newTransitionCounter(name string) *transitionCounter {
+	return &transitionCounter{name: name, targets: make(map[string]*transitions)}
+}
+
+// observe records value for target and returns the updated transitions.
+func (c *transitionCounter) observe(target, value string) transitions {
+	c.Lock()
+	defer c.Unlock()
+
+	for _, expired := range c.expiry.touch(target, time.Now()) {
+		delete(c.targets, expired)
+	}
+	t, ok := c.targets[target]
+	if !ok {
+		t = &transitions{}
+		if _, err := targetState.load(target, c.name, t); err != nil {
+			log.Errorf("Failed to load saved %s of %s: %s", c.name, targetName(target), err)
+		}
+		c.targets[target] = t
+	}
+	if t.Last == value {
+		return *t
+	}
+	if t.Last != "" {
+		log.Infof("The %s of %s changed from %s to %s", c.name, targetName(target), t.Last, value)
+		t.Count++
+		t.Changed = time.Now()
+		if c.onChange != nil {
+			c.onChange(target, t.Last, value, t.Changed)
+		}
+	}
+	t.Last = value
+	if err := targetState.save(target, c.name, t); err != nil {
+		log.Errorf("Failed to save %s of %s: %s", c.name, targetName(target), err)
+	}
+	return *t
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransitionCounter(t *testing.T) {
+	c := newTransitionCounter("power_state")
+	var changes []string
+	c.onChange = func(target, from, to string, changed time.Time) {
+		changes = append(changes, from+"->"+to)
+	}
+	var res transitions
+	for _, value := range []string{"1", "1", "0", "0", "1"} {
+		res = c.observe("localhost", value)
+	}
+	if res.Count != 2 {
+		t.Errorf("Transition count check failed.\n Expect: 2\n Got: %v", res.Count)
+	}
+	if res.Changed.IsZero() {
+		t.Errorf("Time of the last transition was not recorded")
+	}
+	if strings.Join(changes, ",") != "1->0,0->1" {
+		t.Errorf("Transition callback check failed.\n Expect: 1->0,0->1\n Got: %v", changes)
+	}
+	if res := c.observe("otherhost", "0"); res.Count != 0 || !res.Changed.IsZero() {
+		t.Errorf("Transition check failed for first observation.\n Expect: 0 changes\n Got: %+v", res)
+	}
+}
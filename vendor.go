@@ -0,0 +1,253 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// vendorProfile adjusts a module to the BMCs of a vendor.
+type vendorProfile struct {
+	name string
+	// manufacturer is matched against the manufacturer reported by the BMC.
+	manufacturer *regexp.Regexp
+	// Sensor name patterns added to the inlet_sensors and exhaust_sensors of
+	// the module.
+	inletSensors   []string
+	exhaustSensors []string
+	// Mappings of the vendor's discrete sensor names to metric families.
+	discreteSensors []discreteSensorMapping
+	// Collectors that don't work with the vendor's BMCs, e.g. fwum, which is
+	// specific to Kontron. They are only skipped in modules relying on the
+	// default collectors.
+	skipCollectors []string
+	// psuLineSensors enables the generic `Voltage N` and `Current N` sensor
+	// names as the input line sensors of PSU N.
+	psuLineSensors bool
+}
+
+var (
+	vendorProfiles = []vendorProfile{
+		{
+			name:           "dell",
+			manufacturer:   regexp.MustCompile(`(?i)\bdell\b`),
+			inletSensors:   []string{`(?i)^SystemBoardInletTemp$`},
+			exhaustSensors: []string{`(?i)^SystemBoardExhaustTemp$`},
+			discreteSensors: []discreteSensorMapping{
+				builtinDiscreteSensor(`^Intrusion$`, "chassis_intrusion"),
+			},
+			skipCollectors: []string{"fwum"},
+			psuLineSensors: true,
+		},
+		{
+			name:           "hpe",
+			manufacturer:   regexp.MustCompile(`(?i)hewlett|\bhpe?\b`),
+			exhaustSensors: []string{`(?i)^\d+-SysExhaust\d*$`},
+			skipCollectors: []string{"fwum"},
+		},
+		{
+			name:           "lenovo",
+			manufacturer:   regexp.MustCompile(`(?i)lenovo|\bibm\b`),
+			skipCollectors: []string{"fwum"},
+		},
+		{
+			name:           "supermicro",
+			manufacturer:   regexp.MustCompile(`(?i)super\s*micro`),
+			skipCollectors: []string{"fwum"},
+		},
+		{
+			name:         "kontron",
+			manufacturer: regexp.MustCompile(`(?i)kontron`),
+		},
+	}
+
+	vendorManufacturerRegex = regexp.MustCompile(`(?m)^Manufacturer\sName\s*:\s*(.*?)\s*$`)
+
+	vendorInfoDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "vendor", "info"),
+		"Constant metric with value '1' providing the vendor profile applied to the target.",
+		[]string{"vendor", "manufacturer"},
+		nil,
+	)
+
+	targetVendors = &vendorCache{targets: make(map[string]detectedVendor)}
+)
+
+// vendorProfileByName returns the built-in profile with the given name.
+func vendorProfileByName(name string) (vendorProfile, bool) {
+	for _, p := range vendorProfiles {
+		if strings.EqualFold(p.name, name) {
+			return p, true
+		}
+	}
+	return vendorProfile{}, false
+}
+
+// vendorProfileNames returns the names of all built-in profiles.
+func vendorProfileNames() []string {
+	var names []string
+	for _, p := range vendorProfiles {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// vendorProfileForManufacturer returns the profile matching the manufacturer
+// reported by a BMC.
+func vendorProfileForManufacturer(manufacturer string) (vendorProfile, bool) {
+	for _, p := range vendorProfiles {
+		if p.manufacturer.MatchString(manufacturer) {
+			return p, true
+		}
+	}
+	return vendorProfile{}, false
+}
+
+// apply returns a copy of config adjusted to the vendor. Patterns that fail
+// to compile are logged and ignored.
+func (p vendorProfile) apply(config IPMIConfig) IPMIConfig {
+	if !config.collectorsListed {
+		var collectors []string
+		for _, c := range config.Collectors {
+			if !containsString(p.skipCollectors, c) {
+				collectors = append(collectors, c)
+			}
+		}
+		config.Collectors = collectors
+	}
+
+	inlet, err := compileRegexps(p.inletSensors)
+	if err != nil {
+		log.Errorf("Invalid inlet sensor pattern in vendor profile %s: %s", p.name, err)
+	}
+	exhaust, err := compileRegexps(p.exhaustSensors)
+	if err != nil {
+		log.Errorf("Invalid exhaust sensor pattern in vendor profile %s: %s", p.name, err)
+	}
+	config.inletRegexps = append(append([]*regexp.Regexp{}, config.inletRegexps...), inlet...)
+	config.exhaustRegexps = append(append([]*regexp.Regexp{}, config.exhaustRegexps...), exhaust...)
+	config.discreteSensors = append(append([]discreteSensorMapping{}, config.discreteSensors...), p.discreteSensors...)
+	config.psuLineSensors = config.psuLineSensors || p.psuLineSensors
+	return config
+}
+
+type detectedVendor struct {
+	profile      vendorProfile
+	found        bool
+	manufacturer string
+	// retry is when a failed detection is retried, zero if it succeeded.
+	retry time.Time
+}
+
+// vendorDetectionRetry is how long a failed vendor detection is cached, so
+// that unreachable BMCs don't pay the bmc info timeout on every scrape.
+const vendorDetectionRetry = 10 * time.Minute
+
+// savedVendor is the vendor detection result persisted in the state
+// directory. Vendor is empty if no profile matched.
+type savedVendor struct {
+	Vendor       string `json:"vendor"`
+	Manufacturer string `json:"manufacturer"`
+}
+
+// vendorCache remembers the vendor detected per target, so that detection
+// only runs on the first scrape.
+type vendorCache struct {
+	sync.Mutex
+	targets map[string]detectedVendor
+	expiry  targetExpiry
+}
+
+// detect returns the vendor of target, running bmc info on the first scrape
+// unless a result was saved in the state directory. Failed detections are
+// retried after vendorDetectionRetry.
+func (v *vendorCache) detect(target ipmiTarget) detectedVendor {
+	now := time.Now()
+	v.Lock()
+	for _, expired := range v.expiry.touch(target.host, now) {
+		delete(v.targets, expired)
+	}
+	detected, ok := v.targets[target.host]
+	v.Unlock()
+	if ok && (detected.retry.IsZero() || now.Before(detected.retry)) {
+		return detected
+	}
+	detected = detectedVendor{}
+
+	var saved savedVendor
+	if ok, err := targetState.load(target.host, "vendor", &saved); err != nil {
+		log.Errorf("Failed to load saved vendor of %s: %s", targetName(target.host), err)
+	} else if ok {
+		detected.manufacturer = saved.Manufacturer
+		detected.profile, detected.found = vendorProfileByName(saved.Vendor)
+		v.Lock()
+		v.targets[target.host] = detected
+		v.Unlock()
+		return detected
+	}
+
+	output, err := ipmitoolOutput(target, []string{"bmc", "info"})
+	if err != nil {
+		log.Debugf("Failed to detect vendor of %s: %s", targetName(target.host), err)
+		v.Lock()
+		v.targets[target.host] = detectedVendor{retry: now.Add(vendorDetectionRetry)}
+		v.Unlock()
+		return detectedVendor{}
+	}
+	if m := vendorManufacturerRegex.FindStringSubmatch(output); m != nil {
+		detected.manufacturer = m[1]
+		detected.profile, detected.found = vendorProfileForManufacturer(m[1])
+	}
+	if detected.found {
+		log.Infof("Detected vendor %s (%s) for %s", detected.profile.name, detected.manufacturer, targetName(target.host))
+	} else {
+		log.Infof("No vendor profile for %s (manufacturer %q)", targetName(target.host), detected.manufacturer)
+	}
+
+	saved = savedVendor{Vendor: detected.profile.name, Manufacturer: detected.manufacturer}
+	if err := targetState.save(target.host, "vendor", saved); err != nil {
+		log.Errorf("Failed to save vendor of %s: %s", targetName(target.host), err)
+	}
+
+	v.Lock()
+	v.targets[target.host] = detected
+	v.Unlock()
+	return detected
+}
+
+// applyVendorProfile adjusts the module config of target according to its
+// vendor setting and emits the applied profile.
+func applyVendorProfile(ch chan<- prometheus.Metric, target ipmiTarget) IPMIConfig {
+	var detected detectedVendor
+	switch strings.ToLower(target.config.Vendor) {
+	case "none":
+		return target.config
+	case "auto", "":
+		detected = targetVendors.detect(target)
+	default:
+		detected.profile, detected.found = vendorProfileByName(target.config.Vendor)
+	}
+	if !detected.found {
+		return target.config
+	}
+	ch <- prometheus.MustNewConstMetric(
+		vendorInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		detected.profile.name, detected.manufacturer,
+	)
+	return detected.profile.apply(target.config)
+}
+
+func containsString(s []string, elm string) bool {
+	for _, a := range s {
+		if a == elm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestVendorProfileForManufacturer(t *testing.T) {
+	for manufacturer, expected := range map[string]string{
+		"Super Micro Computer Inc.":     "supermicro",
+		"DELL Inc":                      "dell",
+		"Hewlett Packard Enterprise":    "hpe",
+		"Lenovo":                        "lenovo",
+		"Kontron":                       "kontron",
+		"Unknown (0x1234)":              "",
+		"Quanta Computer Inc. (0x1c4c)": "",
+	} {
+		profile, _ := vendorProfileForManufacturer(manufacturer)
+		if profile.name != expected {
+			t.Errorf("Vendor profile check failed for '%s'.\n Expect: '%s'\n Got: '%s'", manufacturer, expected, profile.name)
+		}
+	}
+}
+
+func TestVendorProfileApply(t *testing.T) {
+	config := defaultConfig()
+	profile, _ := vendorProfileByName("dell")
+	res := profile.apply(config)
+	if strings.Join(res.Collectors, ",") != "sensor,fru,dcmi-power,power" {
+		t.Errorf("Vendor profile collectors check failed.\n Expect: sensor,fru,dcmi-power,power\n Got: %s", strings.Join(res.Collectors, ","))
+	}
+	if !matchAny(res.inletRegexps, "SystemBoardInletTemp") {
+		t.Errorf("Vendor profile inlet sensor pattern not applied")
+	}
+	if len(config.inletRegexps) != 0 || len(config.Collectors) != 5 {
+		t.Errorf("Vendor profile modified the module config")
+	}
+
+	if err := yaml.Unmarshal([]byte("collectors: [sensor, fwum]"), &config); err != nil {
+		t.Fatalf("Module with collectors not loaded.\n Error is: %s", err)
+	}
+	if res := profile.apply(config); strings.Join(res.Collectors, ",") != "sensor,fwum" {
+		t.Errorf("Vendor profile collectors check failed for listed collectors.\n Expect: sensor,fwum\n Got: %s", strings.Join(res.Collectors, ","))
+	}
+}
+
+func TestVendorDetectionFailureCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ipmitool_exporter")
+	if err != nil {
+		t.Fatalf("Creating mock directory failed. Reason: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	*mockDir = dir
+	defer func() { *mockDir = "" }()
+
+	v := &vendorCache{targets: make(map[string]detectedVendor)}
+	target := ipmiTarget{host: "10.0.0.1"}
+	if detected := v.detect(target); detected.found {
+		t.Fatalf("Vendor detected without bmc info output")
+	}
+
+	// The BMC answers now, but the failure is cached until the retry.
+	if err := os.MkdirAll(filepath.Join(dir, "10.0.0.1"), 0755); err != nil {
+		t.Fatalf("Creating mock directory failed. Reason: %s", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "10.0.0.1", "bmc_info.txt"), []byte("Manufacturer Name : DELL Inc\n"), 0644); err != nil {
+		t.Fatalf("Writing mock output failed. Reason: %s", err)
+	}
+	if detected := v.detect(target); detected.found {
+		t.Errorf("Failed vendor detection was retried before %s", vendorDetectionRetry)
+	}
+
+	v.targets[target.host] = detectedVendor{retry: time.Now().Add(-time.Second)}
+	if detected := v.detect(target); !detected.found || detected.profile.name != "dell" {
+		t.Errorf("Vendor detection retry check failed.\n Expect: dell\n Got: %+v", detected)
+	}
+}
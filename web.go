@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/common/log"
+	"golang.org/x/crypto/bcrypt"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// webConfig is the Go representation of the web config file.
+type webConfig struct {
+	Listeners []listenerConfig `yaml:"listeners"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// listenerConfig configures an address the exporter listens on.
+type listenerConfig struct {
+	Address string `yaml:"address"`
+	// Paths served on this listener, e.g. "/metrics". All endpoints are
+	// served if empty.
+	Endpoints []string `yaml:"endpoints"`
+
+	// Serve HTTPS with this certificate and key, and require client
+	// certificates signed by the CAs in ClientCAFile if set.
+	TLSCertFile  string `yaml:"tls_cert_file"`
+	TLSKeyFile   string `yaml:"tls_key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+
+	// Require HTTP basic authentication with one of these users, mapped to
+	// the bcrypt hash of their password.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+
+	// Catches all undefined fields and must be empty after parsing.
+	XXX map[string]interface{} `yaml:",inline"`
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (c *webConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain webConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	return checkOverflow(c.XXX, "web config")
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (l *listenerConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain listenerConfig
+	if err := unmarshal((*plain)(l)); err != nil {
+		return err
+	}
+	if err := checkOverflow(l.XXX, "listeners"); err != nil {
+		return err
+	}
+	if l.Address == "" {
+		return fmt.Errorf("listener without address")
+	}
+	if (l.TLSCertFile == "") != (l.TLSKeyFile == "") {
+		return fmt.Errorf("listener %s: tls_cert_file and tls_key_file must be set together", l.Address)
+	}
+	if l.ClientCAFile != "" && l.TLSCertFile == "" {
+		return fmt.Errorf("listener %s: client_ca_file requires tls_cert_file", l.Address)
+	}
+	for user, hash := range l.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return fmt.Errorf("listener %s: password of user %s is not a bcrypt hash: %s", l.Address, user, err)
+		}
+	}
+	return nil
+}
+
+// loadWebConfig reads the web config file.
+func loadWebConfig(file string) (*webConfig, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c := &webConfig{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// handler wraps h with the endpoint restrictions and authentication of the
+// listener.
+func (l listenerConfig) handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(l.BasicAuthUsers) > 0 && !l.authenticated(r) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ipmitool_exporter"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if len(l.Endpoints) > 0 && !containsString(l.Endpoints, r.URL.Path) {
+			http.NotFound(w, r)
+			return
+		}
+		if user := l.user(r); user != "" {
+			r = r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (l listenerConfig) authenticated(r *http.Request) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	expect, ok := l.BasicAuthUsers[user]
+	if !ok {
+		return false
+	}
+	return authCache.compare(expect, password)
+}
+
+// bcryptCache remembers the passwords that matched a bcrypt hash, as
+// comparing them takes tens of milliseconds on purpose, which would add up
+// over frequent scrapes. Only a SHA-256 hash of every hash and password pair
+// is kept.
+type bcryptCache struct {
+	sync.Mutex
+	matched map[[sha256.Size]byte]bool
+}
+
+var authCache = &bcryptCache{matched: make(map[[sha256.Size]byte]bool)}
+
+// compare returns true if password matches the bcrypt hash.
+func (c *bcryptCache) compare(hash, password string) bool {
+	key := sha256.Sum256([]byte(hash + "\x00" + password))
+	c.Lock()
+	matched := c.matched[key]
+	c.Unlock()
+	if matched {
+		return true
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false
+	}
+	c.Lock()
+	c.matched[key] = true
+	c.Unlock()
+	return true
+}
+
+// user returns the name of the authenticated user of an accepted request:
+// the basic authentication user or the common name of the verified client
+// certificate. It returns an empty string if the listener doesn't
+// authenticate requests.
+func (l listenerConfig) user(r *http.Request) string {
+	if len(l.BasicAuthUsers) > 0 {
+		user, _, _ := r.BasicAuth()
+		return user
+	}
+	if l.ClientCAFile != "" && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	return ""
+}
+
+type contextKey int
+
+// userContextKey holds the authenticated user in the request context.
+const userContextKey contextKey = iota
+
+// requestUser returns the authenticated user of r, or an empty string if the
+// listener doesn't authenticate requests.
+func requestUser(r *http.Request) string {
+	user, _ := r.Context().Value(userContextKey).(string)
+	return user
+}
+
+// actionHandlers maps the paths of the endpoints changing the state of BMCs,
+// served with web.enable-actions, to their handlers. Actions register
+// themselves from actions.go, which the noactions build tag leaves out.
+var actionHandlers = make(map[string]http.HandlerFunc)
+
+// serve listens on the address of the listener and serves h.
+func (l listenerConfig) serve(h http.Handler) error {
+	server := &http.Server{Addr: l.Address, Handler: l.handler(h)}
+	if l.TLSCertFile == "" {
+		log.Infof("Listening on %s", l.Address)
+		return server.ListenAndServe()
+	}
+	if l.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(l.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in %s", l.ClientCAFile)
+		}
+		server.TLSConfig = &tls.Config{ClientCAs: pool, ClientAuth: tls.RequireAndVerifyClientCert}
+	}
+	log.Infof("Listening on %s (TLS)", l.Address)
+	return server.ListenAndServeTLS(l.TLSCertFile, l.TLSKeyFile)
+}
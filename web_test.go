@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestListenerHandler(t *testing.T) {
+	c := &webConfig{}
+	err := yaml.Unmarshal([]byte(`listeners:
+- address: 127.0.0.1:9105
+  endpoints: [/-/reload]
+  basic_auth_users:
+    # "secret"
+    agent: $2a$10$ifjhQvc4Nx5CsWrgBDRgkuGa2vLqzeD2qtIcIKT8c8EVBotXIO1vi
+`), c)
+	if err != nil {
+		t.Fatalf("Web config not loaded.\n Error is: %s", err)
+	}
+	h := c.Listeners[0].handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, req := range []struct {
+		path, user, password string
+		expect               int
+	}{
+		{"/-/reload", "agent", "secret", http.StatusOK},
+		{"/-/reload", "agent", "wrong", http.StatusUnauthorized},
+		{"/-/reload", "", "", http.StatusUnauthorized},
+		{"/metrics", "agent", "secret", http.StatusNotFound},
+	} {
+		r := httptest.NewRequest("GET", req.path, nil)
+		if req.user != "" {
+			r.SetBasicAuth(req.user, req.password)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != req.expect {
+			t.Errorf("Listener status check failed for %s as %q.\n Expect: %d\n Got: %d", req.path, req.user, req.expect, w.Code)
+		}
+	}
+}
+
+func TestBadWebConfig(t *testing.T) {
+	for _, config := range []string{
+		"listeners:\n- endpoints: [/metrics]\n",
+		"listeners:\n- address: :9104\n  tls_cert_file: cert.pem\n",
+		"listeners:\n- address: :9104\n  basic_auth_users:\n    agent: secret\n",
+		// An unsalted SHA-256 hash of "secret".
+		"listeners:\n- address: :9104\n  basic_auth_users:\n    agent: 2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b\n",
+	} {
+		if err := yaml.Unmarshal([]byte(config), &webConfig{}); err == nil {
+			t.Errorf("Bad web config was loaded: %q", config)
+		}
+	}
+}
+
+func TestListenerUser(t *testing.T) {
+	var user string
+	h := listenerConfig{
+		BasicAuthUsers: map[string]string{"agent": "$2a$10$ifjhQvc4Nx5CsWrgBDRgkuGa2vLqzeD2qtIcIKT8c8EVBotXIO1vi"},
+	}.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = requestUser(r)
+	}))
+	r := httptest.NewRequest("POST", "/actions/intrusion-reset", nil)
+	r.SetBasicAuth("agent", "secret")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if user != "agent" {
+		t.Errorf("Authenticated user check failed.\n Expect: agent\n Got: %q", user)
+	}
+
+	user = "unset"
+	listenerConfig{}.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = requestUser(r)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/actions/intrusion-reset", nil))
+	if user != "" {
+		t.Errorf("User check failed for listener without authentication.\n Expect: \"\"\n Got: %q", user)
+	}
+}
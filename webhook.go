@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/common/log"
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// postEvent sends event as JSON to the events webhook, if configured. It
+// doesn't block the scrape that observed the event.
+func postEvent(event interface{}) {
+	if *eventsWebhookURL == "" {
+		return
+	}
+	go func(url string) {
+		if err := postWebhook(url, event); err != nil {
+			log.Errorf("Error posting event to %s: %s", url, err)
+		}
+	}(*eventsWebhookURL)
+}
+
+func postWebhook(url string, event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := webhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostWebhook(t *testing.T) {
+	var res map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+			t.Errorf("Decoding webhook body failed. Reason: %s", err)
+		}
+	}))
+	defer server.Close()
+
+	if err := postWebhook(server.URL, map[string]string{"direction": "off"}); err != nil {
+		t.Fatalf("postWebhook() call failed. Reason: %s", err)
+	}
+	if res["direction"] != "off" {
+		t.Errorf("Webhook body check failed.\n Expect: map[direction:off]\n Got: %v", res)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer failing.Close()
+	if err := postWebhook(failing.URL, nil); err == nil {
+		t.Errorf("Webhook error status was accepted")
+	}
+}